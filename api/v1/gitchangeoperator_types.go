@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -75,6 +76,35 @@ type OperatorConfig struct {
 	// ProbeAddr is the address for health probe endpoint
 	// +optional
 	ProbeAddr string `json:"probeAddr,omitempty"`
+
+	// CommitAuthor is the default author of commits whose resource does not set one
+	// +optional
+	CommitAuthor *CommitIdentity `json:"commitAuthor,omitempty"`
+
+	// RepositoryCache keeps fetched repositories on a PersistentVolumeClaim so runs only fetch
+	// new commits instead of cloning the whole repository
+	// +optional
+	RepositoryCache *RepositoryCacheConfig `json:"repositoryCache,omitempty"`
+}
+
+// RepositoryCacheConfig defines the persistent repository cache of the operator
+type RepositoryCacheConfig struct {
+	// Size of the PersistentVolumeClaim created for the cache
+	// +kubebuilder:default="10Gi"
+	// +optional
+	Size resource.Quantity `json:"size,omitempty"`
+
+	// MaxSize above which the least recently used repositories are evicted, defaults to 80% of Size
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// StorageClassName of the PersistentVolumeClaim, the cluster default when empty
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// ExistingClaim mounts an existing PersistentVolumeClaim instead of creating one
+	// +optional
+	ExistingClaim string `json:"existingClaim,omitempty"`
 }
 
 // RBACConfig defines RBAC configuration
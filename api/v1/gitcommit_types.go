@@ -112,16 +112,31 @@ type RestAPIStatus struct {
 	FormattedOutput string `json:"formattedOutput,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.fanOut) || (has(self.repository) && has(self.authSecretRef))",message="repository and authSecretRef are required without fanOut"
 type GitCommitSpec struct {
-	Repository    string        `json:"repository"`
-	Branch        string        `json:"branch"`
+	// Repository is the URL of the repository to commit to. It may be left empty when FanOut
+	// lists or selects the repositories.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	Branch     string `json:"branch"`
+	// BaseBranch is the branch a Branch that does not exist yet is created from, the default
+	// branch of the repository when empty. It is ignored once Branch exists.
+	// +optional
+	BaseBranch    string        `json:"baseBranch,omitempty"`
 	Files         []File        `json:"files,omitempty"`
 	ResourceRefs  []ResourceRef `json:"resourceRefs,omitempty"`
 	CommitMessage string        `json:"commitMessage"`
-	AuthSecretRef string        `json:"authSecretRef"`
-	AuthSecretKey string        `json:"authSecretKey,omitempty"`
-	Encryption    *Encryption   `json:"encryption,omitempty"`
-	RestAPIs      []RestAPI     `json:"restAPIs,omitempty"`
+	// Template renders CommitMessage as a Go template. Without it the message is used as it is,
+	// including any "{{".
+	// +optional
+	Template bool `json:"template,omitempty"`
+	// AuthSecretRef names the secret with the credentials for Repository, and for the fan-out
+	// repositories that do not name their own
+	// +optional
+	AuthSecretRef string      `json:"authSecretRef,omitempty"`
+	AuthSecretKey string      `json:"authSecretKey,omitempty"`
+	Encryption    *Encryption `json:"encryption,omitempty"`
+	RestAPIs      []RestAPI   `json:"restAPIs,omitempty"`
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=43200
 	TTLMinutes *int `json:"ttlMinutes,omitempty"`
@@ -144,11 +159,303 @@ type GitCommitSpec struct {
 	// +kubebuilder:default=10
 	// +optional
 	MaxExecutionHistory *int `json:"maxExecutionHistory,omitempty"`
+
+	// ConflictStrategy controls what happens when the push is rejected because the branch
+	// moved on since it was cloned. Without it the first rejection fails the GitCommit, an
+	// empty conflictStrategy rebases with 5 attempts.
+	// +optional
+	ConflictStrategy *ConflictStrategy `json:"conflictStrategy,omitempty"`
+
+	// Signing signs the commit with an OpenPGP or SSH key stored in a secret
+	// +optional
+	Signing *Signing `json:"signing,omitempty"`
+
+	// Clone limits the history, branches and files fetched from the repository
+	// +optional
+	Clone *CloneOptions `json:"clone,omitempty"`
+
+	// EmptyCommitPolicy decides what happens when the files are already up to date. Skip records
+	// the execution as NoChanges without committing, Allow creates an empty commit.
+	// +kubebuilder:validation:Enum=Skip;Allow
+	// +kubebuilder:default=Skip
+	// +optional
+	EmptyCommitPolicy EmptyCommitPolicy `json:"emptyCommitPolicy,omitempty"`
+
+	// FanOut commits the same files to several repositories, listed explicitly or selected from
+	// an inventory of Secrets
+	// +optional
+	FanOut *FanOut `json:"fanOut,omitempty"`
+
+	// Tag tags the pushed commit and optionally publishes a GitHub release for the tag
+	// +optional
+	Tag *TagSpec `json:"tag,omitempty"`
+
+	CommitMetadata `json:",inline"`
+}
+
+// TagSpec creates a tag at the commit of a GitCommit once it was pushed. Nothing is tagged when
+// the repository was already up to date.
+type TagSpec struct {
+	// Name of the tag, rendered as a Go template with the data available to the commit message
+	// and the pushed commit as .commitSHA, e.g. v{{ .restAPIs.version.formattedOutput }}
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Message creates an annotated tag with this message, rendered like Name. Without a message
+	// the tag is lightweight.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Sign signs the tag with the key of spec.signing. Signed tags are always annotated, the
+	// message defaults to the tag name.
+	// +optional
+	Sign bool `json:"sign,omitempty"`
+
+	// Release publishes a GitHub release for the tag
+	// +optional
+	Release *ReleaseSpec `json:"release,omitempty"`
+}
+
+// ReleaseSpec describes the GitHub release published for a tag
+type ReleaseSpec struct {
+	// Name of the release, rendered like the tag name. Defaults to the tag name.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Body of the release, rendered like the tag name. It precedes the generated notes.
+	// +optional
+	Body string `json:"body,omitempty"`
+
+	// GenerateNotes lets GitHub generate the release notes from the changes since the
+	// previous release
+	// +optional
+	GenerateNotes bool `json:"generateNotes,omitempty"`
+
+	// +optional
+	Draft bool `json:"draft,omitempty"`
+
+	// +optional
+	Prerelease bool `json:"prerelease,omitempty"`
+}
+
+// FanOut commits the files of a GitCommit to every listed and selected repository in addition
+// to spec.repository. Each repository gets a commit of its own, reported in status.repositories.
+type FanOut struct {
+	// Repositories lists further repositories to commit to
+	// +optional
+	Repositories []RepositoryTarget `json:"repositories,omitempty"`
+
+	// Selector selects Secrets in the namespace of the GitCommit that make up the repository
+	// inventory. Each Secret holds the repository URL under the key "repository", optionally
+	// "branch" and "baseBranch", and the credentials for the repository.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// MaxConcurrency is the number of repositories committed to at the same time
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=32
+	// +kubebuilder:default=4
+	// +optional
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+
+	// FailurePolicy decides what happens when committing to a repository fails. Continue commits
+	// to all other repositories, FailFast starts no further commits after the first failure.
+	// +kubebuilder:validation:Enum=Continue;FailFast
+	// +kubebuilder:default=Continue
+	// +optional
+	FailurePolicy FanOutFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+type FanOutFailurePolicy string
+
+const (
+	FanOutFailurePolicyContinue FanOutFailurePolicy = "Continue"
+	FanOutFailurePolicyFailFast FanOutFailurePolicy = "FailFast"
+)
+
+// RepositoryTarget is a repository of a fan-out. Empty fields default to those of the GitCommit.
+type RepositoryTarget struct {
+	// Repository is the URL of the repository
+	Repository string `json:"repository"`
+
+	// Branch to commit to, defaults to spec.branch
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// BaseBranch a new Branch is created from, defaults to spec.baseBranch
+	// +optional
+	BaseBranch string `json:"baseBranch,omitempty"`
+
+	// AuthSecretRef names the secret with the credentials, defaults to spec.authSecretRef
+	// +optional
+	AuthSecretRef string `json:"authSecretRef,omitempty"`
+
+	// AuthSecretKey is the key of the credentials in the secret, defaults to spec.authSecretKey
+	// +optional
+	AuthSecretKey string `json:"authSecretKey,omitempty"`
+}
+
+type EmptyCommitPolicy string
+
+const (
+	EmptyCommitPolicySkip  EmptyCommitPolicy = "Skip"
+	EmptyCommitPolicyAllow EmptyCommitPolicy = "Allow"
+)
+
+// CommitMetadata sets the identities recorded on the commit and the trailers appended to its message
+type CommitMetadata struct {
+	// Author of the commit, defaults to the operator-wide author
+	// +optional
+	Author *CommitIdentity `json:"author,omitempty"`
+
+	// Committer of the commit, defaults to the author
+	// +optional
+	Committer *CommitIdentity `json:"committer,omitempty"`
+
+	// Trailers are appended to the commit message as "Key: value" lines, e.g. Co-authored-by
+	// +optional
+	Trailers []CommitTrailer `json:"trailers,omitempty"`
+
+	// SignOff appends a Signed-off-by trailer for the committer, as required by the DCO
+	// +optional
+	SignOff bool `json:"signOff,omitempty"`
+
+	// OriginTrailer appends a Git-Change-Operator-Origin trailer with the kind, namespace,
+	// name and uid of this resource so the commit can be traced back to it
+	// +optional
+	OriginTrailer bool `json:"originTrailer,omitempty"`
+}
+
+// CommitIdentity is a name and email as recorded by git
+type CommitIdentity struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinLength=1
+	Email string `json:"email"`
+}
+
+// CommitTrailer is a single "Key: value" line of the commit message trailer block
+type CommitTrailer struct {
+	// Key is the trailer token, e.g. Co-authored-by or Reviewed-by
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9][A-Za-z0-9-]*$"
+	Key string `json:"key"`
+
+	// +kubebuilder:validation:MinLength=1
+	Value string `json:"value"`
+}
+
+// ConflictStrategy retries pushes that were rejected as non-fast-forward
+type ConflictStrategy struct {
+	// Type is Fail to give up on the first rejection, or Rebase to fetch the new tip,
+	// re-apply the files on top of it and push again
+	// +kubebuilder:validation:Enum=Fail;Rebase
+	// +kubebuilder:default=Rebase
+	// +optional
+	Type ConflictStrategyType `json:"type,omitempty"`
+
+	// MaxAttempts is the total number of push attempts, including the first one
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=20
+	// +kubebuilder:default=5
+	// +optional
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+
+	// Backoff is the delay before the first retry, it doubles with every further attempt
+	// +kubebuilder:default="1s"
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+}
+
+// CloneOptions reduce what is downloaded from large repositories
+type CloneOptions struct {
+	// Depth fetches only the given number of most recent commits, 0 fetches the full history.
+	// More history is fetched when the branch moved on further than that before pushing.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Depth int `json:"depth,omitempty"`
+
+	// SingleBranch fetches only the target branch, or the branch it is created from while it does
+	// not exist yet
+	// +optional
+	SingleBranch bool `json:"singleBranch,omitempty"`
+
+	// SparsePaths checks out only these directories and files. Files written, deleted or moved
+	// by the resource are checked out as well, all other files stay unchanged in the commit.
+	// +optional
+	SparsePaths []string `json:"sparsePaths,omitempty"`
+}
+
+type ConflictStrategyType string
+
+const (
+	ConflictStrategyFail   ConflictStrategyType = "Fail"
+	ConflictStrategyRebase ConflictStrategyType = "Rebase"
+)
+
+// Signing references the private key used to sign commits
+type Signing struct {
+	// Format is openpgp for an ASCII armored GPG key or ssh for an OpenSSH private key
+	// +kubebuilder:validation:Enum=openpgp;ssh
+	// +kubebuilder:default=openpgp
+	// +optional
+	Format SigningFormat `json:"format,omitempty"`
+
+	// SecretRef references the secret holding the private key, Key defaults to signing-key.
+	// A passphrase protecting the key is read from the passphrase key of the same secret.
+	SecretRef SecretRef `json:"secretRef"`
 }
 
+type SigningFormat string
+
+const (
+	SigningFormatOpenPGP SigningFormat = "openpgp"
+	SigningFormatSSH     SigningFormat = "ssh"
+)
+
 type File struct {
-	Path    string `json:"path"`
-	Content string `json:"content"`
+	Path string `json:"path"`
+	// +optional
+	Content string `json:"content,omitempty"`
+
+	// BinaryContent is the content of binary files such as images or archives, base64 encoded in
+	// the manifest like the binaryData of a ConfigMap. It cannot be combined with Content.
+	// +optional
+	BinaryContent []byte `json:"binaryContent,omitempty"`
+
+	// Executable commits the file with the executable bit, git file mode 100755
+	// +optional
+	Executable bool `json:"executable,omitempty"`
+
+	// Mode sets the permissions of the file, e.g. 0755 or 0644. Git only records whether the
+	// file is executable. Without Mode and Executable an existing file keeps its mode.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	// +optional
+	Mode *int32 `json:"mode,omitempty"`
+
+	// SymlinkTarget commits Path as a symbolic link to the target instead of writing content,
+	// git file mode 120000. The target is resolved from the directory of the link and must be a
+	// relative path inside of the repository.
+	// +optional
+	SymlinkTarget string `json:"symlinkTarget,omitempty"`
+
+	// Operation is write (default) to write Content to Path, delete to remove Path or move to
+	// rename Path to Destination. Delete accepts a directory or a glob pattern where ** matches
+	// any number of directories, e.g. reports/2023/**. Encrypted counterparts are included.
+	// +kubebuilder:validation:Enum=write;delete;move
+	// +kubebuilder:default=write
+	// +optional
+	Operation FileOperation `json:"operation,omitempty"`
+
+	// Destination is the new path of a moved file
+	// +optional
+	Destination string `json:"destination,omitempty"`
+
+	// Template renders Path, Content and SymlinkTarget as Go templates. Besides the data of the
+	// commit message they see the referenced resources by name and the current content of the file.
+	// +optional
+	Template bool `json:"template,omitempty"`
 
 	// UseRestAPIData indicates this file content should be the formatted REST API response
 	// When true, Content is ignored and the file will contain the API response data
@@ -169,11 +476,93 @@ type File struct {
 	// WriteMode controls how the file content is written to the repository
 	// "overwrite" (default) - replaces existing file content entirely
 	// "append" - adds content to the end of existing files
-	// +kubebuilder:validation:Enum=overwrite;append
+	// "appendLine", "prepend" - adds the lines of content at the end or the top, shaped by Lines
+	// "jsonPatch" - applies Content as an RFC 6902 JSON Patch to the existing JSON or YAML file
+	// "mergePatch" - applies Content as an RFC 7386 JSON merge patch to the existing file
+	// "yamlSet" - sets the value at YAMLPath in the existing file to Content, parsed as YAML
+	// "regexReplace" - replaces the matches of Pattern with Content, which may use $1 or ${name}
+	// "insertBefore", "insertAfter" - inserts Content before or after the first line matching Pattern
+	// "ensureLine" - adds the line Content, replacing the first line matching Pattern if any
+	// "removeLine" - removes the lines matching Pattern, or equal to Content without Pattern
+	// +kubebuilder:validation:Enum=overwrite;append;appendLine;prepend;jsonPatch;mergePatch;yamlSet;regexReplace;insertBefore;insertAfter;ensureLine;removeLine
 	// +kubebuilder:default=overwrite
 	WriteMode WriteMode `json:"writeMode,omitempty"`
+
+	// YAMLPath is the path set by the yamlSet write mode. Keys are separated by dots, list
+	// elements are selected by index or field, e.g. spec.template.spec.containers[name=app].image
+	// +optional
+	YAMLPath string `json:"yamlPath,omitempty"`
+
+	// Pattern is the regular expression (RE2 syntax) of the regexReplace, insertBefore,
+	// insertAfter, ensureLine and removeLine write modes. Line modes match it against each line.
+	// +optional
+	Pattern string `json:"pattern,omitempty"`
+
+	// OnNoMatch decides what happens when the Pattern of regexReplace, insertBefore or
+	// insertAfter matches nothing. Fail fails the commit, Skip leaves the file unchanged.
+	// +kubebuilder:validation:Enum=Fail;Skip
+	// +kubebuilder:default=Fail
+	// +optional
+	OnNoMatch NoMatchPolicy `json:"onNoMatch,omitempty"`
+
+	// Lines adds a header, deduplication and size limits to the appendLine and prepend write modes
+	// +optional
+	Lines *LineOptions `json:"lines,omitempty"`
+}
+
+type NoMatchPolicy string
+
+const (
+	NoMatchPolicyFail NoMatchPolicy = "Fail"
+	NoMatchPolicySkip NoMatchPolicy = "Skip"
+)
+
+// LineOptions keep files that grow by a line per entry, such as logs and CSV time series, in shape
+type LineOptions struct {
+	// Header is the first line of the file, e.g. the column names of a CSV file. It is written
+	// unless the file already starts with it and entries are added below it.
+	// +optional
+	Header string `json:"header,omitempty"`
+
+	// DedupeKey is a regular expression extracting the key of an entry, its first capture group
+	// or else the whole match, e.g. ^([^,]+), for the first CSV column. A new entry replaces the
+	// existing entries with the same key.
+	// +optional
+	DedupeKey string `json:"dedupeKey,omitempty"`
+
+	// MaxLines is the maximum number of entries, the header not counted
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxLines int `json:"maxLines,omitempty"`
+
+	// MaxBytes is the maximum size of the file in bytes
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxBytes int `json:"maxBytes,omitempty"`
+
+	// Rotation decides what happens when the file exceeds MaxLines or MaxBytes. Trim drops the
+	// oldest entries, Rollover moves the file to <name>-<date><ext> and starts a new one.
+	// +kubebuilder:validation:Enum=Trim;Rollover
+	// +kubebuilder:default=Trim
+	// +optional
+	Rotation RotationPolicy `json:"rotation,omitempty"`
 }
 
+type RotationPolicy string
+
+const (
+	RotationPolicyTrim     RotationPolicy = "Trim"
+	RotationPolicyRollover RotationPolicy = "Rollover"
+)
+
+type FileOperation string
+
+const (
+	FileOperationWrite  FileOperation = "write"
+	FileOperationDelete FileOperation = "delete"
+	FileOperationMove   FileOperation = "move"
+)
+
 type ResourceRef struct {
 	ApiVersion string         `json:"apiVersion"`
 	Kind       string         `json:"kind"`
@@ -187,6 +576,23 @@ type OutputStrategy struct {
 	Path      string     `json:"path"`
 	WriteMode WriteMode  `json:"writeMode,omitempty"`
 	FieldRef  *FieldRef  `json:"fieldRef,omitempty"`
+
+	// YAMLPath is the path set by the yamlSet write mode, see File
+	// +optional
+	YAMLPath string `json:"yamlPath,omitempty"`
+
+	// Pattern is the regular expression of the text write modes, see File
+	// +optional
+	Pattern string `json:"pattern,omitempty"`
+
+	// OnNoMatch is Fail (default) or Skip when Pattern matches nothing, see File
+	// +kubebuilder:validation:Enum=Fail;Skip
+	// +optional
+	OnNoMatch NoMatchPolicy `json:"onNoMatch,omitempty"`
+
+	// Lines shapes the appendLine and prepend write modes, see File
+	// +optional
+	Lines *LineOptions `json:"lines,omitempty"`
 }
 
 type FieldRef struct {
@@ -231,8 +637,19 @@ const (
 type WriteMode string
 
 const (
-	WriteModeOverwrite WriteMode = "overwrite"
-	WriteModeAppend    WriteMode = "append"
+	WriteModeOverwrite  WriteMode = "overwrite"
+	WriteModeAppend     WriteMode = "append"
+	WriteModeAppendLine WriteMode = "appendLine"
+	WriteModePrepend    WriteMode = "prepend"
+	WriteModeJSONPatch  WriteMode = "jsonPatch"
+	WriteModeMergePatch WriteMode = "mergePatch"
+	WriteModeYAMLSet    WriteMode = "yamlSet"
+
+	WriteModeRegexReplace WriteMode = "regexReplace"
+	WriteModeInsertBefore WriteMode = "insertBefore"
+	WriteModeInsertAfter  WriteMode = "insertAfter"
+	WriteModeEnsureLine   WriteMode = "ensureLine"
+	WriteModeRemoveLine   WriteMode = "removeLine"
 )
 
 // ExecutionRecord tracks a single execution of a scheduled GitCommit
@@ -248,6 +665,12 @@ type ExecutionRecord struct {
 
 	// Message contains any error or status message
 	Message string `json:"message,omitempty"`
+
+	// Tag is the tag created at the commit
+	Tag string `json:"tag,omitempty"`
+
+	// ReleaseURL is the URL of the release published for the tag
+	ReleaseURL string `json:"releaseURL,omitempty"`
 }
 
 type GitCommitStatus struct {
@@ -265,6 +688,35 @@ type GitCommitStatus struct {
 
 	// ExecutionHistory keeps track of the last N executions (configurable via spec.maxExecutionHistory)
 	ExecutionHistory []ExecutionRecord `json:"executionHistory,omitempty"`
+
+	// Repositories holds the result of the last execution per repository of a fan-out
+	// +optional
+	Repositories []RepositoryStatus `json:"repositories,omitempty"`
+
+	// Tag is the tag created at the last commit
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// ReleaseURL is the URL of the release published for Tag
+	// +optional
+	ReleaseURL string `json:"releaseURL,omitempty"`
+
+	// TagAttempts counts the failed attempts to tag CommitSHA after it was pushed. While it is set
+	// the GitCommit stays Running and only the tag is retried.
+	// +optional
+	TagAttempts int32 `json:"tagAttempts,omitempty"`
+}
+
+// RepositoryStatus is the result of committing to one repository of a fan-out
+type RepositoryStatus struct {
+	Repository string         `json:"repository"`
+	Branch     string         `json:"branch,omitempty"`
+	Phase      GitCommitPhase `json:"phase,omitempty"`
+	CommitSHA  string         `json:"commitSHA,omitempty"`
+	Tag        string         `json:"tag,omitempty"`
+	ReleaseURL string         `json:"releaseURL,omitempty"`
+	// Message describes the error when committing to the repository failed
+	Message string `json:"message,omitempty"`
 }
 
 type GitCommitPhase string
@@ -274,6 +726,10 @@ const (
 	GitCommitPhaseRunning   GitCommitPhase = "Running"
 	GitCommitPhaseCommitted GitCommitPhase = "Committed"
 	GitCommitPhaseFailed    GitCommitPhase = "Failed"
+	// GitCommitPhaseNoChanges means the repository already had the desired content
+	GitCommitPhaseNoChanges GitCommitPhase = "NoChanges"
+	// GitCommitPhasePartiallyCommitted means a fan-out failed for some of its repositories
+	GitCommitPhasePartiallyCommitted GitCommitPhase = "PartiallyCommitted"
 )
 
 //+kubebuilder:object:root=true
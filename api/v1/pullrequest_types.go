@@ -6,12 +6,61 @@ import (
 
 type PullRequestSpec struct {
 	Repository string `json:"repository"`
+
+	// Provider selects the hosting API used to open the pull request.
+	// When empty it is detected from the repository host.
+	// +kubebuilder:validation:Enum=github;gitlab;gitea;forgejo;bitbucket-server
+	// +optional
+	Provider GitProvider `json:"provider,omitempty"`
+
 	BaseBranch string `json:"baseBranch"`
 	HeadBranch string `json:"headBranch"`
+	// Title and Body are Go templates rendered like CommitMessage when Template is set
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
-	Title         string        `json:"title"`
-	Body          string        `json:"body,omitempty"`
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+
+	// CommitMessage is a Go template with access to the execution time, the resource metadata,
+	// REST API results, the changed files, the previous commit and the rendered title when
+	// Template is set. Defaults to "Changes for PR: <title>".
+	// +optional
+	CommitMessage string `json:"commitMessage,omitempty"`
+
+	// Template renders Title, Body and CommitMessage as Go templates. Without it they are used
+	// as they are, including any "{{".
+	// +optional
+	Template bool `json:"template,omitempty"`
+
+	// Labels are added to the pull request after it has been opened
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// Reviewers are usernames requested to review the pull request
+	// +optional
+	Reviewers []string `json:"reviewers,omitempty"`
+
+	// TeamReviewers are team slugs requested to review the pull request (GitHub and Gitea only)
+	// +optional
+	TeamReviewers []string `json:"teamReviewers,omitempty"`
+
+	// Assignees are usernames assigned to the pull request
+	// +optional
+	Assignees []string `json:"assignees,omitempty"`
+
+	// Milestone is the title of an existing milestone to attach the pull request to
+	// +optional
+	Milestone string `json:"milestone,omitempty"`
+
+	// Draft opens the pull request as a draft. GitLab and Gitea mark the title with
+	// "Draft:" and "WIP:" respectively.
+	// +optional
+	Draft bool `json:"draft,omitempty"`
+
+	// AutoMerge lets the operator merge the pull request once it is ready
+	// +optional
+	AutoMerge *AutoMerge `json:"autoMerge,omitempty"`
+
 	Files         []File        `json:"files,omitempty"`
 	ResourceRefs  []ResourceRef `json:"resourceRefs,omitempty"`
 	AuthSecretRef string        `json:"authSecretRef"`
@@ -29,6 +78,28 @@ type PullRequestSpec struct {
 	// +optional
 	Schedule string `json:"schedule,omitempty"`
 
+	// Upsert updates the open pull request for headBranch/baseBranch instead of failing when one exists.
+	// The head branch is force-updated with the new commit and the title and body are refreshed.
+	// +optional
+	Upsert bool `json:"upsert,omitempty"`
+
+	// CleanupPolicy decides what happens to the pull request and its head branch when this
+	// resource is deleted, including deletion after TTLMinutes. Retain leaves both untouched.
+	// +kubebuilder:validation:Enum=Retain;Close;CloseAndDeleteBranch
+	// +kubebuilder:default=Retain
+	// +optional
+	CleanupPolicy CleanupPolicy `json:"cleanupPolicy,omitempty"`
+
+	// Signing signs the commit with an OpenPGP or SSH key stored in a secret
+	// +optional
+	Signing *Signing `json:"signing,omitempty"`
+
+	// Clone limits the history, branches and files fetched from the repository
+	// +optional
+	Clone *CloneOptions `json:"clone,omitempty"`
+
+	CommitMetadata `json:",inline"`
+
 	// Suspend will suspend execution when set to true. Execution will resume when set to false.
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
@@ -42,6 +113,17 @@ type PullRequestSpec struct {
 	MaxExecutionHistory *int `json:"maxExecutionHistory,omitempty"`
 }
 
+// CleanupPolicy is applied to the pull request before the PullRequest resource is removed
+type CleanupPolicy string
+
+const (
+	CleanupPolicyRetain CleanupPolicy = "Retain"
+	// CleanupPolicyClose closes the pull request if it is still open
+	CleanupPolicyClose CleanupPolicy = "Close"
+	// CleanupPolicyCloseAndDeleteBranch also deletes the head branch
+	CleanupPolicyCloseAndDeleteBranch CleanupPolicy = "CloseAndDeleteBranch"
+)
+
 // PRExecutionRecord tracks a single execution of a scheduled PullRequest
 type PRExecutionRecord struct {
 	// ExecutionTime is when the PR was executed
@@ -56,6 +138,9 @@ type PRExecutionRecord struct {
 	// Phase indicates the result of this execution
 	Phase PullRequestPhase `json:"phase"`
 
+	// Action tells whether the pull request was created or an existing one was updated
+	Action PullRequestAction `json:"action,omitempty"`
+
 	// Message contains any error or status message
 	Message string `json:"message,omitempty"`
 }
@@ -76,8 +161,123 @@ type PullRequestStatus struct {
 
 	// ExecutionHistory keeps track of the last N executions (configurable via spec.maxExecutionHistory)
 	ExecutionHistory []PRExecutionRecord `json:"executionHistory,omitempty"`
+
+	// MergeCommitSHA is the commit that landed the pull request on the base branch, set once merged
+	MergeCommitSHA string `json:"mergeCommitSHA,omitempty"`
+
+	// MergeableState reports whether the pull request can currently be merged
+	MergeableState MergeableState `json:"mergeableState,omitempty"`
+
+	// ReviewDecision summarizes the reviews of the pull request
+	ReviewDecision ReviewDecision `json:"reviewDecision,omitempty"`
+
+	// CheckStatus is the combined result of all checks on the head commit
+	CheckStatus CheckStatus `json:"checkStatus,omitempty"`
+
+	// AutoMerge records the outcome of the merge policy
+	AutoMerge *AutoMergeStatus `json:"autoMerge,omitempty"`
 }
 
+// AutoMerge configures how the operator merges its pull requests
+type AutoMerge struct {
+	// Method is the merge method used for the pull request
+	// +kubebuilder:validation:Enum=merge;squash;rebase
+	// +kubebuilder:default=merge
+	// +optional
+	Method MergeMethod `json:"method,omitempty"`
+
+	// RequireChecks waits until all checks on the head commit succeed before merging
+	// +kubebuilder:default=true
+	// +optional
+	RequireChecks *bool `json:"requireChecks,omitempty"`
+
+	// DeleteBranch deletes the head branch once the pull request is merged
+	// +optional
+	DeleteBranch bool `json:"deleteBranch,omitempty"`
+
+	// Native enables the provider's own auto-merge (GitHub auto-merge, GitLab merge when
+	// pipeline succeeds, Gitea merge when checks succeed) instead of polling and merging
+	// from the operator. Branch protection then decides which checks are required.
+	// +optional
+	Native bool `json:"native,omitempty"`
+}
+
+type MergeMethod string
+
+const (
+	MergeMethodMerge  MergeMethod = "merge"
+	MergeMethodSquash MergeMethod = "squash"
+	MergeMethodRebase MergeMethod = "rebase"
+)
+
+// AutoMergeStatus is the outcome of the merge policy
+type AutoMergeStatus struct {
+	State   AutoMergeState `json:"state,omitempty"`
+	Message string         `json:"message,omitempty"`
+
+	// LastAttemptTime is when the operator last tried to merge or enable auto-merge
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+}
+
+type AutoMergeState string
+
+const (
+	// AutoMergeStateWaiting means the pull request is not ready to be merged yet
+	AutoMergeStateWaiting AutoMergeState = "Waiting"
+	// AutoMergeStateEnabled means native auto-merge is armed on the provider
+	AutoMergeStateEnabled AutoMergeState = "Enabled"
+	AutoMergeStateMerged  AutoMergeState = "Merged"
+	// AutoMergeStateFailed means the last merge attempt was rejected, it is retried on the next poll
+	AutoMergeStateFailed AutoMergeState = "Failed"
+)
+
+// +kubebuilder:validation:Enum=Mergeable;Conflicting;Blocked;Unknown
+type MergeableState string
+
+const (
+	MergeableStateMergeable   MergeableState = "Mergeable"
+	MergeableStateConflicting MergeableState = "Conflicting"
+	MergeableStateBlocked     MergeableState = "Blocked"
+	MergeableStateUnknown     MergeableState = "Unknown"
+)
+
+// +kubebuilder:validation:Enum=Approved;ChangesRequested;ReviewRequired
+type ReviewDecision string
+
+const (
+	ReviewDecisionApproved         ReviewDecision = "Approved"
+	ReviewDecisionChangesRequested ReviewDecision = "ChangesRequested"
+	ReviewDecisionReviewRequired   ReviewDecision = "ReviewRequired"
+)
+
+// +kubebuilder:validation:Enum=Success;Pending;Failure
+type CheckStatus string
+
+const (
+	CheckStatusSuccess CheckStatus = "Success"
+	CheckStatusPending CheckStatus = "Pending"
+	CheckStatusFailure CheckStatus = "Failure"
+)
+
+// GitProvider identifies the hosting provider of a repository
+type GitProvider string
+
+const (
+	GitProviderGitHub          GitProvider = "github"
+	GitProviderGitLab          GitProvider = "gitlab"
+	GitProviderGitea           GitProvider = "gitea"
+	GitProviderForgejo         GitProvider = "forgejo"
+	GitProviderBitbucketServer GitProvider = "bitbucket-server"
+)
+
+// PullRequestAction describes what an execution did to the pull request
+type PullRequestAction string
+
+const (
+	PullRequestActionCreated PullRequestAction = "Created"
+	PullRequestActionUpdated PullRequestAction = "Updated"
+)
+
 type PullRequestPhase string
 
 const (
@@ -85,6 +285,8 @@ const (
 	PullRequestPhaseRunning PullRequestPhase = "Running"
 	PullRequestPhaseCreated PullRequestPhase = "Created"
 	PullRequestPhaseFailed  PullRequestPhase = "Failed"
+	PullRequestPhaseMerged  PullRequestPhase = "Merged"
+	PullRequestPhaseClosed  PullRequestPhase = "Closed"
 )
 
 //+kubebuilder:object:root=true
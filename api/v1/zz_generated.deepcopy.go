@@ -25,6 +25,45 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoMerge) DeepCopyInto(out *AutoMerge) {
+	*out = *in
+	if in.RequireChecks != nil {
+		in, out := &in.RequireChecks, &out.RequireChecks
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoMerge.
+func (in *AutoMerge) DeepCopy() *AutoMerge {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoMerge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoMergeStatus) DeepCopyInto(out *AutoMergeStatus) {
+	*out = *in
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoMergeStatus.
+func (in *AutoMergeStatus) DeepCopy() *AutoMergeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoMergeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CRDsConfig) DeepCopyInto(out *CRDsConfig) {
 	*out = *in
@@ -40,6 +79,106 @@ func (in *CRDsConfig) DeepCopy() *CRDsConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneOptions) DeepCopyInto(out *CloneOptions) {
+	*out = *in
+	if in.SparsePaths != nil {
+		in, out := &in.SparsePaths, &out.SparsePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneOptions.
+func (in *CloneOptions) DeepCopy() *CloneOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommitIdentity) DeepCopyInto(out *CommitIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommitIdentity.
+func (in *CommitIdentity) DeepCopy() *CommitIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(CommitIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommitMetadata) DeepCopyInto(out *CommitMetadata) {
+	*out = *in
+	if in.Author != nil {
+		in, out := &in.Author, &out.Author
+		*out = new(CommitIdentity)
+		**out = **in
+	}
+	if in.Committer != nil {
+		in, out := &in.Committer, &out.Committer
+		*out = new(CommitIdentity)
+		**out = **in
+	}
+	if in.Trailers != nil {
+		in, out := &in.Trailers, &out.Trailers
+		*out = make([]CommitTrailer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommitMetadata.
+func (in *CommitMetadata) DeepCopy() *CommitMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(CommitMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommitTrailer) DeepCopyInto(out *CommitTrailer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommitTrailer.
+func (in *CommitTrailer) DeepCopy() *CommitTrailer {
+	if in == nil {
+		return nil
+	}
+	out := new(CommitTrailer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConflictStrategy) DeepCopyInto(out *ConflictStrategy) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConflictStrategy.
+func (in *ConflictStrategy) DeepCopy() *ConflictStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConflictStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Encryption) DeepCopyInto(out *Encryption) {
 	*out = *in
@@ -78,6 +217,31 @@ func (in *ExecutionRecord) DeepCopy() *ExecutionRecord {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FanOut) DeepCopyInto(out *FanOut) {
+	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]RepositoryTarget, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FanOut.
+func (in *FanOut) DeepCopy() *FanOut {
+	if in == nil {
+		return nil
+	}
+	out := new(FanOut)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FieldRef) DeepCopyInto(out *FieldRef) {
 	*out = *in
@@ -96,6 +260,21 @@ func (in *FieldRef) DeepCopy() *FieldRef {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *File) DeepCopyInto(out *File) {
 	*out = *in
+	if in.BinaryContent != nil {
+		in, out := &in.BinaryContent, &out.BinaryContent
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Lines != nil {
+		in, out := &in.Lines, &out.Lines
+		*out = new(LineOptions)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new File.
@@ -176,7 +355,7 @@ func (in *GitChangeOperatorSpec) DeepCopyInto(out *GitChangeOperatorSpec) {
 		**out = **in
 	}
 	out.Image = in.Image
-	out.Operator = in.Operator
+	in.Operator.DeepCopyInto(&out.Operator)
 	out.RBAC = in.RBAC
 	out.ServiceAccount = in.ServiceAccount
 	in.Metrics.DeepCopyInto(&out.Metrics)
@@ -282,7 +461,9 @@ func (in *GitCommitSpec) DeepCopyInto(out *GitCommitSpec) {
 	if in.Files != nil {
 		in, out := &in.Files, &out.Files
 		*out = make([]File, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.ResourceRefs != nil {
 		in, out := &in.ResourceRefs, &out.ResourceRefs
@@ -313,6 +494,32 @@ func (in *GitCommitSpec) DeepCopyInto(out *GitCommitSpec) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.ConflictStrategy != nil {
+		in, out := &in.ConflictStrategy, &out.ConflictStrategy
+		*out = new(ConflictStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Signing != nil {
+		in, out := &in.Signing, &out.Signing
+		*out = new(Signing)
+		**out = **in
+	}
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(CloneOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FanOut != nil {
+		in, out := &in.FanOut, &out.FanOut
+		*out = new(FanOut)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tag != nil {
+		in, out := &in.Tag, &out.Tag
+		*out = new(TagSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.CommitMetadata.DeepCopyInto(&out.CommitMetadata)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitCommitSpec.
@@ -354,6 +561,11 @@ func (in *GitCommitStatus) DeepCopyInto(out *GitCommitStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]RepositoryStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitCommitStatus.
@@ -532,6 +744,21 @@ func (in *IngressTLS) DeepCopy() *IngressTLS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LineOptions) DeepCopyInto(out *LineOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LineOptions.
+func (in *LineOptions) DeepCopy() *LineOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(LineOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
 	*out = *in
@@ -567,6 +794,16 @@ func (in *MetricsServiceConfig) DeepCopy() *MetricsServiceConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperatorConfig) DeepCopyInto(out *OperatorConfig) {
 	*out = *in
+	if in.CommitAuthor != nil {
+		in, out := &in.CommitAuthor, &out.CommitAuthor
+		*out = new(CommitIdentity)
+		**out = **in
+	}
+	if in.RepositoryCache != nil {
+		in, out := &in.RepositoryCache, &out.RepositoryCache
+		*out = new(RepositoryCacheConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfig.
@@ -587,6 +824,11 @@ func (in *OutputStrategy) DeepCopyInto(out *OutputStrategy) {
 		*out = new(FieldRef)
 		**out = **in
 	}
+	if in.Lines != nil {
+		in, out := &in.Lines, &out.Lines
+		*out = new(LineOptions)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputStrategy.
@@ -677,10 +919,37 @@ func (in *PullRequestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullRequestSpec) DeepCopyInto(out *PullRequestSpec) {
 	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Reviewers != nil {
+		in, out := &in.Reviewers, &out.Reviewers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TeamReviewers != nil {
+		in, out := &in.TeamReviewers, &out.TeamReviewers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Assignees != nil {
+		in, out := &in.Assignees, &out.Assignees
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AutoMerge != nil {
+		in, out := &in.AutoMerge, &out.AutoMerge
+		*out = new(AutoMerge)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Files != nil {
 		in, out := &in.Files, &out.Files
 		*out = make([]File, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.ResourceRefs != nil {
 		in, out := &in.ResourceRefs, &out.ResourceRefs
@@ -706,6 +975,17 @@ func (in *PullRequestSpec) DeepCopyInto(out *PullRequestSpec) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Signing != nil {
+		in, out := &in.Signing, &out.Signing
+		*out = new(Signing)
+		**out = **in
+	}
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(CloneOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	in.CommitMetadata.DeepCopyInto(&out.CommitMetadata)
 	if in.MaxExecutionHistory != nil {
 		in, out := &in.MaxExecutionHistory, &out.MaxExecutionHistory
 		*out = new(int)
@@ -752,6 +1032,11 @@ func (in *PullRequestStatus) DeepCopyInto(out *PullRequestStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AutoMerge != nil {
+		in, out := &in.AutoMerge, &out.AutoMerge
+		*out = new(AutoMergeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullRequestStatus.
@@ -799,6 +1084,77 @@ func (in *Recipient) DeepCopy() *Recipient {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseSpec) DeepCopyInto(out *ReleaseSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseSpec.
+func (in *ReleaseSpec) DeepCopy() *ReleaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryCacheConfig) DeepCopyInto(out *RepositoryCacheConfig) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryCacheConfig.
+func (in *RepositoryCacheConfig) DeepCopy() *RepositoryCacheConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryCacheConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryStatus) DeepCopyInto(out *RepositoryStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryStatus.
+func (in *RepositoryStatus) DeepCopy() *RepositoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryTarget) DeepCopyInto(out *RepositoryTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryTarget.
+func (in *RepositoryTarget) DeepCopy() *RepositoryTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRef) DeepCopyInto(out *ResourceRef) {
 	*out = *in
@@ -939,3 +1295,39 @@ func (in *ServiceMonitorConfig) DeepCopy() *ServiceMonitorConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Signing) DeepCopyInto(out *Signing) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Signing.
+func (in *Signing) DeepCopy() *Signing {
+	if in == nil {
+		return nil
+	}
+	out := new(Signing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TagSpec) DeepCopyInto(out *TagSpec) {
+	*out = *in
+	if in.Release != nil {
+		in, out := &in.Release, &out.Release
+		*out = new(ReleaseSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TagSpec.
+func (in *TagSpec) DeepCopy() *TagSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TagSpec)
+	in.DeepCopyInto(out)
+	return out
+}
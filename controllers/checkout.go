@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/encryption"
+	"github.com/mihaigalos/git-change-operator/pkg/gitcache"
+)
+
+// unshallowDepth is the depth git itself uses to fetch the complete history of a shallow clone
+const unshallowDepth = 2147483647
+
+// cloneRepository checks out url into a directory of its own. With a repository cache only the
+// changes since the last run are fetched, otherwise the repository is cloned into a temporary
+// directory, limited to the history and branch configured in options. branches are the branches
+// the run may build on in order of preference, a single branch clone takes the first one that
+// exists remotely. The returned function removes the checkout.
+func cloneRepository(ctx context.Context, repoCache *gitcache.Cache, url string, branches []string, options *gitv1.CloneOptions, auth transport.AuthMethod, prefix string) (*git.Repository, string, func(), error) {
+	var sparsePaths []string
+	if options != nil {
+		sparsePaths = options.SparsePaths
+	}
+
+	repo, dir, cleanup, err := fetchRepository(ctx, repoCache, url, branches, options, auth, prefix)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if len(sparsePaths) > 0 {
+		if err := sparseCheckout(repo, dir, sparsePaths); err != nil {
+			cleanup()
+			return nil, "", nil, fmt.Errorf("failed to check out %v: %w", sparsePaths, err)
+		}
+	}
+	return repo, dir, cleanup, nil
+}
+
+// fetchRepository clones or checks out url without writing any files when sparse paths are set
+func fetchRepository(ctx context.Context, repoCache *gitcache.Cache, url string, branches []string, options *gitv1.CloneOptions, auth transport.AuthMethod, prefix string) (*git.Repository, string, func(), error) {
+	sparse := options != nil && len(options.SparsePaths) > 0
+
+	if repoCache != nil {
+		wt, err := repoCache.Checkout(ctx, url, auth, sparse)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return wt.Repository, wt.Dir, func() { wt.Close() }, nil
+	}
+
+	cloneOptions := &git.CloneOptions{
+		URL:        url,
+		Auth:       auth,
+		NoCheckout: sparse,
+	}
+	// An empty name clones the default branch
+	candidates := []string{""}
+	if options != nil {
+		cloneOptions.Depth = options.Depth
+		if options.SingleBranch {
+			cloneOptions.SingleBranch = true
+			candidates = []string{}
+			for _, branch := range branches {
+				if branch != "" {
+					candidates = append(candidates, branch)
+				}
+			}
+			candidates = append(candidates, "")
+		}
+	}
+
+	for i, branch := range candidates {
+		cloneOptions.ReferenceName = ""
+		if branch != "" {
+			cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		}
+
+		tempDir, err := os.MkdirTemp("", prefix)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		cleanup := func() { os.RemoveAll(tempDir) }
+
+		repo, err := git.PlainCloneContext(ctx, tempDir, false, cloneOptions)
+		if err == nil {
+			return repo, tempDir, cleanup, nil
+		}
+		cleanup()
+
+		// The branch is created by this run, try the next one it may be created from
+		if errors.Is(err, git.NoMatchingRefSpecError{}) && i < len(candidates)-1 {
+			log.FromContext(ctx).Info("Branch does not exist, cloning the next candidate", "branch", branch)
+			continue
+		}
+		return nil, "", nil, err
+	}
+	return nil, "", nil, fmt.Errorf("no branch to clone")
+}
+
+// branchStart returns the commit branch continues from: the tip of the remote branch when it
+// exists, otherwise the tip of baseBranch, or of the default branch when baseBranch is empty
+func branchStart(ctx context.Context, repo *git.Repository, branch, baseBranch string) (plumbing.Hash, error) {
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), true)
+	if err == nil {
+		return ref.Hash(), nil
+	}
+	if err != plumbing.ErrReferenceNotFound {
+		return plumbing.ZeroHash, err
+	}
+
+	if baseBranch == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		log.FromContext(ctx).Info("Creating branch from the default branch", "branch", branch, "baseBranch", head.Name().Short())
+		return head.Hash(), nil
+	}
+
+	ref, err = repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, baseBranch), true)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("base branch %s not found: %w", baseBranch, err)
+	}
+	log.FromContext(ctx).Info("Creating branch from the base branch", "branch", branch, "baseBranch", baseBranch)
+	return ref.Hash(), nil
+}
+
+// checkoutBranch points branch at hash and checks it out. A sparse checkout is rebuilt from the
+// new commit instead, go-git would otherwise see the left out files as deleted.
+func checkoutBranch(repo *git.Repository, w *git.Worktree, branch plumbing.ReferenceName, hash plumbing.Hash, options *gitv1.CloneOptions) error {
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(branch, hash)); err != nil {
+		return err
+	}
+	if options == nil || len(options.SparsePaths) == 0 {
+		return w.Checkout(&git.CheckoutOptions{Branch: branch, Force: true})
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch)); err != nil {
+		return err
+	}
+	return sparseCheckout(repo, w.Filesystem.Root(), options.SparsePaths)
+}
+
+// deepen fetches the full history of a shallow clone, needed when the commits fetched or pushed
+// later connect to history below the clone depth. It does nothing for complete clones.
+func deepen(ctx context.Context, repo *git.Repository, auth transport.AuthMethod) error {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil || len(shallow) == 0 {
+		return err
+	}
+	log.FromContext(ctx).Info("Fetching the full history of the shallow clone")
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		Depth:      unshallowDepth,
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
+
+// push pushes with options. A shallow clone lacking history the push needs is deepened first.
+func push(ctx context.Context, repo *git.Repository, options *git.PushOptions) error {
+	err := repo.PushContext(ctx, options)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		if err := deepen(ctx, repo, options.Auth); err != nil {
+			return err
+		}
+		err = repo.PushContext(ctx, options)
+	}
+	return err
+}
+
+// sparseCheckout writes only the files of HEAD below paths to root. The index holds every file
+// of HEAD, the ones left out are flagged skip-worktree so they are committed unchanged.
+func sparseCheckout(repo *git.Repository, root string, paths []string) error {
+	tree, err := headTree(repo)
+	if err != nil {
+		return err
+	}
+
+	idx := &index.Index{Version: 3}
+	err = walkFiles(tree, func(name string, entry object.TreeEntry) error {
+		e := idx.Add(name)
+		e.Hash = entry.Hash
+		e.Mode = entry.Mode
+		if !matchesAny(paths, name, "") {
+			e.SkipWorktree = true
+			return nil
+		}
+		return checkoutEntry(repo, root, e)
+	})
+	if err != nil {
+		return err
+	}
+	return repo.Storer.SetIndex(idx)
+}
+
+// materialize checks out the files of a sparse checkout matching patterns, or whose path
+// without the encrypted extension ext matches them, so they can be read, changed or removed
+func materialize(repo *git.Repository, root string, patterns []string, ext string) error {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, e := range idx.Entries {
+		if !e.SkipWorktree || !matchesAny(patterns, e.Name, ext) {
+			continue
+		}
+		if err := checkoutEntry(repo, root, e); err != nil {
+			return err
+		}
+		e.SkipWorktree = false
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return repo.Storer.SetIndex(idx)
+}
+
+// materializeFile checks out the files file reads, writes or removes when options limit the
+// checkout to sparse paths
+func materializeFile(repo *git.Repository, root string, options *gitv1.CloneOptions, file gitv1.File, config *gitv1.Encryption) error {
+	if options == nil || len(options.SparsePaths) == 0 {
+		return nil
+	}
+	patterns := []string{file.Path}
+	if file.Destination != "" {
+		patterns = append(patterns, file.Destination)
+	}
+	if err := materialize(repo, root, patterns, encryption.GetFileExtension(config)); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", file.Path, err)
+	}
+	return nil
+}
+
+// checkoutEntry writes the blob of e to root and records its size and modification time
+func checkoutEntry(repo *git.Repository, root string, e *index.Entry) error {
+	if e.Mode == filemode.Submodule {
+		return nil
+	}
+	blob, err := repo.BlobObject(e.Hash)
+	if err != nil {
+		return err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	p := filepath.Join(root, filepath.FromSlash(e.Name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	os.Remove(p)
+	if e.Mode == filemode.Symlink {
+		err = os.Symlink(string(content), p)
+	} else {
+		perm := os.FileMode(0644)
+		if e.Mode == filemode.Executable {
+			perm = 0755
+		}
+		err = os.WriteFile(p, content, perm)
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+	e.Size = uint32(info.Size())
+	e.ModifiedAt = info.ModTime()
+	return nil
+}
+
+// changedFiles lists the paths whose staged content differs from HEAD in lexical order. Files
+// outside of a sparse checkout keep the content of HEAD in the index and count as unchanged.
+func changedFiles(repo *git.Repository) ([]string, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	staged := map[string]*index.Entry{}
+	for _, e := range idx.Entries {
+		staged[e.Name] = e
+	}
+
+	files := []string{}
+	tree, err := headTree(repo)
+	if err != nil && err != plumbing.ErrReferenceNotFound {
+		return nil, err
+	}
+	if tree != nil {
+		err = walkFiles(tree, func(name string, entry object.TreeEntry) error {
+			if e, ok := staged[name]; !ok || e.Hash != entry.Hash || e.Mode != entry.Mode {
+				files = append(files, name)
+			}
+			delete(staged, name)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name := range staged {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func headTree(repo *git.Repository) (*object.Tree, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// walkFiles calls fn for every file, symlink and submodule of tree
+func walkFiles(tree *object.Tree, fn func(name string, entry object.TreeEntry) error) error {
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		if err := fn(name, entry); err != nil {
+			return err
+		}
+	}
+}
+
+// matchesAny reports whether name is one of patterns, below one of them or matches one as a
+// glob. With a non-empty ext, name also matches when it does without that extension.
+func matchesAny(patterns []string, name, ext string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+		candidates := []string{name}
+		if ext != "" && strings.HasSuffix(name, ext) {
+			candidates = append(candidates, strings.TrimSuffix(name, ext))
+		}
+		for _, candidate := range candidates {
+			if candidate == pattern || strings.HasPrefix(candidate, pattern+"/") ||
+				globMatch(strings.Split(pattern, "/"), strings.Split(candidate, "/")) {
+				return true
+			}
+		}
+	}
+	return false
+}
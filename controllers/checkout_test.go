@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestSparseCheckout(t *testing.T) {
+	_, upstream := committedWorktree(t, "apps/a.txt", "apps/b.txt", "docs/readme.md", "config/app.yaml.age")
+
+	options := &gitv1.CloneOptions{SparsePaths: []string{"apps/"}}
+	repo, root, cleanup, err := cloneRepository(context.Background(), nil, upstream, nil, options, nil, "sparse-")
+	if err != nil {
+		t.Fatalf("cloneRepository() error = %v", err)
+	}
+	defer cleanup()
+
+	for path, want := range map[string]bool{"apps/a.txt": true, "apps/b.txt": true, "docs/readme.md": false, "config/app.yaml.age": false} {
+		if _, err := os.Stat(filepath.Join(root, path)); (err == nil) != want {
+			t.Errorf("%s checked out = %v, want %v", path, err == nil, want)
+		}
+	}
+	if files, err := changedFiles(repo); err != nil || len(files) != 0 {
+		t.Errorf("changedFiles() = %v, %v, want no changes after checkout", files, err)
+	}
+
+	// Files outside of the sparse paths are checked out once they are written, also encrypted
+	if err := materializeFile(repo, root, options, gitv1.File{Path: "config/app.yaml"}, &gitv1.Encryption{Enabled: true}); err != nil {
+		t.Fatalf("materializeFile() error = %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "config/app.yaml.age")); string(content) != "config/app.yaml.age" {
+		t.Errorf("config/app.yaml.age = %q", content)
+	}
+
+	w, _ := repo.Worktree()
+	os.WriteFile(filepath.Join(root, "apps/a.txt"), []byte("changed"), 0644)
+	if _, err := w.Add("apps/a.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if files, err := changedFiles(repo); err != nil || !reflect.DeepEqual(files, []string{"apps/a.txt"}) {
+		t.Errorf("changedFiles() = %v, %v, want [apps/a.txt]", files, err)
+	}
+
+	commit, err := w.Commit("Change a", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	c, _ := repo.CommitObject(commit)
+	for _, path := range []string{"apps/b.txt", "docs/readme.md", "config/app.yaml.age"} {
+		if _, err := c.File(path); err != nil {
+			t.Errorf("%s missing from the commit: %v", path, err)
+		}
+	}
+}
+
+func TestSingleBranchCloneOfNewBranch(t *testing.T) {
+	_, upstream := committedWorktree(t, "config.txt")
+
+	options := &gitv1.CloneOptions{Depth: 1, SingleBranch: true}
+	repo, _, cleanup, err := cloneRepository(context.Background(), nil, upstream, []string{"feature"}, options, nil, "single-branch-")
+	if err != nil {
+		t.Fatalf("cloneRepository() error = %v", err)
+	}
+	defer cleanup()
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if head.Name() != plumbing.Master {
+		t.Errorf("HEAD = %s, want the default branch when the branch does not exist", head.Name())
+	}
+	if shallow, _ := repo.Storer.Shallow(); len(shallow) != 1 {
+		t.Errorf("Shallow() = %v, want the cloned commit", shallow)
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  string
+		want bool
+	}{
+		{name: "apps/a.txt", want: true},
+		{name: "apps/nested/b.txt", want: true},
+		{name: "applications/c.txt", want: false},
+		{name: "config/app.yaml", want: true},
+		{name: "config/app.yaml.age", want: false},
+		{name: "config/app.yaml.age", ext: ".age", want: true},
+		{name: "reports/2024/summary.csv", want: true},
+		{name: "reports/summary.txt", want: false},
+	}
+	patterns := []string{"/apps/", "config/app.yaml", "reports/**/*.csv"}
+
+	for _, tt := range tests {
+		if got := matchesAny(patterns, tt.name, tt.ext); got != tt.want {
+			t.Errorf("matchesAny(%s, %q) = %v, want %v", tt.name, tt.ext, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+const originTrailerKey = "Git-Change-Operator-Origin"
+
+// DefaultCommitAuthor is used when neither the resource nor the operator configure an author
+var DefaultCommitAuthor = gitv1.CommitIdentity{
+	Name:  "Git Change Operator",
+	Email: "git-change-operator@galos.one",
+}
+
+var trailerLine = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S`)
+
+// commitOptions resolves the author from the resource, then the operator default, and
+// the committer from the resource, then the author
+func commitOptions(metadata gitv1.CommitMetadata, defaultAuthor gitv1.CommitIdentity) *git.CommitOptions {
+	author := defaultAuthor
+	if author.Name == "" || author.Email == "" {
+		author = DefaultCommitAuthor
+	}
+	if metadata.Author != nil {
+		author = *metadata.Author
+	}
+	committer := author
+	if metadata.Committer != nil {
+		committer = *metadata.Committer
+	}
+
+	now := time.Now()
+	return &git.CommitOptions{
+		Author:    &object.Signature{Name: author.Name, Email: author.Email, When: now},
+		Committer: &object.Signature{Name: committer.Name, Email: committer.Email, When: now},
+	}
+}
+
+// commitMessage appends the configured trailers, the sign-off of committer and the origin of
+// obj to message. They join an existing trailer block at the end of the message.
+func commitMessage(message string, metadata gitv1.CommitMetadata, committer *object.Signature, kind string, obj metav1.Object) string {
+	var trailers []string
+	for _, trailer := range metadata.Trailers {
+		trailers = append(trailers, fmt.Sprintf("%s: %s", trailer.Key, trailer.Value))
+	}
+	if metadata.SignOff {
+		trailers = append(trailers, fmt.Sprintf("Signed-off-by: %s <%s>", committer.Name, committer.Email))
+	}
+	if metadata.OriginTrailer {
+		trailers = append(trailers, fmt.Sprintf("%s: %s %s/%s (uid %s)", originTrailerKey, kind, obj.GetNamespace(), obj.GetName(), obj.GetUID()))
+	}
+	if len(trailers) == 0 {
+		return message
+	}
+
+	message = strings.TrimRight(message, "\n")
+	separator := "\n\n"
+	if endsWithTrailers(message) {
+		separator = "\n"
+	}
+	return message + separator + strings.Join(trailers, "\n") + "\n"
+}
+
+// endsWithTrailers reports whether the last paragraph of a multi-paragraph message only
+// consists of trailer lines
+func endsWithTrailers(message string) bool {
+	paragraphs := strings.Split(message, "\n\n")
+	if len(paragraphs) < 2 {
+		return false
+	}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if !trailerLine.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
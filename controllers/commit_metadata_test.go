@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestCommitOptions(t *testing.T) {
+	alice := &gitv1.CommitIdentity{Name: "Alice", Email: "alice@example.com"}
+	bot := &gitv1.CommitIdentity{Name: "Release Bot", Email: "bot@example.com"}
+	operator := gitv1.CommitIdentity{Name: "Platform", Email: "platform@example.com"}
+
+	tests := []struct {
+		name          string
+		metadata      gitv1.CommitMetadata
+		defaultAuthor gitv1.CommitIdentity
+		wantAuthor    string
+		wantCommitter string
+	}{
+		{
+			name:          "built-in default",
+			wantAuthor:    "Git Change Operator <git-change-operator@galos.one>",
+			wantCommitter: "Git Change Operator <git-change-operator@galos.one>",
+		},
+		{
+			name:          "operator default",
+			defaultAuthor: operator,
+			wantAuthor:    "Platform <platform@example.com>",
+			wantCommitter: "Platform <platform@example.com>",
+		},
+		{
+			name:          "resource author overrides the operator default",
+			metadata:      gitv1.CommitMetadata{Author: alice},
+			defaultAuthor: operator,
+			wantAuthor:    "Alice <alice@example.com>",
+			wantCommitter: "Alice <alice@example.com>",
+		},
+		{
+			name:          "separate committer",
+			metadata:      gitv1.CommitMetadata{Author: alice, Committer: bot},
+			wantAuthor:    "Alice <alice@example.com>",
+			wantCommitter: "Release Bot <bot@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := commitOptions(tt.metadata, tt.defaultAuthor)
+			if got := options.Author.Name + " <" + options.Author.Email + ">"; got != tt.wantAuthor {
+				t.Errorf("Author = %q, want %q", got, tt.wantAuthor)
+			}
+			if got := options.Committer.Name + " <" + options.Committer.Email + ">"; got != tt.wantCommitter {
+				t.Errorf("Committer = %q, want %q", got, tt.wantCommitter)
+			}
+		})
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	obj := &metav1.ObjectMeta{Namespace: "team-a", Name: "update-config", UID: "1234"}
+	committer := commitOptions(gitv1.CommitMetadata{}, gitv1.CommitIdentity{Name: "Bot", Email: "bot@example.com"}).Committer
+
+	tests := []struct {
+		name     string
+		message  string
+		metadata gitv1.CommitMetadata
+		want     string
+	}{
+		{
+			name:    "no trailers",
+			message: "Update config",
+			want:    "Update config",
+		},
+		{
+			name:    "all trailers",
+			message: "Update config\n",
+			metadata: gitv1.CommitMetadata{
+				Trailers:      []gitv1.CommitTrailer{{Key: "Co-authored-by", Value: "Alice <alice@example.com>"}},
+				SignOff:       true,
+				OriginTrailer: true,
+			},
+			want: "Update config\n\n" +
+				"Co-authored-by: Alice <alice@example.com>\n" +
+				"Signed-off-by: Bot <bot@example.com>\n" +
+				"Git-Change-Operator-Origin: GitCommit team-a/update-config (uid 1234)\n",
+		},
+		{
+			name:     "joins an existing trailer block",
+			message:  "Update config\n\nRefs: #42",
+			metadata: gitv1.CommitMetadata{SignOff: true},
+			want:     "Update config\n\nRefs: #42\nSigned-off-by: Bot <bot@example.com>\n",
+		},
+		{
+			name:     "body is not mistaken for trailers",
+			message:  "Update config\n\nThis changes the replica count.",
+			metadata: gitv1.CommitMetadata{SignOff: true},
+			want:     "Update config\n\nThis changes the replica count.\n\nSigned-off-by: Bot <bot@example.com>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitMessage(tt.message, tt.metadata, committer, "GitCommit", obj); got != tt.want {
+				t.Errorf("commitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
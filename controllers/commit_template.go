@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/render"
+)
+
+// templateData is what commit messages, pull request titles and bodies are rendered with
+func templateData(obj metav1.Object, statuses []gitv1.RestAPIStatus, files []string, previousCommit string) map[string]interface{} {
+	restAPIs := make(map[string]interface{}, len(statuses))
+	for _, status := range statuses {
+		var extracted interface{} = status.ExtractedData
+		if status.ExtractedData != "" {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(status.ExtractedData), &decoded); err == nil {
+				extracted = decoded
+			}
+		}
+		restAPIs[status.Name] = map[string]interface{}{
+			"formattedOutput": status.FormattedOutput,
+			"extractedData":   extracted,
+		}
+	}
+
+	return map[string]interface{}{
+		"time": time.Now().UTC(),
+		"metadata": map[string]interface{}{
+			"name":        obj.GetName(),
+			"namespace":   obj.GetNamespace(),
+			"uid":         string(obj.GetUID()),
+			"generation":  obj.GetGeneration(),
+			"labels":      obj.GetLabels(),
+			"annotations": obj.GetAnnotations(),
+		},
+		"restAPIs":       restAPIs,
+		"files":          files,
+		"previousCommit": previousCommit,
+	}
+}
+
+// errNoChanges is returned instead of committing when the staged files match the branch tip
+var errNoChanges = errors.New("no changes to commit")
+
+// headCommit returns the SHA HEAD points to, empty when the branch has no commits yet
+func headCommit(repo *git.Repository) string {
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+	return head.Hash().String()
+}
+
+// renderPullRequest renders the title, body and commit message of pr when spec.template is set.
+// The rendered title is available to the commit message, which defaults to
+// "Changes for PR: <title>".
+func renderPullRequest(pr *gitv1.PullRequest, repo *git.Repository, previousCommit string) (string, string, string, error) {
+	title, body, message := pr.Spec.Title, pr.Spec.Body, pr.Spec.CommitMessage
+	if pr.Spec.Template {
+		files, err := changedFiles(repo)
+		if err != nil {
+			return "", "", "", err
+		}
+		data := templateData(pr, pr.Status.RestAPIStatuses, files, previousCommit)
+
+		if title, err = render.String("title", title, data); err != nil {
+			return "", "", "", err
+		}
+		if body, err = render.String("body", body, data); err != nil {
+			return "", "", "", err
+		}
+		data["title"] = title
+		if message, err = render.String("commitMessage", message, data); err != nil {
+			return "", "", "", err
+		}
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("Changes for PR: %s", title)
+	}
+	return title, body, message, nil
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+// stagedRepository returns a repository with the given files written and staged
+func stagedRepository(t *testing.T, paths ...string) *git.Repository {
+	t.Helper()
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree() error = %v", err)
+	}
+	for _, path := range paths {
+		file, err := fs.Create(path)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		file.Write([]byte(path))
+		file.Close()
+		if _, err := w.Add(path); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	return repo
+}
+
+func TestRenderPullRequest(t *testing.T) {
+	pr := &gitv1.PullRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "prices", Namespace: "team-a", Labels: map[string]string{"env": "prod"}},
+		Spec: gitv1.PullRequestSpec{
+			Title:    "Update {{ index .metadata.labels \"env\" }} prices",
+			Body:     "Changed: {{ join \", \" .files }}\nPrice: {{ .restAPIs.prices.extractedData.value }}",
+			Template: true,
+		},
+		Status: gitv1.PullRequestStatus{
+			RestAPIStatuses: []gitv1.RestAPIStatus{{Name: "prices", FormattedOutput: "42", ExtractedData: `{"value": "42"}`}},
+		},
+	}
+	repo := stagedRepository(t, "prices/b.csv", "prices/a.csv")
+
+	title, body, message, err := renderPullRequest(pr, repo, "0123456789abcdef0123456789abcdef01234567")
+	if err != nil {
+		t.Fatalf("renderPullRequest() error = %v", err)
+	}
+	if title != "Update prod prices" {
+		t.Errorf("title = %q", title)
+	}
+	if body != "Changed: prices/a.csv, prices/b.csv\nPrice: 42" {
+		t.Errorf("body = %q", body)
+	}
+	if message != "Changes for PR: Update prod prices" {
+		t.Errorf("default commit message = %q", message)
+	}
+
+	pr.Spec.CommitMessage = "{{ .title }} on top of {{ short .previousCommit }} ({{ .restAPIs.prices.formattedOutput }})"
+	if _, _, message, err = renderPullRequest(pr, repo, "0123456789abcdef0123456789abcdef01234567"); err != nil {
+		t.Fatalf("renderPullRequest() error = %v", err)
+	}
+	if message != "Update prod prices on top of 0123456 (42)" {
+		t.Errorf("commit message = %q", message)
+	}
+
+	pr.Spec.Body = "{{ .restAPIs.missing.formattedOutput }}"
+	if _, _, _, err := renderPullRequest(pr, repo, ""); err == nil {
+		t.Error("Expected an error for an unknown REST API")
+	}
+}
+
+// Messages are only rendered with spec.template, a literal "{{" is kept otherwise
+func TestMessagesWithoutTemplate(t *testing.T) {
+	pr := &gitv1.PullRequest{
+		Spec: gitv1.PullRequestSpec{
+			Title: "Document {{ .Values.image }}",
+			Body:  "Uses {{ missing }} Helm syntax",
+		},
+	}
+	title, body, message, err := renderPullRequest(pr, stagedRepository(t, "README.md"), "")
+	if err != nil {
+		t.Fatalf("renderPullRequest() error = %v", err)
+	}
+	if title != pr.Spec.Title || body != pr.Spec.Body || message != "Changes for PR: "+pr.Spec.Title {
+		t.Errorf("renderPullRequest() = %q, %q, %q, want the literal text", title, body, message)
+	}
+
+	w, root := committedWorktree(t, "values.yaml")
+	repo, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatalf("PlainOpen() error = %v", err)
+	}
+	gitCommit := &gitv1.GitCommit{
+		ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default"},
+		Spec: gitv1.GitCommitSpec{
+			CommitMessage: "Set {{ .Values.image }} in the chart",
+			Files:         []gitv1.File{{Path: "values.yaml", Content: "image: nginx\n"}},
+		},
+	}
+	hash, err := (&GitCommitReconciler{}).commitChanges(context.Background(), gitCommit, repo, w, root)
+	if err != nil {
+		t.Fatalf("commitChanges() error = %v", err)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatalf("CommitObject() error = %v", err)
+	}
+	if !strings.HasPrefix(commit.Message, "Set {{ .Values.image }} in the chart") {
+		t.Errorf("commit message = %q, want the literal message", commit.Message)
+	}
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestCommitChangesWithoutChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  gitv1.EmptyCommitPolicy
+		content string
+		wantErr error
+	}{
+		{name: "skips unchanged content by default", content: "config.txt", wantErr: errNoChanges},
+		{name: "skips unchanged content", policy: gitv1.EmptyCommitPolicySkip, content: "config.txt", wantErr: errNoChanges},
+		{name: "allows an empty commit", policy: gitv1.EmptyCommitPolicyAllow, content: "config.txt"},
+		{name: "commits changed content", content: "replicas: 3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, root := committedWorktree(t, "config.txt")
+			repo, err := git.PlainOpen(root)
+			if err != nil {
+				t.Fatalf("PlainOpen() error = %v", err)
+			}
+			before, _ := repo.Head()
+
+			gitCommit := &gitv1.GitCommit{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+				Spec: gitv1.GitCommitSpec{
+					CommitMessage:     "Update config",
+					EmptyCommitPolicy: tt.policy,
+					Files:             []gitv1.File{{Path: "config.txt", Content: tt.content}},
+				},
+			}
+
+			r := &GitCommitReconciler{}
+			hash, err := r.commitChanges(context.Background(), gitCommit, repo, w, root)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("commitChanges() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if head, _ := repo.Head(); head.Hash() != before.Hash() {
+					t.Errorf("HEAD moved to %s without changes", head.Hash())
+				}
+				return
+			}
+
+			commit, err := repo.CommitObject(hash)
+			if err != nil {
+				t.Fatalf("CommitObject() error = %v", err)
+			}
+			if len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != before.Hash() {
+				t.Errorf("Parents = %v, want %s", commit.ParentHashes, before.Hash())
+			}
+		})
+	}
+}
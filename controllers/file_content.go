@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+// fileContent returns the content written to file, its binary content when that is set
+func fileContent(file gitv1.File) ([]byte, error) {
+	if len(file.BinaryContent) == 0 {
+		return []byte(file.Content), nil
+	}
+	if file.Content != "" {
+		return nil, fmt.Errorf("file %s: content and binaryContent cannot be combined", file.Path)
+	}
+	return file.BinaryContent, nil
+}
+
+// fileMode returns the permissions file is written with, 0 when an existing file keeps its mode
+func fileMode(file gitv1.File) (os.FileMode, error) {
+	if file.Mode == nil {
+		if file.Executable {
+			return 0755, nil
+		}
+		return 0, nil
+	}
+	mode := os.FileMode(*file.Mode) & os.ModePerm
+	if file.Executable && mode&0111 == 0 {
+		return 0, fmt.Errorf("file %s: executable conflicts with mode %#o", file.Path, mode)
+	}
+	return mode, nil
+}
+
+// writeFile writes content to p and applies mode when it is not 0. A symbolic link at p is
+// replaced by the file instead of writing to its target.
+func writeFile(p string, content []byte, mode os.FileMode) error {
+	if info, err := os.Lstat(p); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+
+	perm := mode
+	if perm == 0 {
+		perm = 0644
+	}
+	if err := os.WriteFile(p, content, perm); err != nil {
+		return err
+	}
+	if mode == 0 {
+		return nil
+	}
+	// WriteFile applies the umask and keeps the permissions of existing files
+	return os.Chmod(p, mode)
+}
+
+// writeSymlink replaces the path of file in the worktree rooted at root with a symbolic link to
+// its target and stages it. Links have no content of their own and are never encrypted.
+func writeSymlink(w *git.Worktree, root string, file gitv1.File) error {
+	if file.Content != "" || len(file.BinaryContent) > 0 || file.UseRestAPIData || file.Mode != nil || file.Executable {
+		return fmt.Errorf("file %s: symlinkTarget cannot be combined with content or a mode", file.Path)
+	}
+	if file.WriteMode != "" && file.WriteMode != gitv1.WriteModeOverwrite {
+		return fmt.Errorf("file %s: symlinkTarget cannot be combined with write mode %s", file.Path, file.WriteMode)
+	}
+	p, err := cleanRepositoryPath(file.Path)
+	if err != nil {
+		return err
+	}
+	link, err := worktreePath(root, p)
+	if err != nil {
+		return err
+	}
+
+	// The target is relative to the directory of the link and must stay inside of the repository
+	if filepath.IsAbs(file.SymlinkTarget) {
+		return fmt.Errorf("file %s: symlinkTarget %s must be relative", file.Path, file.SymlinkTarget)
+	}
+	target, err := cleanRepositoryPath(path.Join(path.Dir(p), filepath.ToSlash(file.SymlinkTarget)))
+	if err != nil {
+		return fmt.Errorf("file %s: symlinkTarget %s is outside of the repository", file.Path, file.SymlinkTarget)
+	}
+	if err := insideWorktree(root, target); err != nil {
+		return fmt.Errorf("file %s: symlinkTarget %s: %w", file.Path, file.SymlinkTarget, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(link); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("file %s: cannot replace a directory with a symbolic link", file.Path)
+		}
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+	}
+	if err := os.Symlink(file.SymlinkTarget, link); err != nil {
+		return fmt.Errorf("failed to link %s to %s: %w", p, file.SymlinkTarget, err)
+	}
+	_, err = w.Add(p)
+	return err
+}
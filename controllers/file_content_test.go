@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+// stagedMode returns the git file mode of p in the index
+func stagedMode(t *testing.T, w *git.Worktree, p string) filemode.FileMode {
+	t.Helper()
+	repo, err := git.PlainOpen(w.Filesystem.Root())
+	if err != nil {
+		t.Fatalf("PlainOpen() error = %v", err)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	entry, err := idx.Entry(p)
+	if err != nil {
+		t.Fatalf("Entry(%s) error = %v", p, err)
+	}
+	return entry.Mode
+}
+
+func TestFileContentAndMode(t *testing.T) {
+	mode := func(m int32) *int32 { return &m }
+
+	tests := []struct {
+		name        string
+		file        gitv1.File
+		wantContent string
+		wantMode    os.FileMode
+		wantErr     string
+	}{
+		{name: "text", file: gitv1.File{Path: "a", Content: "text"}, wantContent: "text"},
+		{name: "binary", file: gitv1.File{Path: "a", BinaryContent: []byte{0x89, 'P', 'N', 'G'}}, wantContent: "\x89PNG"},
+		{name: "executable", file: gitv1.File{Path: "a", Executable: true}, wantMode: 0755},
+		{name: "explicit mode", file: gitv1.File{Path: "a", Mode: mode(0700), Executable: true}, wantMode: 0700},
+		{name: "read only", file: gitv1.File{Path: "a", Mode: mode(0444)}, wantMode: 0444},
+		{name: "content and binary content", file: gitv1.File{Path: "a", Content: "text", BinaryContent: []byte("binary")}, wantErr: "cannot be combined"},
+		{name: "executable without execute permission", file: gitv1.File{Path: "a", Mode: mode(0644), Executable: true}, wantErr: "executable conflicts with mode 0644"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := fileContent(tt.file)
+			if err == nil {
+				var m os.FileMode
+				m, err = fileMode(tt.file)
+				if err == nil && m != tt.wantMode {
+					t.Errorf("fileMode() = %#o, want %#o", m, tt.wantMode)
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("fileContent() = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestWriteFileMode(t *testing.T) {
+	w, root := committedWorktree(t, "scripts/deploy.sh", "README.md")
+
+	if err := writeFile(filepath.Join(root, "scripts/deploy.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	if err := writeFile(filepath.Join(root, "README.md"), []byte("# Readme\n"), 0); err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	for _, p := range []string{"scripts/deploy.sh", "README.md"} {
+		if _, err := w.Add(p); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	if got := stagedMode(t, w, "scripts/deploy.sh"); got != filemode.Executable {
+		t.Errorf("mode of scripts/deploy.sh = %v, want %v", got, filemode.Executable)
+	}
+	if got := stagedMode(t, w, "README.md"); got != filemode.Regular {
+		t.Errorf("mode of README.md = %v, want %v", got, filemode.Regular)
+	}
+
+	// Writing to a symbolic link replaces the link instead of its target
+	if err := os.Symlink("README.md", filepath.Join(root, "link")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	if err := writeFile(filepath.Join(root, "link"), []byte("file\n"), 0); err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	if readme, _ := os.ReadFile(filepath.Join(root, "README.md")); string(readme) != "# Readme\n" {
+		t.Errorf("README.md = %q, written through the link", readme)
+	}
+}
+
+func TestWriteSymlink(t *testing.T) {
+	w, root := committedWorktree(t, "releases/v1.4.0/app.yaml", "current")
+
+	file := gitv1.File{Path: "current", SymlinkTarget: "releases/v1.4.0"}
+	if err := writeSymlink(w, root, file); err != nil {
+		t.Fatalf("writeSymlink() error = %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(root, "current")); err != nil || target != "releases/v1.4.0" {
+		t.Errorf("Readlink() = %q, %v, want releases/v1.4.0", target, err)
+	}
+	if got := stagedMode(t, w, "current"); got != filemode.Symlink {
+		t.Errorf("mode of current = %v, want %v", got, filemode.Symlink)
+	}
+
+	// Linking again is idempotent
+	if err := writeSymlink(w, root, file); err != nil {
+		t.Fatalf("writeSymlink() error = %v", err)
+	}
+
+	for _, file := range []gitv1.File{
+		{Path: "current", SymlinkTarget: "releases/v1.4.0", Content: "text"},
+		{Path: "current", SymlinkTarget: "releases/v1.4.0", Executable: true},
+		{Path: "current", SymlinkTarget: "releases/v1.4.0", WriteMode: gitv1.WriteModeAppend},
+		{Path: "releases", SymlinkTarget: "v1.4.0"},
+		{Path: "../current", SymlinkTarget: "releases/v1.4.0"},
+		{Path: "current", SymlinkTarget: "/etc"},
+		{Path: "releases/previous", SymlinkTarget: "../../outside"},
+		{Path: "current", SymlinkTarget: ".git/config"},
+		{Path: "docs", SymlinkTarget: "."},
+	} {
+		if err := writeSymlink(w, root, file); err == nil {
+			t.Errorf("writeSymlink(%+v) error = nil, want an error", file)
+		}
+	}
+}
+
+func TestWorktreePath(t *testing.T) {
+	w, root := committedWorktree(t, "README.md")
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// A link inside of the repository can be written through
+	if err := os.Mkdir(filepath.Join(root, "releases"), 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := writeSymlink(w, root, gitv1.File{Path: "current", SymlinkTarget: "releases"}); err != nil {
+		t.Fatalf("writeSymlink() error = %v", err)
+	}
+	if _, err := worktreePath(root, "current/app.yaml"); err != nil {
+		t.Errorf("worktreePath(current/app.yaml) error = %v", err)
+	}
+
+	// Links already on disk that resolve outside of the worktree are neither written nor read
+	if err := os.Symlink(outside, filepath.Join(root, "d")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "secret")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	for _, p := range []string{"d/x", "d/nested/x"} {
+		if _, err := worktreePath(root, p); err == nil {
+			t.Errorf("worktreePath(%s) error = nil, want an error", p)
+		}
+	}
+	for _, p := range []string{"d/secret", "secret"} {
+		if _, err := readWorktreeFile(root, p); err == nil {
+			t.Errorf("readWorktreeFile(%s) error = nil, want an error", p)
+		}
+	}
+	if err := writeSymlink(w, root, gitv1.File{Path: "d/link", SymlinkTarget: "../README.md"}); err == nil {
+		t.Errorf("writeSymlink(d/link) error = nil, want an error")
+	}
+	if err := writeSymlink(w, root, gitv1.File{Path: "link", SymlinkTarget: "d/secret"}); err == nil {
+		t.Errorf("writeSymlink(link) to d/secret error = nil, want an error")
+	}
+	if _, _, err := patchFile(root, "d/secret", fileEdit{mode: gitv1.WriteModeAppendLine}, []byte("line\n"), nil); err == nil {
+		t.Errorf("patchFile(d/secret) error = nil, want an error")
+	}
+
+	// Overwriting the link replaces it instead of writing through it
+	p, err := worktreePath(root, "secret")
+	if err != nil {
+		t.Fatalf("worktreePath(secret) error = %v", err)
+	}
+	if err := writeFile(p, []byte("replaced\n"), 0); err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	if secret, _ := os.ReadFile(filepath.Join(outside, "secret")); string(secret) != "secret\n" {
+		t.Errorf("secret = %q, written outside of the worktree", secret)
+	}
+}
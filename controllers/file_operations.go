@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/encryption"
+)
+
+// isFileOperation reports whether file removes or renames files instead of writing content
+func isFileOperation(file gitv1.File) bool {
+	return file.Operation == gitv1.FileOperationDelete || file.Operation == gitv1.FileOperationMove
+}
+
+// applyFileOperation stages the delete or move described by file in the worktree rooted at root
+func applyFileOperation(ctx context.Context, w *git.Worktree, root string, file gitv1.File, config *gitv1.Encryption) error {
+	switch file.Operation {
+	case gitv1.FileOperationDelete:
+		return deleteFiles(ctx, w, root, file.Path, config)
+	case gitv1.FileOperationMove:
+		if file.Destination == "" {
+			return fmt.Errorf("file %s: destination is required to move a file", file.Path)
+		}
+		return moveFile(w, root, file.Path, file.Destination, config)
+	default:
+		return fmt.Errorf("file %s: unsupported operation %q", file.Path, file.Operation)
+	}
+}
+
+// deleteFiles removes every file matching pattern together with its encrypted counterpart.
+// Matching nothing is not an error, so deleting stays idempotent across executions.
+func deleteFiles(ctx context.Context, w *git.Worktree, root, pattern string, config *gitv1.Encryption) error {
+	pattern, err := cleanRepositoryPath(pattern)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(filepath.Join(root, pattern)); err == nil && info.IsDir() {
+		pattern += "/**"
+	}
+
+	paths, err := matchFiles(root, pattern, encryption.GetFileExtension(config))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		log.FromContext(ctx).Info("No files matched, nothing to delete", "path", pattern)
+		return nil
+	}
+
+	for _, p := range paths {
+		if err := removeFile(w, root, p); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// moveFile renames from to to, and from.age to to.age when the encrypted counterpart exists.
+// It succeeds without changes when only the destination exists, i.e. the file was moved before.
+func moveFile(w *git.Worktree, root, from, to string, config *gitv1.Encryption) error {
+	from, err := cleanRepositoryPath(from)
+	if err != nil {
+		return err
+	}
+	to, err = cleanRepositoryPath(to)
+	if err != nil {
+		return err
+	}
+
+	moved := false
+	for _, suffix := range []string{"", encryption.GetFileExtension(config)} {
+		source, destination := from+suffix, to+suffix
+		sourcePath, err := worktreePath(root, source)
+		if err != nil {
+			return err
+		}
+		if !exists(sourcePath) {
+			continue
+		}
+
+		destinationPath, err := worktreePath(root, destination)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(destinationPath), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(sourcePath, destinationPath); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", source, destination, err)
+		}
+		if err := removeFile(w, root, source); err != nil {
+			return fmt.Errorf("failed to move %s: %w", source, err)
+		}
+		if _, err := w.Add(destination); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", source, destination, err)
+		}
+		moved = true
+	}
+
+	if !moved && !exists(filepath.Join(root, to)) && !exists(filepath.Join(root, encryption.GetEncryptedFilePath(to, config))) {
+		return fmt.Errorf("file %s to move not found", from)
+	}
+	return nil
+}
+
+// removeFile stages the removal of a tracked file and deletes untracked ones from disk
+func removeFile(w *git.Worktree, root, p string) error {
+	_, err := w.Remove(p)
+	if err == index.ErrEntryNotFound {
+		return os.RemoveAll(filepath.Join(root, p))
+	}
+	return err
+}
+
+// matchFiles returns the files below root matching pattern, or whose path without the
+// encrypted extension ext matches it, in lexical order
+func matchFiles(root, pattern, ext string) ([]string, error) {
+	patternParts := strings.Split(pattern, "/")
+
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == git.GitDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if globMatch(patternParts, strings.Split(rel, "/")) ||
+			(strings.HasSuffix(rel, ext) && globMatch(patternParts, strings.Split(strings.TrimSuffix(rel, ext), "/"))) {
+			matches = append(matches, rel)
+		}
+		return nil
+	})
+	sort.Strings(matches)
+	return matches, err
+}
+
+// globMatch matches path segments against pattern segments, where ** matches any number of segments
+func globMatch(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if globMatch(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return globMatch(pattern[1:], name[1:])
+}
+
+// cleanRepositoryPath normalizes p and rejects paths outside of the repository
+func cleanRepositoryPath(p string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(p, "/"))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.HasPrefix(cleaned, git.GitDirName+"/") || cleaned == git.GitDirName {
+		return "", fmt.Errorf("path %q is outside of the repository worktree", p)
+	}
+	return cleaned, nil
+}
+
+// worktreePath cleans the repository path p and joins it to root. It is rejected when one of its
+// parent directories is a symbolic link resolving outside of the worktree, so writes stay inside.
+func worktreePath(root, p string) (string, error) {
+	cleaned, err := cleanRepositoryPath(p)
+	if err != nil {
+		return "", err
+	}
+	if err := insideWorktree(root, path.Dir(cleaned)); err != nil {
+		return "", err
+	}
+	return filepath.Join(root, filepath.FromSlash(cleaned)), nil
+}
+
+// readWorktreeFile reads the file at the repository path p. A symbolic link is only followed
+// when its target is inside of the worktree.
+func readWorktreeFile(root, p string) ([]byte, error) {
+	cleaned, err := cleanRepositoryPath(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := insideWorktree(root, cleaned); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(root, filepath.FromSlash(cleaned)))
+}
+
+// insideWorktree resolves the symbolic links of the repository path p, or of its closest
+// existing parent, and rejects it when the result is outside of the worktree rooted at root
+func insideWorktree(root, p string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	existing := filepath.Join(root, filepath.FromSlash(p))
+	resolved, err := filepath.EvalSymlinks(existing)
+	for os.IsNotExist(err) && existing != root {
+		existing = filepath.Dir(existing)
+		resolved, err = filepath.EvalSymlinks(existing)
+	}
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return nil
+	}
+	if _, err := cleanRepositoryPath(filepath.ToSlash(rel)); err != nil {
+		return fmt.Errorf("path %q resolves to %s outside of the repository worktree", p, resolved)
+	}
+	return nil
+}
+
+func exists(p string) bool {
+	_, err := os.Lstat(p)
+	return err == nil
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+// committedWorktree creates a repository in a temporary directory with the given files committed
+func committedWorktree(t *testing.T, paths ...string) (*git.Worktree, string) {
+	t.Helper()
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree() error = %v", err)
+	}
+	for _, p := range paths {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, p)), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, p), []byte(p), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if _, err := w.Add(p); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if _, err := w.Commit("Initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	return w, root
+}
+
+// stagedChanges returns the staged changes as "D path" and "A path" entries
+func stagedChanges(t *testing.T, w *git.Worktree) []string {
+	t.Helper()
+	status, err := w.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	changes := []string{}
+	for p, fileStatus := range status {
+		switch fileStatus.Staging {
+		case git.Deleted:
+			changes = append(changes, "D "+p)
+		case git.Added:
+			changes = append(changes, "A "+p)
+		case git.Unmodified:
+		default:
+			t.Errorf("Unexpected status %q for %s", fileStatus.Staging, p)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func TestDeleteFiles(t *testing.T) {
+	files := []string{
+		"reports/2023/01/summary.csv",
+		"reports/2023/02/summary.csv.age",
+		"reports/2024/summary.csv",
+		"config/app.yaml",
+		"config/app.yaml.age",
+		"config/db.yaml",
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "glob subtree",
+			path: "reports/2023/**",
+			want: []string{"D reports/2023/01/summary.csv", "D reports/2023/02/summary.csv.age"},
+		},
+		{
+			name: "directory",
+			path: "reports/2023/",
+			want: []string{"D reports/2023/01/summary.csv", "D reports/2023/02/summary.csv.age"},
+		},
+		{
+			name: "single file with encrypted counterpart",
+			path: "config/app.yaml",
+			want: []string{"D config/app.yaml", "D config/app.yaml.age"},
+		},
+		{
+			name: "glob across directories",
+			path: "**/summary.csv",
+			want: []string{"D reports/2023/01/summary.csv", "D reports/2023/02/summary.csv.age", "D reports/2024/summary.csv"},
+		},
+		{
+			name: "no match",
+			path: "missing/*.yaml",
+			want: []string{},
+		},
+		{
+			name:    "outside of the repository",
+			path:    "../etc/passwd",
+			wantErr: "outside of the repository",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, root := committedWorktree(t, files...)
+			file := gitv1.File{Path: tt.path, Operation: gitv1.FileOperationDelete}
+
+			err := applyFileOperation(context.Background(), w, root, file, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyFileOperation() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyFileOperation() error = %v", err)
+			}
+			if got := stagedChanges(t, w); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staged = %v, want %v", got, tt.want)
+			}
+			for _, change := range tt.want {
+				if exists(filepath.Join(root, strings.TrimPrefix(change, "D "))) {
+					t.Errorf("%s still exists on disk", change)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	config := &gitv1.Encryption{Enabled: true, FileExtension: ".enc"}
+
+	t.Run("moves the file and its encrypted counterpart", func(t *testing.T) {
+		w, root := committedWorktree(t, "old/secret.yaml", "old/secret.yaml.enc")
+		file := gitv1.File{Path: "old/secret.yaml", Operation: gitv1.FileOperationMove, Destination: "new/secret.yaml"}
+
+		if err := applyFileOperation(context.Background(), w, root, file, config); err != nil {
+			t.Fatalf("applyFileOperation() error = %v", err)
+		}
+		want := []string{"A new/secret.yaml", "A new/secret.yaml.enc", "D old/secret.yaml", "D old/secret.yaml.enc"}
+		if got := stagedChanges(t, w); !reflect.DeepEqual(got, want) {
+			t.Errorf("staged = %v, want %v", got, want)
+		}
+
+		// Moving again is a no-op now that only the destination exists
+		if err := applyFileOperation(context.Background(), w, root, file, config); err != nil {
+			t.Errorf("applyFileOperation() second move error = %v", err)
+		}
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		w, root := committedWorktree(t, "a.txt")
+		file := gitv1.File{Path: "b.txt", Operation: gitv1.FileOperationMove, Destination: "c.txt"}
+		if err := applyFileOperation(context.Background(), w, root, file, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("applyFileOperation() error = %v, want not found", err)
+		}
+	})
+
+	t.Run("missing destination", func(t *testing.T) {
+		w, root := committedWorktree(t, "a.txt")
+		file := gitv1.File{Path: "a.txt", Operation: gitv1.FileOperationMove}
+		if err := applyFileOperation(context.Background(), w, root, file, nil); err == nil || !strings.Contains(err.Error(), "destination is required") {
+			t.Errorf("applyFileOperation() error = %v, want destination is required", err)
+		}
+	})
+}
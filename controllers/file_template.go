@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/render"
+)
+
+// resourceFetcher reads the object a resource reference points to
+type resourceFetcher func(ctx context.Context, resourceRef gitv1.ResourceRef, namespace string) (*unstructured.Unstructured, error)
+
+// hasTemplates reports whether any of the files is rendered as a template
+func hasTemplates(files []gitv1.File) bool {
+	for _, file := range files {
+		if file.Template {
+			return true
+		}
+	}
+	return false
+}
+
+// fileTemplateData is the data of the commit message templates extended by the referenced
+// resources, keyed by name
+func fileTemplateData(ctx context.Context, obj metav1.Object, statuses []gitv1.RestAPIStatus, previousCommit string, resourceRefs []gitv1.ResourceRef, fetch resourceFetcher) (map[string]interface{}, error) {
+	resources := make(map[string]interface{}, len(resourceRefs))
+	for _, resourceRef := range resourceRefs {
+		resource, err := fetch(ctx, resourceRef, obj.GetNamespace())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch resource %s/%s: %w", resourceRef.Kind, resourceRef.Name, err)
+		}
+		resources[resourceRef.Name] = resource.Object
+	}
+
+	data := templateData(obj, statuses, nil, previousCommit)
+	data["resources"] = resources
+	return data, nil
+}
+
+// prepareFile renders the path of a templated file, checks the file out of a sparse clone and
+// then renders its content. The content template sees the file in the clone as .file.
+func prepareFile(repo *git.Repository, root string, options *gitv1.CloneOptions, file gitv1.File, config *gitv1.Encryption, data map[string]interface{}) (gitv1.File, error) {
+	if !file.Template {
+		return file, materializeFile(repo, root, options, file, config)
+	}
+
+	path, err := render.String("path", file.Path, data)
+	if err != nil {
+		return file, err
+	}
+	path = strings.TrimSpace(path)
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(filepath.Clean(path), "..") {
+		return file, fmt.Errorf("rendered path %q of %s is not inside the repository", path, file.Path)
+	}
+	file.Path = path
+
+	if err := materializeFile(repo, root, options, file, config); err != nil {
+		return file, err
+	}
+
+	// Encrypted files are not decrypted, their clear text content is not available
+	current, err := readWorktreeFile(root, file.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return file, err
+	}
+	fileData := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		fileData[k] = v
+	}
+	fileData["file"] = map[string]interface{}{
+		"path":    file.Path,
+		"exists":  err == nil,
+		"content": string(current),
+	}
+
+	if file.Content, err = render.String(file.Path, file.Content, fileData); err != nil {
+		return file, err
+	}
+	if file.SymlinkTarget, err = render.String(file.Path, file.SymlinkTarget, fileData); err != nil {
+		return file, err
+	}
+	return file, nil
+}
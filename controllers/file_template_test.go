@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestPrepareFile(t *testing.T) {
+	_, root := committedWorktree(t, "apps/frontend.yaml")
+
+	gitCommit := &gitv1.GitCommit{ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default"}}
+	statuses := []gitv1.RestAPIStatus{{Name: "release", ExtractedData: `{"image":"frontend:1.4.0"}`}}
+	fetch := func(ctx context.Context, resourceRef gitv1.ResourceRef, namespace string) (*unstructured.Unstructured, error) {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"data": map[string]interface{}{"replicas": "3"},
+		}}, nil
+	}
+	data, err := fileTemplateData(context.Background(), gitCommit, statuses, "", []gitv1.ResourceRef{{Kind: "ConfigMap", Name: "frontend-settings"}}, fetch)
+	if err != nil {
+		t.Fatalf("fileTemplateData() error = %v", err)
+	}
+
+	file, err := prepareFile(nil, root, nil, gitv1.File{
+		Path:     "apps/{{ .metadata.name }}.yaml",
+		Template: true,
+		Content: `# previously {{ .file.content }}
+image: {{ .restAPIs.release.extractedData.image }}
+replicas: {{ index .resources "frontend-settings" "data" "replicas" }}
+new: {{ .file.exists | not }}`,
+	}, nil, data)
+	if err != nil {
+		t.Fatalf("prepareFile() error = %v", err)
+	}
+	if file.Path != "apps/frontend.yaml" {
+		t.Errorf("Path = %q", file.Path)
+	}
+	want := "# previously apps/frontend.yaml\nimage: frontend:1.4.0\nreplicas: 3\nnew: false"
+	if file.Content != want {
+		t.Errorf("Content = %q, want %q", file.Content, want)
+	}
+
+	// Files without the template flag are written as they are
+	file, err = prepareFile(nil, root, nil, gitv1.File{Path: "{{ x }}", Content: "{{ y }}"}, nil, nil)
+	if err != nil || file.Path != "{{ x }}" || file.Content != "{{ y }}" {
+		t.Errorf("prepareFile() = %+v, %v, want the file unchanged", file, err)
+	}
+
+	_, err = prepareFile(nil, root, nil, gitv1.File{Path: "../{{ .metadata.name }}", Template: true}, nil, data)
+	if err == nil || !strings.Contains(err.Error(), "not inside the repository") {
+		t.Errorf("Expected the rendered path to be rejected, got %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -58,6 +59,14 @@ func (r *GitChangeOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		}
 	}
 
+	// The cache volume must exist before the operator pod can mount it
+	if cacheConfig := gitChangeOperator.Spec.Operator.RepositoryCache; cacheConfig != nil && cacheConfig.ExistingClaim == "" {
+		if err := r.reconcileRepositoryCacheClaim(ctx, &gitChangeOperator); err != nil {
+			log.Error(err, "Failed to reconcile repository cache PersistentVolumeClaim")
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Reconcile the operator Deployment. The manager always ensures it exists.
 	if err := r.reconcileDeployment(ctx, &gitChangeOperator); err != nil {
 		log.Error(err, "Failed to reconcile operator Deployment")
@@ -199,6 +208,28 @@ func (r *GitChangeOperatorReconciler) reconcileDeployment(ctx context.Context, g
 	if gco.Spec.Operator.LeaderElect {
 		args = append(args, "--leader-elect=true")
 	}
+	if author := gco.Spec.Operator.CommitAuthor; author != nil {
+		args = append(args, "--commit-author-name="+author.Name, "--commit-author-email="+author.Email)
+	}
+
+	volumes := []corev1.Volume{
+		{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	volumeMounts := []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}}
+	strategy := appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+	if cacheConfig := gco.Spec.Operator.RepositoryCache; cacheConfig != nil {
+		maxSize := repositoryCacheMaxSize(cacheConfig)
+		args = append(args, "--repository-cache-dir="+repositoryCacheMountPath, "--repository-cache-max-size="+maxSize.String())
+		volumes = append(volumes, corev1.Volume{
+			Name: repositoryCacheVolume,
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: repositoryCacheClaimName(gco),
+			}},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: repositoryCacheVolume, MountPath: repositoryCacheMountPath})
+		// A ReadWriteOnce volume cannot be attached to the old and the new pod at the same time
+		strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
 
 	gracePeriod := int64(10)
 	selectorLabels := map[string]string{
@@ -216,6 +247,7 @@ func (r *GitChangeOperatorReconciler) reconcileDeployment(ctx context.Context, g
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{MatchLabels: selectorLabels},
+			Strategy: strategy,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{Labels: selectorLabels},
 				Spec: corev1.PodSpec{
@@ -235,12 +267,10 @@ func (r *GitChangeOperatorReconciler) reconcileDeployment(ctx context.Context, g
 									},
 								},
 							},
-							VolumeMounts: []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}},
+							VolumeMounts: volumeMounts,
 						},
 					},
-					Volumes: []corev1.Volume{
-						{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
-					},
+					Volumes: volumes,
 				},
 			},
 		},
@@ -258,13 +288,87 @@ func (r *GitChangeOperatorReconciler) reconcileDeployment(ctx context.Context, g
 		return err
 	}
 
-	// Patch only the mutable fields: replicas, image, and args.
+	// Patch only the mutable fields: replicas, image, args and the volumes of the repository cache.
 	patch := client.MergeFrom(found.DeepCopy())
 	found.Spec.Replicas = &replicas
+	found.Spec.Strategy = strategy
+	found.Spec.Template.Spec.Volumes = volumes
 	if len(found.Spec.Template.Spec.Containers) > 0 {
 		found.Spec.Template.Spec.Containers[0].Image = operandImage
 		found.Spec.Template.Spec.Containers[0].Args = args
+		found.Spec.Template.Spec.Containers[0].VolumeMounts = volumeMounts
+	}
+	return r.Patch(ctx, found, patch)
+}
+
+const (
+	repositoryCacheVolume    = "repository-cache"
+	repositoryCacheMountPath = "/var/cache/git-change-operator"
+)
+
+// repositoryCacheClaimName returns the PersistentVolumeClaim mounted as repository cache
+func repositoryCacheClaimName(gco *gitchangeoperatoriov1.GitChangeOperator) string {
+	if claim := gco.Spec.Operator.RepositoryCache.ExistingClaim; claim != "" {
+		return claim
 	}
+	return fmt.Sprintf("%s-repository-cache", gco.Name)
+}
+
+// repositoryCacheMaxSize defaults to 80% of the volume so eviction starts before it runs full
+func repositoryCacheMaxSize(config *gitchangeoperatoriov1.RepositoryCacheConfig) resource.Quantity {
+	if config.MaxSize != nil {
+		return *config.MaxSize
+	}
+	size := repositoryCacheSize(config)
+	return *resource.NewQuantity(size.Value()/10*8, resource.BinarySI)
+}
+
+func repositoryCacheSize(config *gitchangeoperatoriov1.RepositoryCacheConfig) resource.Quantity {
+	if config.Size.IsZero() {
+		return resource.MustParse("10Gi")
+	}
+	return config.Size
+}
+
+// reconcileRepositoryCacheClaim creates the PersistentVolumeClaim of the repository cache and
+// grows it when the configured size increases. Claims cannot shrink, smaller sizes are ignored.
+func (r *GitChangeOperatorReconciler) reconcileRepositoryCacheClaim(ctx context.Context, gco *gitchangeoperatoriov1.GitChangeOperator) error {
+	size := repositoryCacheSize(gco.Spec.Operator.RepositoryCache)
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      repositoryCacheClaimName(gco),
+			Namespace: gco.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "git-change-operator",
+				"app.kubernetes.io/component": "repository-cache",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: gco.Spec.Operator.RepositoryCache.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(gco, pvc, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(pvc), found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, pvc)
+	} else if err != nil {
+		return err
+	}
+
+	if current := found.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(current) <= 0 {
+		return nil
+	}
+	patch := client.MergeFrom(found.DeepCopy())
+	found.Spec.Resources.Requests[corev1.ResourceStorage] = size
 	return r.Patch(ctx, found, patch)
 }
 
@@ -682,5 +786,6 @@ func (r *GitChangeOperatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&gitchangeoperatoriov1.GitChangeOperator{}).
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
 		Complete(r)
 }
@@ -3,9 +3,9 @@ package controllers
 import (
 	"bytes"
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -14,8 +14,7 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/robfig/cron/v3"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -32,12 +31,27 @@ import (
 	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
 	"github.com/mihaigalos/git-change-operator/pkg/cel"
 	"github.com/mihaigalos/git-change-operator/pkg/encryption"
+	"github.com/mihaigalos/git-change-operator/pkg/gitauth"
+	"github.com/mihaigalos/git-change-operator/pkg/gitcache"
+	"github.com/mihaigalos/git-change-operator/pkg/render"
 )
 
+// noChangesMessage is reported when the repository already has the desired content
+const noChangesMessage = "Repository already up to date, nothing to commit"
+
 type GitCommitReconciler struct {
 	client.Client
 	Scheme           *runtime.Scheme
 	metricsCollector *MetricsCollector
+
+	// DefaultAuthor is the commit author for resources that do not set spec.author
+	DefaultAuthor gitv1.CommitIdentity
+
+	// RepositoryCache serves checkouts from cached repositories, nil clones every run from scratch
+	RepositoryCache *gitcache.Cache
+
+	// TagRetryInterval is the time between the attempts to tag a pushed commit, 5 minutes when zero
+	TagRetryInterval time.Duration
 }
 
 func (r *GitCommitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -82,14 +96,14 @@ func (r *GitCommitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	// For committed resources, still requeue periodically for TTL checking
-	if gitCommit.Status.Phase == gitv1.GitCommitPhaseCommitted {
+	// For committed or unchanged resources, still requeue periodically for TTL checking
+	if gitCommit.Status.Phase == gitv1.GitCommitPhaseCommitted || gitCommit.Status.Phase == gitv1.GitCommitPhaseNoChanges {
 		return ctrl.Result{RequeueAfter: time.Minute * 1}, nil
 	}
 
 	// For failed resources, only check TTL - don't retry the operation
 	// But still requeue periodically for TTL checking
-	if gitCommit.Status.Phase == gitv1.GitCommitPhaseFailed {
+	if gitCommit.Status.Phase == gitv1.GitCommitPhaseFailed || gitCommit.Status.Phase == gitv1.GitCommitPhasePartiallyCommitted {
 		return ctrl.Result{RequeueAfter: time.Minute * 1}, nil
 	}
 
@@ -105,6 +119,11 @@ func (r *GitCommitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	// A previous run pushed the commit but failed to tag it, only the tag is tried again
+	if gitCommit.Status.TagAttempts > 0 {
+		return r.retryTag(ctx, &gitCommit)
+	}
+
 	// Check REST API conditions if configured
 	if len(gitCommit.Spec.RestAPIs) > 0 {
 		// Circuit breaker: check if any REST API has exceeded retry limits
@@ -134,7 +153,16 @@ func (r *GitCommitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		log.Info("All REST API conditions met, proceeding with git commit")
 	}
 
-	auth, err := r.getAuthFromSecret(ctx, gitCommit.Namespace, gitCommit.Spec.AuthSecretRef, gitCommit.Spec.AuthSecretKey)
+	if gitCommit.Spec.FanOut != nil {
+		phase, message := r.commitFanOut(ctx, &gitCommit)
+		if err := r.updateStatus(ctx, &gitCommit, phase, message); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Info("Fan-out commit finished", "phase", phase, "message", message)
+		return ctrl.Result{RequeueAfter: time.Minute * 1}, nil
+	}
+
+	auth, err := r.getAuthFromSecret(ctx, gitCommit.Namespace, gitCommit.Spec.AuthSecretRef, gitCommit.Spec.AuthSecretKey, gitCommit.Spec.Repository)
 	if err != nil {
 		log.Error(err, "failed to get authentication")
 		r.updateStatus(ctx, &gitCommit, gitv1.GitCommitPhaseFailed, fmt.Sprintf("Authentication failed: %v", err))
@@ -142,6 +170,16 @@ func (r *GitCommitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	commitSHA, err := r.performGitCommit(ctx, &gitCommit, auth)
+	if stderrors.Is(err, errNoChanges) {
+		log.Info("Repository is already up to date, nothing to commit")
+		if err := r.updateStatus(ctx, &gitCommit, gitv1.GitCommitPhaseNoChanges, noChangesMessage); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Minute * 1}, nil
+	}
+	if stderrors.Is(err, errTagFailed) {
+		return r.tagFailed(ctx, &gitCommit, commitSHA, err)
+	}
 	if err != nil {
 		log.Error(err, "failed to perform git commit")
 		r.updateStatus(ctx, &gitCommit, gitv1.GitCommitPhaseFailed, fmt.Sprintf("Git commit failed: %v", err))
@@ -157,79 +195,115 @@ func (r *GitCommitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	return ctrl.Result{}, nil
 }
 
-func (r *GitCommitReconciler) getAuthFromSecret(ctx context.Context, namespace, secretName, secretKey string) (*githttp.BasicAuth, error) {
+func (r *GitCommitReconciler) getAuthFromSecret(ctx context.Context, namespace, secretName, secretKey, repository string) (transport.AuthMethod, error) {
 	var secret corev1.Secret
 	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, &secret); err != nil {
 		return nil, err
 	}
 
-	key := secretKey
-	if key == "" {
-		key = "token"
-	}
+	return gitauth.FromSecret(ctx, &secret, repository, secretKey)
+}
 
-	token, exists := secret.Data[key]
-	if !exists {
-		return nil, fmt.Errorf("key %s not found in secret %s", key, secretName)
+func (r *GitCommitReconciler) performGitCommit(ctx context.Context, gitCommit *gitv1.GitCommit, auth transport.AuthMethod) (string, error) {
+	repo, tempDir, cleanup, err := cloneRepository(ctx, r.RepositoryCache, gitCommit.Spec.Repository, []string{gitCommit.Spec.Branch, gitCommit.Spec.BaseBranch}, gitCommit.Spec.Clone, auth, "git-commit-")
+	if err != nil {
+		return "", err
 	}
+	defer cleanup()
 
-	username := "oauth2"
-	if usernameData, exists := secret.Data["username"]; exists {
-		username = string(usernameData)
+	w, err := repo.Worktree()
+	if err != nil {
+		return "", err
 	}
 
-	return &githttp.BasicAuth{
-		Username: username,
-		Password: string(token),
-	}, nil
-}
+	if gitCommit.Spec.Branch != "" {
+		start, err := branchStart(ctx, repo, gitCommit.Spec.Branch, gitCommit.Spec.BaseBranch)
+		if err != nil {
+			return "", err
+		}
+		branchRefName := plumbing.NewBranchReferenceName(gitCommit.Spec.Branch)
+		if err := checkoutBranch(repo, w, branchRefName, start, gitCommit.Spec.Clone); err != nil {
+			return "", err
+		}
+	}
 
-func (r *GitCommitReconciler) performGitCommit(ctx context.Context, gitCommit *gitv1.GitCommit, auth *githttp.BasicAuth) (string, error) {
-	tempDir, err := ioutil.TempDir("", "git-commit-")
+	signer, err := loadSigner(ctx, r.Client, gitCommit.Namespace, gitCommit.Spec.Signing)
 	if err != nil {
 		return "", err
 	}
-	defer os.RemoveAll(tempDir)
+	if tag := gitCommit.Spec.Tag; tag != nil && tag.Sign && signer == nil {
+		return "", fmt.Errorf("signing the tag requires spec.signing")
+	}
+	if err := checkReleaseProvider(gitCommit); err != nil {
+		return "", err
+	}
 
-	repo, err := git.PlainClone(tempDir, false, &git.CloneOptions{
-		URL:  gitCommit.Spec.Repository,
-		Auth: auth,
+	// The tag and release describe this run
+	gitCommit.Status.Tag, gitCommit.Status.ReleaseURL = "", ""
+
+	var commit plumbing.Hash
+	attempts, err := pushWithRetry(ctx, gitCommit.Spec.ConflictStrategy, func() error {
+		commit, err = r.commitChanges(ctx, gitCommit, repo, w, tempDir)
+		if err != nil {
+			return err
+		}
+		if commit, err = signCommit(repo, commit, signer); err != nil {
+			return err
+		}
+		return push(ctx, repo, &git.PushOptions{Auth: auth})
+	}, func() error {
+		// Start over from the new tip so append modes see the content pushed in the meantime
+		return resetToRemote(ctx, repo, w, gitCommit.Spec.Clone, auth)
 	})
 	if err != nil {
 		return "", err
 	}
+	if attempts > 1 {
+		log.FromContext(ctx).Info("Pushed after the branch moved on", "attempts", attempts)
+	}
 
-	w, err := repo.Worktree()
-	if err != nil {
-		return "", err
+	if gitCommit.Spec.Tag != nil {
+		if err := r.tagPushedCommit(ctx, gitCommit, repo, commit, signer, auth); err != nil {
+			return commit.String(), err
+		}
 	}
 
-	if gitCommit.Spec.Branch != "" && gitCommit.Spec.Branch != "main" && gitCommit.Spec.Branch != "master" {
-		branchRefName := plumbing.NewBranchReferenceName(gitCommit.Spec.Branch)
-		b := plumbing.NewHashReference(branchRefName, plumbing.ZeroHash)
+	return commit.String(), nil
+}
 
-		err = w.Checkout(&git.CheckoutOptions{
-			Branch: b.Name(),
-			Create: true,
-		})
+// commitChanges writes the files and resource references into the worktree and commits them
+func (r *GitCommitReconciler) commitChanges(ctx context.Context, gitCommit *gitv1.GitCommit, repo *git.Repository, w *git.Worktree, tempDir string) (plumbing.Hash, error) {
+	var fileData map[string]interface{}
+	if hasTemplates(gitCommit.Spec.Files) {
+		var err error
+		fileData, err = fileTemplateData(ctx, gitCommit, gitCommit.Status.RestAPIStatuses, headCommit(repo), gitCommit.Spec.ResourceRefs, r.fetchResource)
 		if err != nil {
-			headRef, err := repo.Head()
-			if err != nil {
-				return "", err
-			}
-			b = plumbing.NewHashReference(branchRefName, headRef.Hash())
-			err = repo.Storer.SetReference(b)
-			if err != nil {
-				return "", err
-			}
-			err = w.Checkout(&git.CheckoutOptions{Branch: b.Name()})
-			if err != nil {
-				return "", err
-			}
+			return plumbing.ZeroHash, err
 		}
 	}
 
 	for _, file := range gitCommit.Spec.Files {
+		file, err := prepareFile(repo, tempDir, gitCommit.Spec.Clone, file, gitCommit.Spec.Encryption, fileData)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if isFileOperation(file) {
+			if err := applyFileOperation(ctx, w, tempDir, file, gitCommit.Spec.Encryption); err != nil {
+				return plumbing.ZeroHash, err
+			}
+			continue
+		}
+		if file.SymlinkTarget != "" {
+			if err := writeSymlink(w, tempDir, file); err != nil {
+				return plumbing.ZeroHash, err
+			}
+			continue
+		}
+		mode, err := fileMode(file)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
 		var content []byte
 
 		// Determine content source
@@ -237,11 +311,26 @@ func (r *GitCommitReconciler) performGitCommit(ctx context.Context, gitCommit *g
 			// Use REST API response data from multiple APIs
 			content = r.buildFileContent(&file, gitCommit.Status.RestAPIStatuses)
 			if len(content) == 0 {
-				return "", fmt.Errorf("file %s requested REST API data but no formatted output available", file.Path)
+				return plumbing.ZeroHash, fmt.Errorf("file %s requested REST API data but no formatted output available", file.Path)
 			}
-		} else {
-			// Use provided content
-			content = []byte(file.Content)
+		} else if content, err = fileContent(file); err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if isPatchWriteMode(file.WriteMode) {
+			patched, rolledOver, err := patchFile(tempDir, file.Path, editOfFile(file), content, gitCommit.Spec.Encryption)
+			if stderrors.Is(err, errEditSkipped) {
+				continue
+			}
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+			if rolledOver != "" {
+				if _, err := w.Add(rolledOver); err != nil {
+					return plumbing.ZeroHash, err
+				}
+			}
+			content = patched
 		}
 
 		targetPath := file.Path
@@ -250,33 +339,39 @@ func (r *GitCommitReconciler) performGitCommit(ctx context.Context, gitCommit *g
 		if encryption.ShouldEncryptFile(file.Path, gitCommit.Spec.Encryption) {
 			encryptedContent, err := r.encryptFileContent(ctx, content, gitCommit.Spec.Encryption, gitCommit.Namespace)
 			if err != nil {
-				return "", fmt.Errorf("failed to encrypt file %s: %w", file.Path, err)
+				return plumbing.ZeroHash, fmt.Errorf("failed to encrypt file %s: %w", file.Path, err)
 			}
 			content = encryptedContent
 			targetPath = encryption.GetEncryptedFilePath(file.Path, gitCommit.Spec.Encryption)
 		}
 
-		filePath := filepath.Join(tempDir, targetPath)
+		filePath, err := worktreePath(tempDir, targetPath)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
 		dir := filepath.Dir(filePath)
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return "", err
+			return plumbing.ZeroHash, err
 		}
 
 		// Handle writeMode for file content
 		var finalContent []byte
 		if file.WriteMode == gitv1.WriteModeAppend {
-			existingContent, _ := ioutil.ReadFile(filePath)
+			existingContent, err := readWorktreeFile(tempDir, targetPath)
+			if err != nil && !os.IsNotExist(err) {
+				return plumbing.ZeroHash, err
+			}
 			finalContent = append(existingContent, content...)
 		} else {
 			finalContent = content
 		}
 
-		if err := ioutil.WriteFile(filePath, finalContent, 0644); err != nil {
-			return "", err
+		if err := writeFile(filePath, finalContent, mode); err != nil {
+			return plumbing.ZeroHash, err
 		}
 
 		if _, err := w.Add(targetPath); err != nil {
-			return "", err
+			return plumbing.ZeroHash, err
 		}
 	}
 
@@ -284,21 +379,40 @@ func (r *GitCommitReconciler) performGitCommit(ctx context.Context, gitCommit *g
 	for _, resourceRef := range gitCommit.Spec.ResourceRefs {
 		resourceFiles, err := r.processResourceRef(ctx, resourceRef, gitCommit.Namespace)
 		if err != nil {
-			return "", fmt.Errorf("failed to process resource reference %s/%s: %w", resourceRef.Kind, resourceRef.Name, err)
+			return plumbing.ZeroHash, fmt.Errorf("failed to process resource reference %s/%s: %w", resourceRef.Kind, resourceRef.Name, err)
 		}
 
 		for _, file := range resourceFiles {
+			if err := materializeFile(repo, tempDir, gitCommit.Spec.Clone, file, gitCommit.Spec.Encryption); err != nil {
+				return plumbing.ZeroHash, err
+			}
 			targetPath := file.Path
 
 			// Handle write modes
 			var content []byte
-			if file.WriteMode == gitv1.WriteModeAppend {
+			if resourceRef.Strategy.WriteMode == gitv1.WriteModeAppend {
 				// Read existing file if it exists
-				tempFilePath := filepath.Join(tempDir, file.Path)
-				if existingContent, err := ioutil.ReadFile(tempFilePath); err == nil {
+				existingContent, err := readWorktreeFile(tempDir, file.Path)
+				if err == nil {
 					content = append(existingContent, []byte("\n"+file.Content)...)
-				} else {
+				} else if os.IsNotExist(err) {
 					content = []byte(file.Content)
+				} else {
+					return plumbing.ZeroHash, err
+				}
+			} else if isPatchWriteMode(resourceRef.Strategy.WriteMode) {
+				var rolledOver string
+				content, rolledOver, err = patchFile(tempDir, file.Path, editOfStrategy(resourceRef.Strategy), []byte(file.Content), gitCommit.Spec.Encryption)
+				if stderrors.Is(err, errEditSkipped) {
+					continue
+				}
+				if err != nil {
+					return plumbing.ZeroHash, err
+				}
+				if rolledOver != "" {
+					if _, err := w.Add(rolledOver); err != nil {
+						return plumbing.ZeroHash, err
+					}
 				}
 			} else {
 				// Default to overwrite
@@ -309,47 +423,49 @@ func (r *GitCommitReconciler) performGitCommit(ctx context.Context, gitCommit *g
 			if encryption.ShouldEncryptFile(file.Path, gitCommit.Spec.Encryption) {
 				encryptedContent, err := r.encryptFileContent(ctx, content, gitCommit.Spec.Encryption, gitCommit.Namespace)
 				if err != nil {
-					return "", fmt.Errorf("failed to encrypt resource file %s: %w", file.Path, err)
+					return plumbing.ZeroHash, fmt.Errorf("failed to encrypt resource file %s: %w", file.Path, err)
 				}
 				content = encryptedContent
 				targetPath = encryption.GetEncryptedFilePath(file.Path, gitCommit.Spec.Encryption)
 			}
 
-			filePath := filepath.Join(tempDir, targetPath)
+			filePath, err := worktreePath(tempDir, targetPath)
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
 			dir := filepath.Dir(filePath)
 			if err := os.MkdirAll(dir, 0755); err != nil {
-				return "", err
+				return plumbing.ZeroHash, err
 			}
 
-			if err := ioutil.WriteFile(filePath, content, 0644); err != nil {
-				return "", err
+			if err := writeFile(filePath, content, 0); err != nil {
+				return plumbing.ZeroHash, err
 			}
 
 			if _, err := w.Add(targetPath); err != nil {
-				return "", err
+				return plumbing.ZeroHash, err
 			}
 		}
 	}
 
-	commit, err := w.Commit(gitCommit.Spec.CommitMessage, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "Git Change Operator",
-			Email: "git-change-operator@galos.one",
-			When:  time.Now(),
-		},
-	})
+	files, err := changedFiles(repo)
 	if err != nil {
-		return "", err
+		return plumbing.ZeroHash, err
 	}
-
-	err = repo.Push(&git.PushOptions{
-		Auth: auth,
-	})
-	if err != nil {
-		return "", err
+	if len(files) == 0 && gitCommit.Spec.EmptyCommitPolicy != gitv1.EmptyCommitPolicyAllow {
+		return plumbing.ZeroHash, errNoChanges
+	}
+	text := gitCommit.Spec.CommitMessage
+	if gitCommit.Spec.Template {
+		data := templateData(gitCommit, gitCommit.Status.RestAPIStatuses, files, headCommit(repo))
+		if text, err = render.String("commitMessage", text, data); err != nil {
+			return plumbing.ZeroHash, err
+		}
 	}
 
-	return commit.String(), nil
+	options := commitOptions(gitCommit.Spec.CommitMetadata, r.DefaultAuthor)
+	message := commitMessage(text, gitCommit.Spec.CommitMetadata, options.Committer, "GitCommit", gitCommit)
+	return w.Commit(message, options)
 }
 
 func (r *GitCommitReconciler) updateStatus(ctx context.Context, gitCommit *gitv1.GitCommit, phase gitv1.GitCommitPhase, message string) error {
@@ -380,6 +496,18 @@ func (r *GitCommitReconciler) updateStatus(ctx context.Context, gitCommit *gitv1
 			fresh.Status.CommitSHA = gitCommit.Status.CommitSHA
 		}
 
+		// Copy over the results of a fan-out
+		if len(gitCommit.Status.Repositories) > 0 {
+			fresh.Status.Repositories = gitCommit.Status.Repositories
+		}
+
+		// Copy over the tag and release of the commit, a commit without them clears them
+		if phase == gitv1.GitCommitPhaseCommitted || gitCommit.Status.Tag != "" {
+			fresh.Status.Tag = gitCommit.Status.Tag
+			fresh.Status.ReleaseURL = gitCommit.Status.ReleaseURL
+		}
+		fresh.Status.TagAttempts = gitCommit.Status.TagAttempts
+
 		err := r.Status().Update(ctx, fresh)
 		if err == nil {
 			// Success - update the original object with the fresh data
@@ -487,8 +615,8 @@ func (r *GitCommitReconciler) processResourceRef(ctx context.Context, resourceRe
 		var filePath string
 		content := fmt.Sprintf("%v", value)
 
-		// For append mode, write directly to the path file
-		if resourceRef.Strategy.WriteMode == gitv1.WriteModeAppend {
+		// For append and patch modes, write directly to the path file
+		if resourceRef.Strategy.WriteMode == gitv1.WriteModeAppend || isPatchWriteMode(resourceRef.Strategy.WriteMode) {
 			filePath = resourceRef.Strategy.Path
 		} else {
 			// For overwrite mode, create path/filename structure
@@ -1016,7 +1144,18 @@ func (r *GitCommitReconciler) handleScheduledGitCommit(ctx context.Context, gitC
 		log.Info("All REST API conditions met, proceeding with scheduled git commit")
 	}
 
-	auth, err := r.getAuthFromSecret(ctx, gitCommit.Namespace, gitCommit.Spec.AuthSecretRef, gitCommit.Spec.AuthSecretKey)
+	if gitCommit.Spec.FanOut != nil {
+		phase, message := r.commitFanOut(ctx, gitCommit)
+		log.Info("Scheduled fan-out commit finished", "phase", phase, "message", message)
+		nextTime := schedule.Next(now)
+		nextTimeMeta := metav1.NewTime(nextTime)
+		if err := r.recordExecution(ctx, gitCommit, "", phase, message, &nextTimeMeta); err != nil {
+			return ctrl.Result{RequeueAfter: time.Minute}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Until(nextTime)}, nil
+	}
+
+	auth, err := r.getAuthFromSecret(ctx, gitCommit.Namespace, gitCommit.Spec.AuthSecretRef, gitCommit.Spec.AuthSecretKey, gitCommit.Spec.Repository)
 	if err != nil {
 		log.Error(err, "failed to get authentication")
 		// Calculate next execution time
@@ -1027,12 +1166,21 @@ func (r *GitCommitReconciler) handleScheduledGitCommit(ctx context.Context, gitC
 	}
 
 	commitSHA, err := r.performGitCommit(ctx, gitCommit, auth)
+	if stderrors.Is(err, errNoChanges) {
+		log.Info("Repository is already up to date, skipping this scheduled execution")
+		nextTime := schedule.Next(now)
+		nextTimeMeta := metav1.NewTime(nextTime)
+		if err := r.recordExecution(ctx, gitCommit, "", gitv1.GitCommitPhaseNoChanges, noChangesMessage, &nextTimeMeta); err != nil {
+			return ctrl.Result{RequeueAfter: time.Minute}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Until(nextTime)}, nil
+	}
 	if err != nil {
 		log.Error(err, "failed to perform scheduled git commit")
 		// Calculate next execution time
 		nextTime := schedule.Next(now)
 		nextTimeMeta := metav1.NewTime(nextTime)
-		r.recordExecution(ctx, gitCommit, "", gitv1.GitCommitPhaseFailed, fmt.Sprintf("Git commit failed: %v", err), &nextTimeMeta)
+		r.recordExecution(ctx, gitCommit, commitSHA, gitv1.GitCommitPhaseFailed, fmt.Sprintf("Git commit failed: %v", err), &nextTimeMeta)
 		return ctrl.Result{RequeueAfter: time.Until(nextTime)}, nil
 	}
 
@@ -1088,6 +1236,11 @@ func (r *GitCommitReconciler) recordExecution(ctx context.Context, gitCommit *gi
 			Phase:         phase,
 			Message:       message,
 		}
+		// Runs that pushed a commit record its tag, empty when it has none or tagging failed
+		if commitSHA != "" {
+			record.Tag = gitCommit.Status.Tag
+			record.ReleaseURL = gitCommit.Status.ReleaseURL
+		}
 
 		// Add to execution history
 		fresh.Status.ExecutionHistory = append([]gitv1.ExecutionRecord{record}, fresh.Status.ExecutionHistory...)
@@ -1104,9 +1257,19 @@ func (r *GitCommitReconciler) recordExecution(ctx context.Context, gitCommit *gi
 		// Update current status fields
 		fresh.Status.Phase = phase
 		fresh.Status.Message = message
-		fresh.Status.CommitSHA = commitSHA
+		// Keep pointing at the last commit when nothing was committed
+		if commitSHA != "" {
+			fresh.Status.CommitSHA = commitSHA
+		}
 		fresh.Status.LastSync = &now
 		fresh.Status.LastScheduledTime = gitCommit.Status.LastScheduledTime
+		if len(gitCommit.Status.Repositories) > 0 {
+			fresh.Status.Repositories = gitCommit.Status.Repositories
+		}
+		if commitSHA != "" {
+			fresh.Status.Tag = record.Tag
+			fresh.Status.ReleaseURL = record.ReleaseURL
+		}
 		// Only update NextScheduledTime if provided (otherwise preserve what's in fresh)
 		if nextScheduledTime != nil {
 			fresh.Status.NextScheduledTime = nextScheduledTime
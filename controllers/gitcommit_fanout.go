@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+const defaultFanOutConcurrency = 4
+
+// Keys of the inventory Secrets selected by spec.fanOut.selector
+const (
+	inventoryRepositoryKey = "repository"
+	inventoryBranchKey     = "branch"
+	inventoryBaseBranchKey = "baseBranch"
+)
+
+// commitFanOut commits the files of gitCommit to every repository of its fan-out, records the
+// result per repository in gitCommit.Status.Repositories and returns the overall phase and message
+func (r *GitCommitReconciler) commitFanOut(ctx context.Context, gitCommit *gitv1.GitCommit) (gitv1.GitCommitPhase, string) {
+	targets, err := r.fanOutTargets(ctx, gitCommit)
+	if err != nil {
+		return gitv1.GitCommitPhaseFailed, fmt.Sprintf("Failed to resolve repositories: %v", err)
+	}
+	if len(targets) == 0 {
+		return gitv1.GitCommitPhaseFailed, "No repositories to commit to"
+	}
+
+	concurrency := gitCommit.Spec.FanOut.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultFanOutConcurrency
+	}
+	failFast := gitCommit.Spec.FanOut.FailurePolicy == gitv1.FanOutFailurePolicyFailFast
+
+	statuses := make([]gitv1.RepositoryStatus, len(targets))
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	slots := make(chan struct{}, concurrency)
+	for i, target := range targets {
+		statuses[i] = gitv1.RepositoryStatus{Repository: target.Repository, Branch: target.Branch}
+
+		slots <- struct{}{}
+		mu.Lock()
+		skip := failFast && failed
+		mu.Unlock()
+		if skip {
+			<-slots
+			statuses[i].Phase = gitv1.GitCommitPhasePending
+			statuses[i].Message = "Skipped after committing to another repository failed"
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, target gitv1.RepositoryTarget) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			status := r.commitToRepository(ctx, gitCommit, target)
+			mu.Lock()
+			statuses[i] = status
+			if status.Phase == gitv1.GitCommitPhaseFailed {
+				failed = true
+			}
+			mu.Unlock()
+		}(i, target)
+	}
+	wg.Wait()
+
+	gitCommit.Status.Repositories = statuses
+	return fanOutResult(statuses)
+}
+
+// commitToRepository commits the files of gitCommit to a single repository of its fan-out
+func (r *GitCommitReconciler) commitToRepository(ctx context.Context, gitCommit *gitv1.GitCommit, target gitv1.RepositoryTarget) gitv1.RepositoryStatus {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("repository", target.Repository, "branch", target.Branch))
+	status := gitv1.RepositoryStatus{Repository: target.Repository, Branch: target.Branch}
+
+	// The copy only differs in the repository, so templates render as for a single repository
+	single := gitCommit.DeepCopy()
+	single.Spec.Repository = target.Repository
+	single.Spec.Branch = target.Branch
+	single.Spec.BaseBranch = target.BaseBranch
+	single.Spec.AuthSecretRef = target.AuthSecretRef
+	single.Spec.AuthSecretKey = target.AuthSecretKey
+	single.Spec.FanOut = nil
+
+	auth, err := r.getAuthFromSecret(ctx, single.Namespace, single.Spec.AuthSecretRef, single.Spec.AuthSecretKey, single.Spec.Repository)
+	if err != nil {
+		status.Phase = gitv1.GitCommitPhaseFailed
+		status.Message = fmt.Sprintf("Authentication failed: %v", err)
+		return status
+	}
+
+	commitSHA, err := r.performGitCommit(ctx, single, auth)
+	switch {
+	case stderrors.Is(err, errNoChanges):
+		status.Phase = gitv1.GitCommitPhaseNoChanges
+	case err != nil:
+		log.FromContext(ctx).Error(err, "failed to commit to repository")
+		status.Phase = gitv1.GitCommitPhaseFailed
+		status.Message = fmt.Sprintf("Git commit failed: %v", err)
+		// Set when the commit was pushed but tagging it failed
+		status.CommitSHA = commitSHA
+	default:
+		status.Phase = gitv1.GitCommitPhaseCommitted
+		status.CommitSHA = commitSHA
+		status.Tag = single.Status.Tag
+		status.ReleaseURL = single.Status.ReleaseURL
+	}
+	return status
+}
+
+// fanOutTargets lists spec.repository, the listed repositories and the ones selected from the
+// inventory, in that order. Repeated repository and branch pairs are committed to once.
+func (r *GitCommitReconciler) fanOutTargets(ctx context.Context, gitCommit *gitv1.GitCommit) ([]gitv1.RepositoryTarget, error) {
+	spec := gitCommit.Spec
+	var targets []gitv1.RepositoryTarget
+	if spec.Repository != "" {
+		targets = append(targets, gitv1.RepositoryTarget{Repository: spec.Repository})
+	}
+	targets = append(targets, spec.FanOut.Repositories...)
+
+	if spec.FanOut.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.FanOut.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+		var secrets corev1.SecretList
+		if err := r.List(ctx, &secrets, client.InNamespace(gitCommit.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		sort.Slice(secrets.Items, func(i, j int) bool { return secrets.Items[i].Name < secrets.Items[j].Name })
+		for _, secret := range secrets.Items {
+			repository := strings.TrimSpace(string(secret.Data[inventoryRepositoryKey]))
+			if repository == "" {
+				return nil, fmt.Errorf("secret %s selected as repository has no %q key", secret.Name, inventoryRepositoryKey)
+			}
+			targets = append(targets, gitv1.RepositoryTarget{
+				Repository:    repository,
+				Branch:        strings.TrimSpace(string(secret.Data[inventoryBranchKey])),
+				BaseBranch:    strings.TrimSpace(string(secret.Data[inventoryBaseBranchKey])),
+				AuthSecretRef: secret.Name,
+			})
+		}
+	}
+
+	seen := map[string]bool{}
+	unique := targets[:0]
+	for _, target := range targets {
+		if target.Branch == "" {
+			target.Branch = spec.Branch
+			if target.BaseBranch == "" {
+				target.BaseBranch = spec.BaseBranch
+			}
+		}
+		if target.AuthSecretRef == "" {
+			target.AuthSecretRef = spec.AuthSecretRef
+		}
+		if target.AuthSecretKey == "" {
+			target.AuthSecretKey = spec.AuthSecretKey
+		}
+
+		key := target.Repository + " " + target.Branch
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, target)
+	}
+	return unique, nil
+}
+
+// fanOutResult summarizes the results per repository. Repositories without changes count as
+// succeeded, skipped ones as failed.
+func fanOutResult(statuses []gitv1.RepositoryStatus) (gitv1.GitCommitPhase, string) {
+	committed, unchanged, failed := 0, 0, 0
+	for _, status := range statuses {
+		switch status.Phase {
+		case gitv1.GitCommitPhaseCommitted:
+			committed++
+		case gitv1.GitCommitPhaseNoChanges:
+			unchanged++
+		default:
+			failed++
+		}
+	}
+
+	total := len(statuses)
+	switch {
+	case failed == total:
+		return gitv1.GitCommitPhaseFailed, fmt.Sprintf("Committing failed for all %d repositories", total)
+	case failed > 0:
+		return gitv1.GitCommitPhasePartiallyCommitted, fmt.Sprintf("Committed to %d of %d repositories, %d unchanged, %d failed", committed, total, unchanged, failed)
+	case committed == 0:
+		return gitv1.GitCommitPhaseNoChanges, noChangesMessage
+	}
+	return gitv1.GitCommitPhaseCommitted, fmt.Sprintf("Committed to %d of %d repositories, %d unchanged", committed, total, unchanged)
+}
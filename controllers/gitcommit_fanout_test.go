@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func fanOutReconciler(objects ...runtime.Object) *GitCommitReconciler {
+	scheme := runtime.NewScheme()
+	gitv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+	return &GitCommitReconciler{Client: c, Scheme: scheme}
+}
+
+func inventorySecret(name string, labels map[string]string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestFanOutTargets(t *testing.T) {
+	shared := map[string]string{"ca-bundle": "true"}
+	r := fanOutReconciler(
+		inventorySecret("service-b", shared, map[string]string{"repository": "https://git.example.com/org/service-b.git", "token": "b"}),
+		inventorySecret("service-a", shared, map[string]string{"repository": "https://git.example.com/org/service-a.git", "branch": "develop", "token": "a"}),
+		inventorySecret("service-c", map[string]string{"ca-bundle": "false"}, map[string]string{"repository": "https://git.example.com/org/service-c.git"}),
+	)
+
+	gitCommit := &gitv1.GitCommit{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "default"},
+		Spec: gitv1.GitCommitSpec{
+			Repository:    "https://git.example.com/org/platform.git",
+			Branch:        "main",
+			BaseBranch:    "release",
+			AuthSecretRef: "platform-token",
+			AuthSecretKey: "token",
+			FanOut: &gitv1.FanOut{
+				Repositories: []gitv1.RepositoryTarget{
+					{Repository: "https://git.example.com/org/platform.git"},
+					{Repository: "https://git.example.com/org/docs.git", Branch: "gh-pages", AuthSecretRef: "docs-token"},
+				},
+				Selector: &metav1.LabelSelector{MatchLabels: shared},
+			},
+		},
+	}
+
+	got, err := r.fanOutTargets(context.Background(), gitCommit)
+	if err != nil {
+		t.Fatalf("fanOutTargets() error = %v", err)
+	}
+	want := []gitv1.RepositoryTarget{
+		{Repository: "https://git.example.com/org/platform.git", Branch: "main", BaseBranch: "release", AuthSecretRef: "platform-token", AuthSecretKey: "token"},
+		{Repository: "https://git.example.com/org/docs.git", Branch: "gh-pages", AuthSecretRef: "docs-token", AuthSecretKey: "token"},
+		{Repository: "https://git.example.com/org/service-a.git", Branch: "develop", AuthSecretRef: "service-a", AuthSecretKey: "token"},
+		{Repository: "https://git.example.com/org/service-b.git", Branch: "main", BaseBranch: "release", AuthSecretRef: "service-b", AuthSecretKey: "token"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fanOutTargets() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// A selected Secret without a repository URL is a configuration error
+	gitCommit.Spec.FanOut.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"ca-bundle": "false"}}
+	r = fanOutReconciler(inventorySecret("broken", map[string]string{"ca-bundle": "false"}, map[string]string{"token": "x"}))
+	if _, err := r.fanOutTargets(context.Background(), gitCommit); err == nil {
+		t.Error("Expected an error for a Secret without a repository")
+	}
+}
+
+func TestCommitFanOutFailurePolicy(t *testing.T) {
+	tests := []struct {
+		policy gitv1.FanOutFailurePolicy
+		want   []gitv1.GitCommitPhase
+	}{
+		{policy: gitv1.FanOutFailurePolicyContinue, want: []gitv1.GitCommitPhase{gitv1.GitCommitPhaseFailed, gitv1.GitCommitPhaseFailed, gitv1.GitCommitPhaseFailed}},
+		{policy: gitv1.FanOutFailurePolicyFailFast, want: []gitv1.GitCommitPhase{gitv1.GitCommitPhaseFailed, gitv1.GitCommitPhasePending, gitv1.GitCommitPhasePending}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			// The credentials are missing, so every commit fails before cloning
+			gitCommit := &gitv1.GitCommit{
+				ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "default"},
+				Spec: gitv1.GitCommitSpec{
+					Branch:        "main",
+					AuthSecretRef: "missing",
+					FanOut: &gitv1.FanOut{
+						Repositories: []gitv1.RepositoryTarget{
+							{Repository: "https://git.example.com/org/a.git"},
+							{Repository: "https://git.example.com/org/b.git"},
+							{Repository: "https://git.example.com/org/c.git"},
+						},
+						MaxConcurrency: 1,
+						FailurePolicy:  tt.policy,
+					},
+				},
+			}
+
+			phase, _ := fanOutReconciler().commitFanOut(context.Background(), gitCommit)
+			if phase != gitv1.GitCommitPhaseFailed {
+				t.Errorf("phase = %s, want Failed", phase)
+			}
+			var got []gitv1.GitCommitPhase
+			for _, status := range gitCommit.Status.Repositories {
+				got = append(got, status.Phase)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("repository phases = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFanOutResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		phases []gitv1.GitCommitPhase
+		want   gitv1.GitCommitPhase
+	}{
+		{name: "all committed", phases: []gitv1.GitCommitPhase{gitv1.GitCommitPhaseCommitted, gitv1.GitCommitPhaseNoChanges}, want: gitv1.GitCommitPhaseCommitted},
+		{name: "all unchanged", phases: []gitv1.GitCommitPhase{gitv1.GitCommitPhaseNoChanges, gitv1.GitCommitPhaseNoChanges}, want: gitv1.GitCommitPhaseNoChanges},
+		{name: "partial failure", phases: []gitv1.GitCommitPhase{gitv1.GitCommitPhaseCommitted, gitv1.GitCommitPhaseFailed}, want: gitv1.GitCommitPhasePartiallyCommitted},
+		{name: "skipped after failure", phases: []gitv1.GitCommitPhase{gitv1.GitCommitPhaseNoChanges, gitv1.GitCommitPhaseFailed, gitv1.GitCommitPhasePending}, want: gitv1.GitCommitPhasePartiallyCommitted},
+		{name: "all failed", phases: []gitv1.GitCommitPhase{gitv1.GitCommitPhaseFailed, gitv1.GitCommitPhasePending}, want: gitv1.GitCommitPhaseFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statuses []gitv1.RepositoryStatus
+			for _, phase := range tt.phases {
+				statuses = append(statuses, gitv1.RepositoryStatus{Phase: phase})
+			}
+			if got, message := fanOutResult(statuses); got != tt.want {
+				t.Errorf("fanOutResult() = %s (%s), want %s", got, message, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+const (
+	defaultPushAttempts = 5
+	defaultPushBackoff  = time.Second
+	// maxPushBackoff caps the doubling delay between push attempts
+	maxPushBackoff = 30 * time.Second
+)
+
+// pushWithRetry runs attempt until it succeeds. When the push is rejected because the branch
+// moved on and the strategy is Rebase, rebase is called to start over from the new tip before
+// the next attempt. Without a strategy the first rejection fails, as it did before conflict
+// strategies existed. It returns the number of attempts made.
+func pushWithRetry(ctx context.Context, strategy *gitv1.ConflictStrategy, attempt func() error, rebase func() error) (int, error) {
+	maxAttempts, backoff := 1, defaultPushBackoff
+	if strategy != nil && strategy.Type != gitv1.ConflictStrategyFail {
+		maxAttempts = defaultPushAttempts
+		if strategy.MaxAttempts > 0 {
+			maxAttempts = strategy.MaxAttempts
+		}
+		if strategy.Backoff != nil {
+			backoff = strategy.Backoff.Duration
+		}
+	}
+
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil {
+			return i, nil
+		}
+		if !isPushRejected(err) {
+			return i, err
+		}
+		if i >= maxAttempts {
+			if maxAttempts > 1 {
+				return i, fmt.Errorf("push still rejected after %d attempts: %w", i, err)
+			}
+			return i, err
+		}
+
+		log.FromContext(ctx).Info("Push rejected, retrying on top of the new tip", "attempt", i, "backoff", backoff, "reason", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return i, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxPushBackoff {
+			backoff = maxPushBackoff
+		}
+
+		if err := rebase(); err != nil {
+			return i, fmt.Errorf("failed to fetch the new tip after the push was rejected: %w", err)
+		}
+	}
+}
+
+// rejectedStatuses are the ref statuses a server reports when the branch moved on during the
+// push
+var rejectedStatuses = []string{"non-fast-forward", "fetch first", "failed to update ref", "failed to lock"}
+
+// isPushRejected reports whether the push failed because the remote branch moved on, either
+// detected by go-git before pushing or reported by the server for a concurrent update.
+// go-git v5 has no sentinel error for either: its own check formats git.ErrNonFastForwardUpdate
+// with the ref name and the status of the server is returned as "command error on REF: STATUS",
+// so only these two forms are recognized. Transport errors are never a rejection.
+func isPushRejected(err error) bool {
+	if errors.Is(err, git.ErrNonFastForwardUpdate) {
+		return true
+	}
+	for _, transportErr := range []error{
+		transport.ErrAuthenticationRequired,
+		transport.ErrAuthorizationFailed,
+		transport.ErrRepositoryNotFound,
+		transport.ErrInvalidAuthMethod,
+	} {
+		if errors.Is(err, transportErr) {
+			return false
+		}
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		message := err.Error()
+		if strings.HasPrefix(message, git.ErrNonFastForwardUpdate.Error()+": ") {
+			return true
+		}
+		if strings.HasPrefix(message, "command error on ") {
+			_, status, _ := strings.Cut(message, ": ")
+			for _, reason := range rejectedStatuses {
+				if strings.HasPrefix(status, reason) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// resetToRemote fetches the current branch and hard resets the worktree to its new tip. The
+// full history is fetched when a shallow clone cannot connect the new tip to its commits.
+func resetToRemote(ctx context.Context, repo *git.Repository, w *git.Worktree, options *gitv1.CloneOptions, auth transport.AuthMethod) error {
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	branch := head.Name()
+	remoteRef := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch.Short())
+	fetchOptions := &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec("+" + branch.String() + ":" + remoteRef.String())},
+		Auth:       auth,
+	}
+	err = repo.FetchContext(ctx, fetchOptions)
+	if err != nil && err != git.NoErrAlreadyUpToDate && errors.Is(err, plumbing.ErrObjectNotFound) {
+		if err = deepen(ctx, repo, auth); err == nil {
+			err = repo.FetchContext(ctx, fetchOptions)
+		}
+	}
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+
+	ref, err := repo.Reference(remoteRef, true)
+	if err != nil {
+		return err
+	}
+
+	return checkoutBranch(repo, w, branch, ref.Hash(), options)
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestPushWithRetry(t *testing.T) {
+	rejected := errors.New("command error on refs/heads/main: failed to update ref")
+	fast := &metav1.Duration{Duration: time.Millisecond}
+
+	tests := []struct {
+		name         string
+		strategy     *gitv1.ConflictStrategy
+		results      []error
+		wantAttempts int
+		wantRebases  int
+		wantErr      bool
+	}{
+		{
+			name:         "first push succeeds",
+			strategy:     &gitv1.ConflictStrategy{Backoff: fast},
+			results:      []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "rebases until the push goes through",
+			strategy:     &gitv1.ConflictStrategy{Backoff: fast},
+			results:      []error{rejected, fmt.Errorf("non-fast-forward update: refs/heads/main"), nil},
+			wantAttempts: 3,
+			wantRebases:  2,
+		},
+		{
+			name:         "gives up after max attempts",
+			strategy:     &gitv1.ConflictStrategy{MaxAttempts: 2, Backoff: fast},
+			results:      []error{rejected, rejected, nil},
+			wantAttempts: 2,
+			wantRebases:  1,
+			wantErr:      true,
+		},
+		{
+			name:         "fail strategy does not retry",
+			strategy:     &gitv1.ConflictStrategy{Type: gitv1.ConflictStrategyFail, MaxAttempts: 5},
+			results:      []error{rejected, nil},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "without a strategy the first rejection fails",
+			results:      []error{rejected, nil},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "other errors are not retried",
+			strategy:     &gitv1.ConflictStrategy{Backoff: fast},
+			results:      []error{transport.ErrAuthenticationRequired, nil},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, rebases := 0, 0
+			attempts, err := pushWithRetry(context.Background(), tt.strategy, func() error {
+				calls++
+				return tt.results[calls-1]
+			}, func() error {
+				rebases++
+				return nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pushWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts || calls != tt.wantAttempts || rebases != tt.wantRebases {
+				t.Errorf("Got attempts=%d calls=%d rebases=%d, want %d attempts and %d rebases",
+					attempts, calls, rebases, tt.wantAttempts, tt.wantRebases)
+			}
+		})
+	}
+}
+
+func TestPushWithRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := pushWithRetry(ctx, &gitv1.ConflictStrategy{}, func() error {
+		return errors.New("non-fast-forward update: refs/heads/main")
+	}, func() error {
+		t.Fatal("Expected no rebase after the context was cancelled")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("pushWithRetry() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestIsPushRejected(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: git.ErrNonFastForwardUpdate, want: true},
+		{err: fmt.Errorf("non-fast-forward update: refs/heads/main"), want: true},
+		{err: fmt.Errorf("command error on refs/heads/main: failed to update ref"), want: true},
+		{err: fmt.Errorf("command error on refs/heads/main: fetch first"), want: true},
+		{err: fmt.Errorf("push failed: %w", fmt.Errorf("command error on refs/heads/main: non-fast-forward")), want: true},
+		{err: fmt.Errorf("command error on refs/heads/main: protected branch hook declined"), want: false},
+		{err: transport.ErrAuthenticationRequired, want: false},
+		{err: fmt.Errorf("%w: non-fast-forward update", transport.ErrAuthorizationFailed), want: false},
+		{err: errors.New("remote: the branch is not a fast-forward, fetch first"), want: false},
+	}
+	for _, tt := range tests {
+		if got := isPushRejected(tt.err); got != tt.want {
+			t.Errorf("isPushRejected(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
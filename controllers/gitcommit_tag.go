@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/gitauth"
+	"github.com/mihaigalos/git-change-operator/pkg/gitprovider"
+	"github.com/mihaigalos/git-change-operator/pkg/gitsign"
+	"github.com/mihaigalos/git-change-operator/pkg/render"
+)
+
+// errTagFailed is returned with the SHA of a commit that was pushed but whose tag or release
+// failed. Later runs tag that commit again instead of committing.
+var errTagFailed = errors.New("tagging failed")
+
+const (
+	// maxTagAttempts is how often the tag of a pushed commit is tried before the GitCommit fails
+	maxTagAttempts = 5
+	// defaultTagRetryInterval is the time between the attempts to tag a pushed commit
+	defaultTagRetryInterval = 5 * time.Minute
+)
+
+// tagFailed records that the pushed commit could not be tagged. The GitCommit stays Running and
+// the tag is retried until maxTagAttempts is reached, then it fails.
+func (r *GitCommitReconciler) tagFailed(ctx context.Context, gitCommit *gitv1.GitCommit, commitSHA string, err error) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+	gitCommit.Status.CommitSHA = commitSHA
+	gitCommit.Status.TagAttempts++
+
+	if gitCommit.Status.TagAttempts >= maxTagAttempts {
+		log.Error(err, "giving up on tagging the pushed commit", "attempts", gitCommit.Status.TagAttempts)
+		message := fmt.Sprintf("Tagging failed after %d attempts: %v", gitCommit.Status.TagAttempts, err)
+		gitCommit.Status.TagAttempts = 0
+		r.updateStatus(ctx, gitCommit, gitv1.GitCommitPhaseFailed, message)
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
+	}
+
+	log.Error(err, "failed to tag the pushed commit", "attempt", gitCommit.Status.TagAttempts)
+	r.updateStatus(ctx, gitCommit, gitv1.GitCommitPhaseRunning,
+		fmt.Sprintf("Retrying the tag (attempt %d of %d): %v", gitCommit.Status.TagAttempts, maxTagAttempts, err))
+	return ctrl.Result{RequeueAfter: r.tagRetryInterval()}, nil
+}
+
+// retryTag tags status.commitSHA, the commit a previous run pushed, without writing or pushing
+// any file
+func (r *GitCommitReconciler) retryTag(ctx context.Context, gitCommit *gitv1.GitCommit) (ctrl.Result, error) {
+	// Status updates trigger reconciles as well, wait for the interval since the last attempt
+	if gitCommit.Status.LastSync != nil {
+		if wait := r.tagRetryInterval() - time.Since(gitCommit.Status.LastSync.Time); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	commitSHA := gitCommit.Status.CommitSHA
+	if err := r.tagCommitAgain(ctx, gitCommit, plumbing.NewHash(commitSHA)); err != nil {
+		return r.tagFailed(ctx, gitCommit, commitSHA, err)
+	}
+
+	gitCommit.Status.TagAttempts = 0
+	if err := r.updateStatus(ctx, gitCommit, gitv1.GitCommitPhaseCommitted, "Git commit completed successfully"); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.FromContext(ctx).Info("Tagged the pushed commit", "commit", commitSHA, "tag", gitCommit.Status.Tag)
+	return ctrl.Result{}, nil
+}
+
+// tagCommitAgain clones the repository to tag commit, which was pushed before
+func (r *GitCommitReconciler) tagCommitAgain(ctx context.Context, gitCommit *gitv1.GitCommit, commit plumbing.Hash) error {
+	if gitCommit.Spec.Tag == nil {
+		return nil
+	}
+	auth, err := r.getAuthFromSecret(ctx, gitCommit.Namespace, gitCommit.Spec.AuthSecretRef, gitCommit.Spec.AuthSecretKey, gitCommit.Spec.Repository)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	repo, _, cleanup, err := cloneRepository(ctx, r.RepositoryCache, gitCommit.Spec.Repository, []string{gitCommit.Spec.Branch, gitCommit.Spec.BaseBranch}, gitCommit.Spec.Clone, auth, "git-commit-")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if _, err := repo.CommitObject(commit); err != nil {
+		return fmt.Errorf("pushed commit %s is not in the clone: %w", commit, err)
+	}
+	signer, err := loadSigner(ctx, r.Client, gitCommit.Namespace, gitCommit.Spec.Signing)
+	if err != nil {
+		return err
+	}
+	return r.tagPushedCommit(ctx, gitCommit, repo, commit, signer, auth)
+}
+
+func (r *GitCommitReconciler) tagRetryInterval() time.Duration {
+	if r.TagRetryInterval > 0 {
+		return r.TagRetryInterval
+	}
+	return defaultTagRetryInterval
+}
+
+// tagPushedCommit tags the pushed commit as configured in spec.tag. The tag is signed by signer
+// when spec.tag.sign is set.
+func (r *GitCommitReconciler) tagPushedCommit(ctx context.Context, gitCommit *gitv1.GitCommit, repo *git.Repository, commit plumbing.Hash, signer gitsign.Signer, auth transport.AuthMethod) error {
+	if !gitCommit.Spec.Tag.Sign {
+		signer = nil
+	}
+	if err := r.tagCommit(ctx, gitCommit, repo, commit, signer, auth); err != nil {
+		gitCommit.Status.Tag, gitCommit.Status.ReleaseURL = "", ""
+		return fmt.Errorf("commit %s was pushed but %w: %w", commit, errTagFailed, err)
+	}
+	return nil
+}
+
+// tagCommit creates the tag of spec.tag at the pushed commit, pushes it and publishes the
+// release. A tag that already points at the commit and its release are kept, so a retried run
+// succeeds.
+// The tag and release are recorded in gitCommit.Status.
+func (r *GitCommitReconciler) tagCommit(ctx context.Context, gitCommit *gitv1.GitCommit, repo *git.Repository, commit plumbing.Hash, signer gitsign.Signer, auth transport.AuthMethod) error {
+	spec := gitCommit.Spec.Tag
+	data := templateData(gitCommit, gitCommit.Status.RestAPIStatuses, nil, "")
+	data["commitSHA"] = commit.String()
+
+	name, err := render.String("tag.name", spec.Name, data)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if err := validateTagName(name); err != nil {
+		return err
+	}
+	data["tag"] = name
+
+	message, err := render.String("tag.message", spec.Message, data)
+	if err != nil {
+		return err
+	}
+	if spec.Sign && message == "" {
+		message = name
+	}
+
+	refName := plumbing.NewTagReferenceName(name)
+	existing, err := remoteTagTarget(ctx, repo, refName, auth)
+	if err != nil {
+		return err
+	}
+	switch existing {
+	case commit:
+	case plumbing.ZeroHash:
+		tagger := commitOptions(gitCommit.Spec.CommitMetadata, r.DefaultAuthor).Committer
+		target, err := createTag(repo, name, commit, tagger, message, signer)
+		if err != nil {
+			return err
+		}
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(refName, target)); err != nil {
+			return err
+		}
+		refSpec := config.RefSpec(refName.String() + ":" + refName.String())
+		if err := push(ctx, repo, &git.PushOptions{Auth: auth, RefSpecs: []config.RefSpec{refSpec}}); err != nil {
+			return fmt.Errorf("failed to push tag %s: %w", name, err)
+		}
+	default:
+		return fmt.Errorf("tag %s already exists at %s", name, existing)
+	}
+	gitCommit.Status.Tag = name
+
+	if spec.Release == nil {
+		return nil
+	}
+	url, err := r.publishRelease(ctx, gitCommit, name, data)
+	if err != nil {
+		return err
+	}
+	gitCommit.Status.ReleaseURL = url
+	return nil
+}
+
+// createTag returns the commit itself for a lightweight tag, or stores an annotated tag object
+// when there is a message and returns its hash. The tag object is signed when signer is set.
+func createTag(repo *git.Repository, name string, commit plumbing.Hash, tagger *object.Signature, message string, signer gitsign.Signer) (plumbing.Hash, error) {
+	if message == "" {
+		return commit, nil
+	}
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+
+	tag := &object.Tag{
+		Name:       name,
+		Tagger:     *tagger,
+		Message:    message,
+		TargetType: plumbing.CommitObject,
+		Target:     commit,
+	}
+	if signer != nil {
+		if err := gitsign.SignTag(tag, signer); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := tag.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// remoteTagTarget returns the commit the tag points to on the remote, ZeroHash when the remote
+// has no such tag
+func remoteTagTarget(ctx context.Context, repo *git.Repository, refName plumbing.ReferenceName, auth transport.AuthMethod) (plumbing.Hash, error) {
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to list remote tags: %w", err)
+	}
+
+	// Annotated tags are advertised twice, the peeled entry names the commit
+	target := plumbing.ZeroHash
+	for _, ref := range refs {
+		switch ref.Name() {
+		case refName + "^{}":
+			return ref.Hash(), nil
+		case refName:
+			target = ref.Hash()
+		}
+	}
+	return target, nil
+}
+
+// validateTagName rejects the names git check-ref-format refuses
+func validateTagName(name string) error {
+	invalid := name == "" || name == "@" ||
+		strings.ContainsAny(name, " ~^:?*[\\\x7f") ||
+		strings.Contains(name, "..") || strings.Contains(name, "@{") || strings.Contains(name, "//") ||
+		strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") ||
+		strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") || strings.HasSuffix(name, ".lock")
+	for _, c := range name {
+		invalid = invalid || c < ' '
+	}
+	for _, part := range strings.Split(name, "/") {
+		invalid = invalid || strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".lock")
+	}
+	if invalid {
+		return fmt.Errorf("invalid tag name %q", name)
+	}
+	return nil
+}
+
+// checkReleaseProvider rejects spec.tag.release before anything is pushed when the repository is
+// served by a provider other than GitHub. Hosts that are not recognized, such as GitHub Enterprise
+// servers, are assumed to be GitHub.
+func checkReleaseProvider(gitCommit *gitv1.GitCommit) error {
+	if gitCommit.Spec.Tag == nil || gitCommit.Spec.Tag.Release == nil {
+		return nil
+	}
+	repo, err := gitprovider.ParseRepository(gitCommit.Spec.Repository)
+	if err != nil {
+		return err
+	}
+	if kind, err := gitprovider.Detect(repo); err == nil && kind != gitprovider.KindGitHub {
+		return fmt.Errorf("tag.release publishes GitHub releases, %s is a %s repository", gitCommit.Spec.Repository, kind)
+	}
+	return nil
+}
+
+// publishRelease publishes the GitHub release of spec.tag.release for the tag and returns its URL
+func (r *GitCommitReconciler) publishRelease(ctx context.Context, gitCommit *gitv1.GitCommit, tag string, data map[string]interface{}) (string, error) {
+	release := gitCommit.Spec.Tag.Release
+	name, err := render.String("tag.release.name", release.Name, data)
+	if err != nil {
+		return "", err
+	}
+	body, err := render.String("tag.release.body", release.Body, data)
+	if err != nil {
+		return "", err
+	}
+
+	tokenSource, err := r.getTokenSource(ctx, gitCommit.Namespace, gitCommit.Spec.AuthSecretRef, gitCommit.Spec.AuthSecretKey)
+	if err != nil {
+		return "", err
+	}
+	provider, err := gitprovider.New(gitCommit.Spec.Repository, gitprovider.Options{
+		Kind:        gitprovider.KindGitHub,
+		TokenSource: tokenSource,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	publisher, ok := provider.(gitprovider.ReleasePublisher)
+	if !ok {
+		return "", fmt.Errorf("the provider of %s cannot publish releases", gitCommit.Spec.Repository)
+	}
+	published, err := publisher.CreateRelease(ctx, gitprovider.ReleaseOptions{
+		Tag:           tag,
+		Name:          name,
+		Body:          body,
+		GenerateNotes: release.GenerateNotes,
+		Draft:         release.Draft,
+		Prerelease:    release.Prerelease,
+	})
+	if err != nil {
+		return "", err
+	}
+	return published.URL, nil
+}
+
+// getTokenSource returns the token source for provider API calls from the auth secret
+func (r *GitCommitReconciler) getTokenSource(ctx context.Context, namespace, secretName, secretKey string) (oauth2.TokenSource, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, &secret); err != nil {
+		return nil, err
+	}
+	return gitauth.TokenSourceFromSecret(ctx, &secret, secretKey)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestTagCommit(t *testing.T) {
+	_, upstream := committedWorktree(t, "VERSION")
+	repo, _, cleanup, err := cloneRepository(context.Background(), nil, upstream, nil, nil, nil, "tag-")
+	if err != nil {
+		t.Fatalf("cloneRepository() error = %v", err)
+	}
+	defer cleanup()
+	head, _ := repo.Head()
+
+	gitCommit := &gitv1.GitCommit{
+		Spec: gitv1.GitCommitSpec{
+			Tag: &gitv1.TagSpec{
+				Name:    "v{{ .restAPIs.version.formattedOutput }}",
+				Message: "Release {{ .tag }} at {{ .commitSHA }}",
+			},
+		},
+		Status: gitv1.GitCommitStatus{
+			RestAPIStatuses: []gitv1.RestAPIStatus{{Name: "version", FormattedOutput: "1.2.3"}},
+		},
+	}
+	r := &GitCommitReconciler{}
+	if err := r.tagCommit(context.Background(), gitCommit, repo, head.Hash(), nil, nil); err != nil {
+		t.Fatalf("tagCommit() error = %v", err)
+	}
+	if gitCommit.Status.Tag != "v1.2.3" {
+		t.Errorf("Status.Tag = %q, want v1.2.3", gitCommit.Status.Tag)
+	}
+
+	remote, err := git.PlainOpen(upstream)
+	if err != nil {
+		t.Fatalf("PlainOpen() error = %v", err)
+	}
+	ref, err := remote.Tag("v1.2.3")
+	if err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+	tag, err := remote.TagObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("Expected an annotated tag: %v", err)
+	}
+	if want := "Release v1.2.3 at " + head.Hash().String() + "\n"; tag.Message != want || tag.Target != head.Hash() {
+		t.Errorf("tag = %q at %s, want %q at %s", tag.Message, tag.Target, want, head.Hash())
+	}
+
+	// Tagging the same commit again keeps the tag
+	if err := r.tagCommit(context.Background(), gitCommit, repo, head.Hash(), nil, nil); err != nil {
+		t.Errorf("tagCommit() of the tagged commit error = %v", err)
+	}
+
+	// A lightweight tag of the same name at another commit is a conflict
+	gitCommit.Spec.Tag.Message = ""
+	other := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	err = r.tagCommit(context.Background(), gitCommit, repo, other, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected the existing tag to be reported, got %v", err)
+	}
+}
+
+func TestValidateTagName(t *testing.T) {
+	for _, name := range []string{"v1.2.3", "release/2024-01", "build-42"} {
+		if err := validateTagName(name); err != nil {
+			t.Errorf("validateTagName(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "v1 2", "v1..2", "-v1", "v1/", "v1.lock", "release/.hidden", "v1^", "v1\n"} {
+		if err := validateTagName(name); err == nil {
+			t.Errorf("validateTagName(%q) expected an error", name)
+		}
+	}
+}
+
+func TestCheckReleaseProvider(t *testing.T) {
+	release := &gitv1.TagSpec{Name: "v1", Release: &gitv1.ReleaseSpec{}}
+	for _, repository := range []string{"https://github.com/org/repo.git", "https://git.example.com/org/repo.git"} {
+		gitCommit := &gitv1.GitCommit{Spec: gitv1.GitCommitSpec{Repository: repository, Tag: release}}
+		if err := checkReleaseProvider(gitCommit); err != nil {
+			t.Errorf("checkReleaseProvider(%q) error = %v", repository, err)
+		}
+	}
+	for _, repository := range []string{"https://gitlab.com/group/project.git", "https://codeberg.org/org/repo.git"} {
+		gitCommit := &gitv1.GitCommit{Spec: gitv1.GitCommitSpec{Repository: repository, Tag: release}}
+		if err := checkReleaseProvider(gitCommit); err == nil {
+			t.Errorf("checkReleaseProvider(%q) expected an error", repository)
+		}
+		// A tag without a release can be pushed to any provider
+		gitCommit.Spec.Tag = &gitv1.TagSpec{Name: "v1"}
+		if err := checkReleaseProvider(gitCommit); err != nil {
+			t.Errorf("checkReleaseProvider(%q) without release error = %v", repository, err)
+		}
+	}
+}
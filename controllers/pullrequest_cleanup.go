@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/gitprovider"
+)
+
+// pullRequestFinalizer keeps a PullRequest with a cleanup policy around until the policy has run
+const pullRequestFinalizer = "pullrequest.gco.galos.one/cleanup"
+
+// cleanupPolicy returns the effective policy, an unset policy retains everything
+func cleanupPolicy(pullRequest *gitv1.PullRequest) gitv1.CleanupPolicy {
+	if pullRequest.Spec.CleanupPolicy == "" {
+		return gitv1.CleanupPolicyRetain
+	}
+	return pullRequest.Spec.CleanupPolicy
+}
+
+// reconcileFinalizer adds the finalizer when a cleanup policy is set and removes it again
+// when the policy is switched back to Retain
+func (r *PullRequestReconciler) reconcileFinalizer(ctx context.Context, pullRequest *gitv1.PullRequest) error {
+	wanted := cleanupPolicy(pullRequest) != gitv1.CleanupPolicyRetain
+	if wanted == controllerutil.ContainsFinalizer(pullRequest, pullRequestFinalizer) {
+		return nil
+	}
+
+	if wanted {
+		controllerutil.AddFinalizer(pullRequest, pullRequestFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(pullRequest, pullRequestFinalizer)
+	}
+	return r.Update(ctx, pullRequest)
+}
+
+// handleDeletion runs the cleanup policy and releases the resource. Cleanup is best effort,
+// a failure is reported as a warning event but never blocks the deletion.
+func (r *PullRequestReconciler) handleDeletion(ctx context.Context, pullRequest *gitv1.PullRequest) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(pullRequest, pullRequestFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	message, err := r.cleanupPullRequest(ctx, pullRequest)
+	if err != nil {
+		log.Error(err, "failed to clean up pull request", "policy", cleanupPolicy(pullRequest))
+		r.recordEvent(pullRequest, corev1.EventTypeWarning, "CleanupFailed", fmt.Sprintf("Cleanup policy %s failed: %v", cleanupPolicy(pullRequest), err))
+	} else {
+		log.Info("Cleaned up pull request", "policy", cleanupPolicy(pullRequest), "result", message)
+		r.recordEvent(pullRequest, corev1.EventTypeNormal, "CleanedUp", message)
+	}
+
+	controllerutil.RemoveFinalizer(pullRequest, pullRequestFinalizer)
+	if err := r.Update(ctx, pullRequest); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// cleanupPullRequest closes the pull request if it is still open and, depending on the policy,
+// deletes its head branch. It returns a summary of what was done.
+func (r *PullRequestReconciler) cleanupPullRequest(ctx context.Context, pullRequest *gitv1.PullRequest) (string, error) {
+	policy := cleanupPolicy(pullRequest)
+	number := pullRequest.Status.PullRequestNumber
+	if policy == gitv1.CleanupPolicyRetain {
+		return "Pull request retained", nil
+	}
+	if number == 0 {
+		return "No pull request was opened, nothing to clean up", nil
+	}
+
+	auth, tokenSource, err := r.getAuthFromSecret(ctx, pullRequest.Namespace, pullRequest.Spec.AuthSecretRef, pullRequest.Spec.AuthSecretKey, pullRequest.Spec.Repository)
+	if err != nil {
+		return "", err
+	}
+
+	provider, err := newProvider(pullRequest, tokenSource)
+	if err != nil {
+		return "", err
+	}
+
+	// The recorded phase may be stale, ask the provider whether the pull request is still open
+	state, err := provider.GetPullRequest(ctx, number)
+	if err != nil {
+		return "", err
+	}
+
+	var done []string
+	switch state.State {
+	case gitprovider.StateOpen:
+		if err := provider.ClosePullRequest(ctx, number); err != nil {
+			return "", err
+		}
+		done = append(done, fmt.Sprintf("closed pull request #%d", number))
+	case gitprovider.StateMerged:
+		done = append(done, fmt.Sprintf("pull request #%d was already merged", number))
+	default:
+		done = append(done, fmt.Sprintf("pull request #%d was already closed", number))
+	}
+
+	if policy == gitv1.CleanupPolicyCloseAndDeleteBranch {
+		if err := deleteRemoteBranch(ctx, pullRequest.Spec.Repository, auth, pullRequest.Spec.HeadBranch); err != nil {
+			return "", fmt.Errorf("%s, but deleting head branch %s failed: %w", strings.Join(done, ", "), pullRequest.Spec.HeadBranch, err)
+		}
+		done = append(done, fmt.Sprintf("deleted head branch %s", pullRequest.Spec.HeadBranch))
+	}
+
+	message := strings.Join(done, ", ")
+	return strings.ToUpper(message[:1]) + message[1:], nil
+}
+
+// recordEvent emits an event on the resource when a recorder is configured
+func (r *PullRequestReconciler) recordEvent(object runtime.Object, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(object, eventType, reason, message)
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,19 +12,20 @@ import (
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
-	"github.com/google/go-github/v55/github"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/robfig/cron/v3"
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -32,12 +34,30 @@ import (
 	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
 	"github.com/mihaigalos/git-change-operator/pkg/cel"
 	"github.com/mihaigalos/git-change-operator/pkg/encryption"
+	"github.com/mihaigalos/git-change-operator/pkg/gitauth"
+	"github.com/mihaigalos/git-change-operator/pkg/gitcache"
+	"github.com/mihaigalos/git-change-operator/pkg/gitprovider"
 )
 
+// defaultStatusPollInterval is how often open pull requests are polled for merges, reviews and checks
+const defaultStatusPollInterval = time.Minute
+
 type PullRequestReconciler struct {
 	client.Client
 	Scheme           *runtime.Scheme
 	metricsCollector *MetricsCollector
+
+	// StatusPollInterval controls how often open pull requests are refreshed from the provider
+	StatusPollInterval time.Duration
+
+	// Recorder emits events for cleanup and TTL expiry
+	Recorder record.EventRecorder
+
+	// DefaultAuthor is the commit author for resources that do not set spec.author
+	DefaultAuthor gitv1.CommitIdentity
+
+	// RepositoryCache serves checkouts from cached repositories, nil clones every run from scratch
+	RepositoryCache *gitcache.Cache
 }
 
 func (r *PullRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -57,6 +77,16 @@ func (r *PullRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Run the cleanup policy before the resource is removed
+	if !pullRequest.DeletionTimestamp.IsZero() {
+		return r.handleDeletion(ctx, &pullRequest)
+	}
+
+	if err := r.reconcileFinalizer(ctx, &pullRequest); err != nil {
+		log.Error(err, "failed to update finalizer")
+		return ctrl.Result{}, err
+	}
+
 	// Check if scheduling is configured
 	if pullRequest.Spec.Schedule != "" {
 		return r.handleScheduledPullRequest(ctx, &pullRequest)
@@ -70,6 +100,7 @@ func (r *PullRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 	if expired {
 		log.Info("Deleting expired PullRequest resource")
+		r.recordEvent(&pullRequest, corev1.EventTypeNormal, "Expired", fmt.Sprintf("TTL of %d minutes expired, deleting the resource", *pullRequest.Spec.TTLMinutes))
 		if err := r.Delete(ctx, &pullRequest); err != nil {
 			log.Error(err, "failed to delete expired PullRequest")
 			return ctrl.Result{RequeueAfter: time.Minute * 1}, err
@@ -77,8 +108,16 @@ func (r *PullRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
-	// For created resources, still requeue periodically for TTL checking
+	// For created resources, follow the pull request until it is merged or closed
 	if pullRequest.Status.Phase == gitv1.PullRequestPhaseCreated {
+		if err := r.syncPullRequestState(ctx, &pullRequest); err != nil {
+			log.Error(err, "failed to refresh pull request state")
+		}
+		return ctrl.Result{RequeueAfter: r.statusPollInterval()}, nil
+	}
+
+	// Merged and closed pull requests are final, only requeue for TTL checking
+	if pullRequest.Status.Phase == gitv1.PullRequestPhaseMerged || pullRequest.Status.Phase == gitv1.PullRequestPhaseClosed {
 		return ctrl.Result{RequeueAfter: time.Minute * 1}, nil
 	}
 
@@ -108,28 +147,173 @@ func (r *PullRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	auth, token, err := r.getAuthFromSecret(ctx, pullRequest.Namespace, pullRequest.Spec.AuthSecretRef, pullRequest.Spec.AuthSecretKey)
+	auth, tokenSource, err := r.getAuthFromSecret(ctx, pullRequest.Namespace, pullRequest.Spec.AuthSecretRef, pullRequest.Spec.AuthSecretKey, pullRequest.Spec.Repository)
 	if err != nil {
 		log.Error(err, "failed to get authentication")
 		r.updateStatus(ctx, &pullRequest, gitv1.PullRequestPhaseFailed, fmt.Sprintf("Authentication failed: %v", err))
 		return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
 	}
 
-	prNumber, prURL, err := r.createPullRequest(ctx, &pullRequest, auth, token)
+	prNumber, prURL, action, err := r.createPullRequest(ctx, &pullRequest, auth, tokenSource)
 	if err != nil {
 		log.Error(err, "failed to create pull request")
 		r.updateStatus(ctx, &pullRequest, gitv1.PullRequestPhaseFailed, fmt.Sprintf("Pull request creation failed: %v", err))
 		return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
 	}
 
+	message := pullRequestActionMessage(action)
+	if err := r.applyPullRequestMetadata(ctx, &pullRequest, tokenSource, prNumber); err != nil {
+		log.Error(err, "failed to apply pull request metadata", "number", prNumber)
+		message = fmt.Sprintf("%s, but applying metadata failed: %v", message, err)
+	}
+
 	pullRequest.Status.PullRequestNumber = prNumber
 	pullRequest.Status.PullRequestURL = prURL
-	if err := r.updateStatus(ctx, &pullRequest, gitv1.PullRequestPhaseCreated, "Pull request created successfully"); err != nil {
+	resetPullRequestState(&pullRequest.Status)
+	if err := r.updateStatus(ctx, &pullRequest, gitv1.PullRequestPhaseCreated, message); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	log.Info("Pull request created successfully", "number", prNumber, "url", prURL)
-	return ctrl.Result{}, nil
+	log.Info(pullRequestActionMessage(action), "number", prNumber, "url", prURL)
+	return ctrl.Result{RequeueAfter: r.statusPollInterval()}, nil
+}
+
+func (r *PullRequestReconciler) statusPollInterval() time.Duration {
+	if r.StatusPollInterval > 0 {
+		return r.StatusPollInterval
+	}
+	return defaultStatusPollInterval
+}
+
+// newProvider returns the provider API client for the pull request repository
+func newProvider(pr *gitv1.PullRequest, tokenSource oauth2.TokenSource) (gitprovider.Provider, error) {
+	return gitprovider.New(pr.Spec.Repository, gitprovider.Options{
+		Kind:        gitprovider.Kind(pr.Spec.Provider),
+		TokenSource: tokenSource,
+	})
+}
+
+// syncPullRequestState refreshes the lifecycle fields of a created pull request from the provider
+// and moves the resource to the Merged or Closed phase once the pull request is done
+func (r *PullRequestReconciler) syncPullRequestState(ctx context.Context, pullRequest *gitv1.PullRequest) error {
+	log := log.FromContext(ctx)
+
+	if pullRequest.Status.PullRequestNumber == 0 {
+		return nil
+	}
+
+	auth, tokenSource, err := r.getAuthFromSecret(ctx, pullRequest.Namespace, pullRequest.Spec.AuthSecretRef, pullRequest.Spec.AuthSecretKey, pullRequest.Spec.Repository)
+	if err != nil {
+		return err
+	}
+
+	provider, err := newProvider(pullRequest, tokenSource)
+	if err != nil {
+		return err
+	}
+
+	state, err := provider.GetPullRequest(ctx, pullRequest.Status.PullRequestNumber)
+	if err != nil {
+		return err
+	}
+
+	state, autoMerge := r.runAutoMerge(ctx, pullRequest, provider, state)
+
+	mergedMessage := "Pull request merged"
+	if state.State == gitprovider.StateMerged && pullRequest.Spec.AutoMerge != nil && pullRequest.Spec.AutoMerge.DeleteBranch {
+		if err := deleteRemoteBranch(ctx, pullRequest.Spec.Repository, auth, pullRequest.Spec.HeadBranch); err != nil {
+			log.Error(err, "failed to delete head branch", "branch", pullRequest.Spec.HeadBranch)
+			mergedMessage = fmt.Sprintf("Pull request merged, but deleting head branch %s failed: %v", pullRequest.Spec.HeadBranch, err)
+		} else {
+			mergedMessage = fmt.Sprintf("Pull request merged, head branch %s deleted", pullRequest.Spec.HeadBranch)
+		}
+	}
+
+	const maxRetries = 3
+	for i := 0; i < maxRetries; i++ {
+		fresh := &gitv1.PullRequest{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(pullRequest), fresh); err != nil {
+			return err
+		}
+
+		// A newer execution may have replaced the pull request in the meantime
+		if fresh.Status.PullRequestNumber != state.Number || fresh.Status.Phase != gitv1.PullRequestPhaseCreated {
+			return nil
+		}
+
+		fresh.Status.MergeCommitSHA = state.MergeCommitSHA
+		fresh.Status.MergeableState = gitv1.MergeableState(state.Mergeable)
+		fresh.Status.ReviewDecision = gitv1.ReviewDecision(state.ReviewDecision)
+		fresh.Status.CheckStatus = gitv1.CheckStatus(state.CheckStatus)
+		fresh.Status.AutoMerge = autoMerge
+
+		switch state.State {
+		case gitprovider.StateMerged:
+			fresh.Status.Phase = gitv1.PullRequestPhaseMerged
+			fresh.Status.Message = mergedMessage
+			// Mergeability is meaningless once the pull request is done
+			fresh.Status.MergeableState = ""
+		case gitprovider.StateClosed:
+			fresh.Status.Phase = gitv1.PullRequestPhaseClosed
+			fresh.Status.Message = "Pull request closed without merging"
+			fresh.Status.MergeableState = ""
+		}
+
+		if equality.Semantic.DeepEqual(fresh.Status, pullRequest.Status) {
+			return nil
+		}
+
+		now := metav1.Now()
+		fresh.Status.LastSync = &now
+
+		if err := r.Status().Update(ctx, fresh); err != nil {
+			if errors.IsConflict(err) && i < maxRetries-1 {
+				log.V(1).Info("Status update conflict, retrying", "attempt", i+1)
+				time.Sleep(time.Millisecond * 100)
+				continue
+			}
+			return err
+		}
+
+		if fresh.Status.Phase != pullRequest.Status.Phase {
+			log.Info("Pull request state changed", "number", state.Number, "phase", fresh.Status.Phase, "mergeCommitSHA", fresh.Status.MergeCommitSHA)
+		}
+		pullRequest.Status = fresh.Status
+		return nil
+	}
+
+	return fmt.Errorf("failed to update status after %d retries", maxRetries)
+}
+
+// applyPullRequestMetadata adds the configured labels, reviewers, assignees and milestone to the
+// opened pull request. Failures are reported but never undo the pull request itself.
+func (r *PullRequestReconciler) applyPullRequestMetadata(ctx context.Context, pullRequest *gitv1.PullRequest, tokenSource oauth2.TokenSource, number int) error {
+	metadata := gitprovider.PullRequestMetadata{
+		Labels:        pullRequest.Spec.Labels,
+		Reviewers:     pullRequest.Spec.Reviewers,
+		TeamReviewers: pullRequest.Spec.TeamReviewers,
+		Assignees:     pullRequest.Spec.Assignees,
+		Milestone:     pullRequest.Spec.Milestone,
+	}
+	if metadata.IsEmpty() {
+		return nil
+	}
+
+	provider, err := newProvider(pullRequest, tokenSource)
+	if err != nil {
+		return err
+	}
+
+	return provider.ApplyMetadata(ctx, number, metadata)
+}
+
+// resetPullRequestState clears the lifecycle fields of a previously tracked pull request
+func resetPullRequestState(status *gitv1.PullRequestStatus) {
+	status.MergeCommitSHA = ""
+	status.MergeableState = ""
+	status.ReviewDecision = ""
+	status.CheckStatus = ""
+	status.AutoMerge = nil
 }
 
 func (r *PullRequestReconciler) fetchResource(ctx context.Context, resourceRef gitv1.ResourceRef, defaultNamespace string) (*unstructured.Unstructured, error) {
@@ -247,80 +431,98 @@ func (r *PullRequestReconciler) processResourceRef(ctx context.Context, resource
 	return files, nil
 }
 
-func (r *PullRequestReconciler) getAuthFromSecret(ctx context.Context, namespace, secretName, secretKey string) (*githttp.BasicAuth, string, error) {
+func (r *PullRequestReconciler) getAuthFromSecret(ctx context.Context, namespace, secretName, secretKey, repository string) (transport.AuthMethod, oauth2.TokenSource, error) {
 	var secret corev1.Secret
 	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, &secret); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	key := secretKey
-	if key == "" {
-		key = "token"
+	auth, err := gitauth.FromSecret(ctx, &secret, repository, secretKey)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	token, exists := secret.Data[key]
-	if !exists {
-		return nil, "", fmt.Errorf("key %s not found in secret %s", key, secretName)
+	// The API token is still needed to open the pull request when pushing over SSH
+	tokenSource, err := gitauth.TokenSourceFromSecret(ctx, &secret, secretKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (an API token is required to create pull requests)", err)
 	}
 
-	username := "oauth2"
-	if usernameData, exists := secret.Data["username"]; exists {
-		username = string(usernameData)
-	}
+	return auth, tokenSource, nil
+}
 
-	auth := &githttp.BasicAuth{
-		Username: username,
-		Password: string(token),
+func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1.PullRequest, auth transport.AuthMethod, tokenSource oauth2.TokenSource) (int, string, gitv1.PullRequestAction, error) {
+	// Resolve the provider before pushing so unsupported repositories fail without side effects
+	provider, err := newProvider(pr, tokenSource)
+	if err != nil {
+		return 0, "", "", err
 	}
 
-	return auth, string(token), nil
-}
-
-func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1.PullRequest, auth *githttp.BasicAuth, token string) (int, string, error) {
-	tempDir, err := os.MkdirTemp("", "pull-request-")
+	signer, err := loadSigner(ctx, r.Client, pr.Namespace, pr.Spec.Signing)
 	if err != nil {
-		return 0, "", err
+		return 0, "", "", err
 	}
-	defer os.RemoveAll(tempDir)
 
-	repo, err := git.PlainClone(tempDir, false, &git.CloneOptions{
-		URL:  pr.Spec.Repository,
-		Auth: auth,
-	})
+	var existing *gitprovider.PullRequest
+	if pr.Spec.Upsert {
+		existing, err = provider.FindOpenPullRequest(ctx, pr.Spec.HeadBranch, pr.Spec.BaseBranch)
+		if err != nil {
+			return 0, "", "", err
+		}
+	}
+
+	repo, tempDir, cleanup, err := cloneRepository(ctx, r.RepositoryCache, pr.Spec.Repository, []string{pr.Spec.BaseBranch}, pr.Spec.Clone, auth, "pull-request-")
 	if err != nil {
-		return 0, "", err
+		return 0, "", "", err
 	}
+	defer cleanup()
 
 	w, err := repo.Worktree()
 	if err != nil {
-		return 0, "", err
+		return 0, "", "", err
 	}
 
-	branchRefName := plumbing.NewBranchReferenceName(pr.Spec.HeadBranch)
-	b := plumbing.NewHashReference(branchRefName, plumbing.ZeroHash)
-
-	err = w.Checkout(&git.CheckoutOptions{
-		Branch: b.Name(),
-		Create: true,
-	})
+	// Build the head branch on top of the base branch so that a force update replaces it cleanly
+	baseRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", pr.Spec.BaseBranch), true)
 	if err != nil {
-		headRef, err := repo.Head()
+		return 0, "", "", fmt.Errorf("base branch %s not found: %w", pr.Spec.BaseBranch, err)
+	}
+	headRef := plumbing.NewHashReference(plumbing.NewBranchReferenceName(pr.Spec.HeadBranch), baseRef.Hash())
+	if err := checkoutBranch(repo, w, headRef.Name(), baseRef.Hash(), pr.Spec.Clone); err != nil {
+		return 0, "", "", err
+	}
+
+	var fileData map[string]interface{}
+	if hasTemplates(pr.Spec.Files) {
+		fileData, err = fileTemplateData(ctx, pr, pr.Status.RestAPIStatuses, baseRef.Hash().String(), pr.Spec.ResourceRefs, r.fetchResource)
 		if err != nil {
-			return 0, "", err
+			return 0, "", "", err
 		}
-		b = plumbing.NewHashReference(branchRefName, headRef.Hash())
-		err = repo.Storer.SetReference(b)
+	}
+
+	// Process regular files
+	for _, file := range pr.Spec.Files {
+		file, err := prepareFile(repo, tempDir, pr.Spec.Clone, file, pr.Spec.Encryption, fileData)
 		if err != nil {
-			return 0, "", err
+			return 0, "", "", err
 		}
-		err = w.Checkout(&git.CheckoutOptions{Branch: b.Name()})
+		if isFileOperation(file) {
+			if err := applyFileOperation(ctx, w, tempDir, file, pr.Spec.Encryption); err != nil {
+				return 0, "", "", err
+			}
+			continue
+		}
+		if file.SymlinkTarget != "" {
+			if err := writeSymlink(w, tempDir, file); err != nil {
+				return 0, "", "", err
+			}
+			continue
+		}
+		mode, err := fileMode(file)
 		if err != nil {
-			return 0, "", err
+			return 0, "", "", err
 		}
-	}
 
-	// Process regular files
-	for _, file := range pr.Spec.Files {
 		var content []byte
 
 		// Determine content source
@@ -328,23 +530,44 @@ func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1
 			// Use REST API response data from multiple APIs
 			content = r.buildFileContent(&file, pr.Status.RestAPIStatuses)
 			if len(content) == 0 {
-				return 0, "", fmt.Errorf("file %s requested REST API data but no formatted output available", file.Path)
+				return 0, "", "", fmt.Errorf("file %s requested REST API data but no formatted output available", file.Path)
 			}
-		} else {
-			// Use provided content
-			content = []byte(file.Content)
+		} else if content, err = fileContent(file); err != nil {
+			return 0, "", "", err
+		}
+
+		if isPatchWriteMode(file.WriteMode) {
+			patched, rolledOver, err := patchFile(tempDir, file.Path, editOfFile(file), content, pr.Spec.Encryption)
+			if stderrors.Is(err, errEditSkipped) {
+				continue
+			}
+			if err != nil {
+				return 0, "", "", err
+			}
+			if rolledOver != "" {
+				if _, err := w.Add(rolledOver); err != nil {
+					return 0, "", "", err
+				}
+			}
+			content = patched
 		}
 
-		filePath := filepath.Join(tempDir, file.Path)
+		filePath, err := worktreePath(tempDir, file.Path)
+		if err != nil {
+			return 0, "", "", err
+		}
 		dir := filepath.Dir(filePath)
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return 0, "", err
+			return 0, "", "", err
 		}
 
 		// Handle writeMode for file content
 		var finalContent []byte
 		if file.WriteMode == gitv1.WriteModeAppend {
-			existingContent, _ := os.ReadFile(filePath)
+			existingContent, err := readWorktreeFile(tempDir, file.Path)
+			if err != nil && !os.IsNotExist(err) {
+				return 0, "", "", err
+			}
 			finalContent = append(existingContent, content...)
 		} else {
 			finalContent = content
@@ -354,14 +577,14 @@ func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1
 		if encryption.ShouldEncryptFile(file.Path, pr.Spec.Encryption) {
 			encryptedContent, err := r.encryptFileContent(ctx, finalContent, pr.Spec.Encryption, pr.Namespace)
 			if err != nil {
-				return 0, "", fmt.Errorf("failed to encrypt file %s: %w", file.Path, err)
+				return 0, "", "", fmt.Errorf("failed to encrypt file %s: %w", file.Path, err)
 			}
 			finalContent = encryptedContent
 			filePath = encryption.GetEncryptedFilePath(filePath, pr.Spec.Encryption)
 		}
 
-		if err := os.WriteFile(filePath, finalContent, 0644); err != nil {
-			return 0, "", err
+		if err := writeFile(filePath, finalContent, mode); err != nil {
+			return 0, "", "", err
 		}
 
 		// Add the correct file path to git (encrypted if applicable)
@@ -370,7 +593,7 @@ func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1
 			gitPath = encryption.GetEncryptedFilePath(file.Path, pr.Spec.Encryption)
 		}
 		if _, err := w.Add(gitPath); err != nil {
-			return 0, "", err
+			return 0, "", "", err
 		}
 	}
 
@@ -378,20 +601,43 @@ func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1
 	for _, resourceRef := range pr.Spec.ResourceRefs {
 		files, err := r.processResourceRef(ctx, resourceRef, resourceRef.Strategy, pr.Namespace)
 		if err != nil {
-			return 0, "", fmt.Errorf("failed to process resource reference %s: %w", resourceRef.Name, err)
+			return 0, "", "", fmt.Errorf("failed to process resource reference %s: %w", resourceRef.Name, err)
 		}
 
 		for relativePath, content := range files {
-			filePath := filepath.Join(tempDir, relativePath)
+			if err := materializeFile(repo, tempDir, pr.Spec.Clone, gitv1.File{Path: relativePath}, pr.Spec.Encryption); err != nil {
+				return 0, "", "", err
+			}
+			filePath, err := worktreePath(tempDir, relativePath)
+			if err != nil {
+				return 0, "", "", err
+			}
 			dir := filepath.Dir(filePath)
 			if err := os.MkdirAll(dir, 0755); err != nil {
-				return 0, "", err
+				return 0, "", "", err
 			}
 
 			var finalContent []byte
 			if resourceRef.Strategy.WriteMode == gitv1.WriteModeAppend {
-				existingContent, _ := os.ReadFile(filePath)
+				existingContent, err := readWorktreeFile(tempDir, relativePath)
+				if err != nil && !os.IsNotExist(err) {
+					return 0, "", "", err
+				}
 				finalContent = append(existingContent, content...)
+			} else if isPatchWriteMode(resourceRef.Strategy.WriteMode) {
+				var rolledOver string
+				finalContent, rolledOver, err = patchFile(tempDir, relativePath, editOfStrategy(resourceRef.Strategy), content, pr.Spec.Encryption)
+				if stderrors.Is(err, errEditSkipped) {
+					continue
+				}
+				if err != nil {
+					return 0, "", "", err
+				}
+				if rolledOver != "" {
+					if _, err := w.Add(rolledOver); err != nil {
+						return 0, "", "", err
+					}
+				}
 			} else {
 				finalContent = content
 			}
@@ -400,14 +646,14 @@ func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1
 			if encryption.ShouldEncryptFile(relativePath, pr.Spec.Encryption) {
 				encryptedContent, err := r.encryptFileContent(ctx, finalContent, pr.Spec.Encryption, pr.Namespace)
 				if err != nil {
-					return 0, "", fmt.Errorf("failed to encrypt file %s: %w", relativePath, err)
+					return 0, "", "", fmt.Errorf("failed to encrypt file %s: %w", relativePath, err)
 				}
 				finalContent = encryptedContent
 				filePath = encryption.GetEncryptedFilePath(filePath, pr.Spec.Encryption)
 			}
 
-			if err := os.WriteFile(filePath, finalContent, 0644); err != nil {
-				return 0, "", err
+			if err := writeFile(filePath, finalContent, 0); err != nil {
+				return 0, "", "", err
 			}
 
 			// Add the correct file path to git (encrypted if applicable)
@@ -416,87 +662,64 @@ func (r *PullRequestReconciler) createPullRequest(ctx context.Context, pr *gitv1
 				gitPath = encryption.GetEncryptedFilePath(relativePath, pr.Spec.Encryption)
 			}
 			if _, err := w.Add(gitPath); err != nil {
-				return 0, "", err
+				return 0, "", "", err
 			}
 		}
 	}
 
-	commitMessage := fmt.Sprintf("Changes for PR: %s", pr.Spec.Title)
-	_, err = w.Commit(commitMessage, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "Git Change Operator",
-			Email: "git-change-operator@galos.one",
-			When:  time.Now(),
-		},
-	})
+	title, body, text, err := renderPullRequest(pr, repo, baseRef.Hash().String())
 	if err != nil {
-		return 0, "", err
+		return 0, "", "", err
 	}
 
-	err = repo.Push(&git.PushOptions{
-		Auth: auth,
-	})
+	options := commitOptions(pr.Spec.CommitMetadata, r.DefaultAuthor)
+	message := commitMessage(text, pr.Spec.CommitMetadata, options.Committer, "PullRequest", pr)
+	commit, err := w.Commit(message, options)
 	if err != nil {
-		// Check if this is a non-fast-forward error (branch already exists)
-		if strings.Contains(err.Error(), "non-fast-forward update") {
-			// Branch already exists - this is fine, we can still try to create the PR
-			// Log the situation but don't fail
-			fmt.Printf("Branch %s already exists, proceeding to PR creation\n", pr.Spec.HeadBranch)
-		} else {
-			return 0, "", err
-		}
+		return 0, "", "", err
 	}
-
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
-
-	owner, repoName, err := r.parseRepository(pr.Spec.Repository)
-	if err != nil {
-		return 0, "", err
+	if _, err := signCommit(repo, commit, signer); err != nil {
+		return 0, "", "", err
 	}
 
-	newPR := &github.NewPullRequest{
-		Title:               github.String(pr.Spec.Title),
-		Head:                github.String(pr.Spec.HeadBranch),
-		Base:                github.String(pr.Spec.BaseBranch),
-		Body:                github.String(pr.Spec.Body),
-		MaintainerCanModify: github.Bool(true),
+	// Upsert replaces whatever the head branch points to; otherwise a diverged head branch is an error
+	refSpec := config.RefSpec(fmt.Sprintf("%s:%s", headRef.Name(), headRef.Name()))
+	if pr.Spec.Upsert {
+		refSpec = "+" + refSpec
 	}
-
-	pullRequest, _, err := client.PullRequests.Create(ctx, owner, repoName, newPR)
+	err = push(ctx, repo, &git.PushOptions{
+		Auth:     auth,
+		RefSpecs: []config.RefSpec{refSpec},
+	})
 	if err != nil {
-		// Check for common GitHub API permission issues
-		if strings.Contains(err.Error(), "Resource not accessible by personal access token") {
-			return 0, "", fmt.Errorf("insufficient GitHub token permissions - token needs 'repo' and 'pull_requests:write' scopes to create pull requests: %w", err)
-		}
-		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Forbidden") {
-			return 0, "", fmt.Errorf("GitHub API access denied - check token permissions and repository access: %w", err)
+		if strings.Contains(err.Error(), "non-fast-forward update") {
+			return 0, "", "", fmt.Errorf("head branch %s already exists and has diverged, set spec.upsert to replace it: %w", pr.Spec.HeadBranch, err)
 		}
-		return 0, "", fmt.Errorf("failed to create GitHub pull request: %w", err)
+		return 0, "", "", err
 	}
 
-	return pullRequest.GetNumber(), pullRequest.GetHTMLURL(), nil
-}
-
-func (r *PullRequestReconciler) parseRepository(repoURL string) (string, string, error) {
-	if len(repoURL) < 19 {
-		return "", "", fmt.Errorf("invalid repository URL")
+	opts := gitprovider.PullRequestOptions{
+		Title: title,
+		Body:  body,
+		Head:  pr.Spec.HeadBranch,
+		Base:  pr.Spec.BaseBranch,
+		Draft: pr.Spec.Draft,
 	}
 
-	if repoURL[:19] == "https://github.com/" {
-		parts := repoURL[19:]
-		if parts[len(parts)-4:] == ".git" {
-			parts = parts[:len(parts)-4]
+	if existing != nil {
+		pullRequest, err := provider.UpdatePullRequest(ctx, existing.Number, opts)
+		if err != nil {
+			return 0, "", "", err
 		}
+		return pullRequest.Number, pullRequest.URL, gitv1.PullRequestActionUpdated, nil
+	}
 
-		repoParts := filepath.Base(filepath.Dir("/" + parts))
-		repoName := filepath.Base(parts)
-
-		return repoParts, repoName, nil
+	pullRequest, err := provider.CreatePullRequest(ctx, opts)
+	if err != nil {
+		return 0, "", "", err
 	}
 
-	return "", "", fmt.Errorf("unsupported repository URL format")
+	return pullRequest.Number, pullRequest.URL, gitv1.PullRequestActionCreated, nil
 }
 
 func (r *PullRequestReconciler) updateStatus(ctx context.Context, pr *gitv1.PullRequest, phase gitv1.PullRequestPhase, message string) error {
@@ -946,6 +1169,16 @@ func (r *PullRequestReconciler) handleScheduledPullRequest(ctx context.Context,
 			waitDuration = time.Minute
 		}
 
+		// Keep following the pull request opened by the last execution
+		if pullRequest.Status.Phase == gitv1.PullRequestPhaseCreated {
+			if err := r.syncPullRequestState(ctx, pullRequest); err != nil {
+				log.Error(err, "failed to refresh pull request state")
+			}
+			if pullRequest.Status.Phase == gitv1.PullRequestPhaseCreated && waitDuration > r.statusPollInterval() {
+				waitDuration = r.statusPollInterval()
+			}
+		}
+
 		log.Info("Waiting for next scheduled execution", "nextTime", nextTime, "waitDuration", waitDuration)
 		return ctrl.Result{RequeueAfter: waitDuration}, nil
 	}
@@ -967,7 +1200,7 @@ func (r *PullRequestReconciler) handleScheduledPullRequest(ctx context.Context,
 		shouldProceed, err := r.checkRestAPIConditions(ctx, pullRequest)
 		if err != nil {
 			log.Error(err, "failed to check REST API conditions")
-			r.recordPRExecution(ctx, pullRequest, 0, "", gitv1.PullRequestPhaseFailed, fmt.Sprintf("REST API condition check failed: %v", err))
+			r.recordPRExecution(ctx, pullRequest, 0, "", "", gitv1.PullRequestPhaseFailed, fmt.Sprintf("REST API condition check failed: %v", err))
 			// Calculate next execution time
 			nextTime := schedule.Next(now)
 			nextTimeMeta := metav1.NewTime(nextTime)
@@ -977,7 +1210,7 @@ func (r *PullRequestReconciler) handleScheduledPullRequest(ctx context.Context,
 
 		if !shouldProceed {
 			log.Info("REST API conditions not met, skipping this scheduled execution")
-			r.recordPRExecution(ctx, pullRequest, 0, "", gitv1.PullRequestPhasePending, "REST API conditions not met")
+			r.recordPRExecution(ctx, pullRequest, 0, "", "", gitv1.PullRequestPhasePending, "REST API conditions not met")
 			// Calculate next execution time
 			nextTime := schedule.Next(now)
 			nextTimeMeta := metav1.NewTime(nextTime)
@@ -988,10 +1221,10 @@ func (r *PullRequestReconciler) handleScheduledPullRequest(ctx context.Context,
 		log.Info("All REST API conditions met, proceeding with scheduled pull request")
 	}
 
-	auth, token, err := r.getAuthFromSecret(ctx, pullRequest.Namespace, pullRequest.Spec.AuthSecretRef, pullRequest.Spec.AuthSecretKey)
+	auth, tokenSource, err := r.getAuthFromSecret(ctx, pullRequest.Namespace, pullRequest.Spec.AuthSecretRef, pullRequest.Spec.AuthSecretKey, pullRequest.Spec.Repository)
 	if err != nil {
 		log.Error(err, "failed to get authentication")
-		r.recordPRExecution(ctx, pullRequest, 0, "", gitv1.PullRequestPhaseFailed, fmt.Sprintf("Authentication failed: %v", err))
+		r.recordPRExecution(ctx, pullRequest, 0, "", "", gitv1.PullRequestPhaseFailed, fmt.Sprintf("Authentication failed: %v", err))
 		// Calculate next execution time
 		nextTime := schedule.Next(now)
 		nextTimeMeta := metav1.NewTime(nextTime)
@@ -999,10 +1232,10 @@ func (r *PullRequestReconciler) handleScheduledPullRequest(ctx context.Context,
 		return ctrl.Result{RequeueAfter: time.Until(nextTime)}, nil
 	}
 
-	prNumber, prURL, err := r.createPullRequest(ctx, pullRequest, auth, token)
+	prNumber, prURL, action, err := r.createPullRequest(ctx, pullRequest, auth, tokenSource)
 	if err != nil {
 		log.Error(err, "failed to create scheduled pull request")
-		r.recordPRExecution(ctx, pullRequest, 0, "", gitv1.PullRequestPhaseFailed, fmt.Sprintf("Pull request creation failed: %v", err))
+		r.recordPRExecution(ctx, pullRequest, 0, "", "", gitv1.PullRequestPhaseFailed, fmt.Sprintf("Pull request creation failed: %v", err))
 		// Calculate next execution time
 		nextTime := schedule.Next(now)
 		nextTimeMeta := metav1.NewTime(nextTime)
@@ -1010,9 +1243,15 @@ func (r *PullRequestReconciler) handleScheduledPullRequest(ctx context.Context,
 		return ctrl.Result{RequeueAfter: time.Until(nextTime)}, nil
 	}
 
+	message := pullRequestActionMessage(action)
+	if err := r.applyPullRequestMetadata(ctx, pullRequest, tokenSource, prNumber); err != nil {
+		log.Error(err, "failed to apply pull request metadata", "number", prNumber)
+		message = fmt.Sprintf("%s, but applying metadata failed: %v", message, err)
+	}
+
 	// Record successful execution
-	log.Info("Scheduled pull request created successfully", "prNumber", prNumber, "prURL", prURL)
-	r.recordPRExecution(ctx, pullRequest, prNumber, prURL, gitv1.PullRequestPhaseCreated, "Pull request created successfully")
+	log.Info("Scheduled pull request executed successfully", "prNumber", prNumber, "prURL", prURL, "action", action)
+	r.recordPRExecution(ctx, pullRequest, prNumber, prURL, action, gitv1.PullRequestPhaseCreated, message)
 
 	// Calculate next execution time
 	nextTime = schedule.Next(now)
@@ -1024,13 +1263,25 @@ func (r *PullRequestReconciler) handleScheduledPullRequest(ctx context.Context,
 	if waitDuration < time.Minute {
 		waitDuration = time.Minute
 	}
+	// Come back earlier to follow the pull request that was just opened
+	if waitDuration > r.statusPollInterval() {
+		waitDuration = r.statusPollInterval()
+	}
 
 	log.Info("Scheduled execution complete, waiting for next run", "nextTime", nextTime, "waitDuration", waitDuration)
 	return ctrl.Result{RequeueAfter: waitDuration}, nil
 }
 
+// pullRequestActionMessage returns the status message for a successful execution
+func pullRequestActionMessage(action gitv1.PullRequestAction) string {
+	if action == gitv1.PullRequestActionUpdated {
+		return "Existing pull request updated successfully"
+	}
+	return "Pull request created successfully"
+}
+
 // recordPRExecution adds an execution record to the history and maintains the max history limit
-func (r *PullRequestReconciler) recordPRExecution(ctx context.Context, pullRequest *gitv1.PullRequest, prNumber int, prURL string, phase gitv1.PullRequestPhase, message string) error {
+func (r *PullRequestReconciler) recordPRExecution(ctx context.Context, pullRequest *gitv1.PullRequest, prNumber int, prURL string, action gitv1.PullRequestAction, phase gitv1.PullRequestPhase, message string) error {
 	log := log.FromContext(ctx)
 
 	// Retry logic to handle optimistic concurrency conflicts
@@ -1049,6 +1300,7 @@ func (r *PullRequestReconciler) recordPRExecution(ctx context.Context, pullReque
 			PullRequestNumber: prNumber,
 			PullRequestURL:    prURL,
 			Phase:             phase,
+			Action:            action,
 			Message:           message,
 		}
 
@@ -1069,6 +1321,7 @@ func (r *PullRequestReconciler) recordPRExecution(ctx context.Context, pullReque
 		fresh.Status.Message = message
 		fresh.Status.PullRequestNumber = prNumber
 		fresh.Status.PullRequestURL = prURL
+		resetPullRequestState(&fresh.Status)
 		fresh.Status.LastSync = &now
 		fresh.Status.LastScheduledTime = pullRequest.Status.LastScheduledTime
 
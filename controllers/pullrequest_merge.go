@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/gitprovider"
+)
+
+// runAutoMerge applies the merge policy to an open pull request. It returns the pull request
+// state to record, refreshed after a merge, together with the outcome of the policy.
+func (r *PullRequestReconciler) runAutoMerge(ctx context.Context, pullRequest *gitv1.PullRequest, provider gitprovider.Provider, state *gitprovider.PullRequest) (*gitprovider.PullRequest, *gitv1.AutoMergeStatus) {
+	log := log.FromContext(ctx)
+
+	policy := pullRequest.Spec.AutoMerge
+	current := pullRequest.Status.AutoMerge
+	if policy == nil {
+		return state, current
+	}
+
+	var lastAttempt *metav1.Time
+	if current != nil {
+		lastAttempt = current.LastAttemptTime
+	}
+
+	switch state.State {
+	case gitprovider.StateMerged:
+		if current != nil && current.State == gitv1.AutoMergeStateMerged {
+			return state, current
+		}
+		return state, &gitv1.AutoMergeStatus{State: gitv1.AutoMergeStateMerged, Message: "Pull request merged", LastAttemptTime: lastAttempt}
+	case gitprovider.StateClosed:
+		return state, current
+	}
+
+	method := gitprovider.MergeMethod(policy.Method)
+	if method == "" {
+		method = gitprovider.MergeMethodMerge
+	}
+	now := metav1.Now()
+
+	if policy.Native {
+		// Native auto-merge only needs to be armed once per pull request
+		if current != nil && current.State == gitv1.AutoMergeStateEnabled {
+			return state, current
+		}
+		if err := provider.MergePullRequest(ctx, state.Number, gitprovider.MergeOptions{Method: method, Auto: true}); err != nil {
+			log.Error(err, "failed to enable native auto-merge", "number", state.Number)
+			return state, &gitv1.AutoMergeStatus{State: gitv1.AutoMergeStateFailed, Message: err.Error(), LastAttemptTime: &now}
+		}
+		log.Info("Native auto-merge enabled", "number", state.Number, "method", method)
+		return state, &gitv1.AutoMergeStatus{State: gitv1.AutoMergeStateEnabled, Message: fmt.Sprintf("Native auto-merge enabled using the %s method", method), LastAttemptTime: &now}
+	}
+
+	if reason := mergeBlockedReason(policy, state); reason != "" {
+		return state, &gitv1.AutoMergeStatus{State: gitv1.AutoMergeStateWaiting, Message: reason, LastAttemptTime: lastAttempt}
+	}
+
+	// Pin the merge to the head commit that was checked so a concurrent push is never merged unchecked
+	if err := provider.MergePullRequest(ctx, state.Number, gitprovider.MergeOptions{Method: method, SHA: state.HeadSHA}); err != nil {
+		log.Error(err, "failed to merge pull request", "number", state.Number)
+		return state, &gitv1.AutoMergeStatus{State: gitv1.AutoMergeStateFailed, Message: err.Error(), LastAttemptTime: &now}
+	}
+	log.Info("Pull request merged", "number", state.Number, "method", method)
+
+	status := &gitv1.AutoMergeStatus{State: gitv1.AutoMergeStateMerged, Message: fmt.Sprintf("Merged by the operator using the %s method", method), LastAttemptTime: &now}
+
+	// Pick up the merge commit, the next poll does the same if this refresh fails
+	merged, err := provider.GetPullRequest(ctx, state.Number)
+	if err != nil {
+		log.Error(err, "failed to refresh merged pull request", "number", state.Number)
+		return state, status
+	}
+	return merged, status
+}
+
+// mergeBlockedReason explains why an open pull request cannot be merged yet, or returns "" when it can
+func mergeBlockedReason(policy *gitv1.AutoMerge, state *gitprovider.PullRequest) string {
+	switch state.Mergeable {
+	case gitprovider.MergeableConflicting:
+		return "Pull request has conflicts with the base branch"
+	case gitprovider.MergeableBlocked:
+		return "Merging is blocked by branch protection, required reviews or checks"
+	case gitprovider.MergeableUnknown, "":
+		return "Waiting for the provider to compute mergeability"
+	}
+
+	if policy.RequireChecks == nil || *policy.RequireChecks {
+		switch state.CheckStatus {
+		case gitprovider.CheckPending:
+			return "Waiting for checks to complete"
+		case gitprovider.CheckFailure:
+			return "Checks failed on the head commit"
+		}
+	}
+
+	return ""
+}
+
+// deleteRemoteBranch deletes a branch from the remote without cloning the repository
+func deleteRemoteBranch(ctx context.Context, repository string, auth transport.AuthMethod, branch string) error {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repository},
+	})
+
+	err := remote.PushContext(ctx, &git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{config.RefSpec(":" + plumbing.NewBranchReferenceName(branch).String())},
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestRepositoryCacheDeployment(t *testing.T) {
+	t.Setenv("OPERAND_IMAGE", "ghcr.io/mihaigalos/git-change-operator:test")
+
+	scheme := runtime.NewScheme()
+	gitv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	appsv1.AddToScheme(scheme)
+
+	gco := &gitv1.GitChangeOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "gco", Namespace: "git-change-operator", UID: "uid"},
+		Spec: gitv1.GitChangeOperatorSpec{
+			Operator: gitv1.OperatorConfig{
+				RepositoryCache: &gitv1.RepositoryCacheConfig{Size: resource.MustParse("20Gi")},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gco).Build()
+	r := &GitChangeOperatorReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	if err := r.reconcileRepositoryCacheClaim(ctx, gco); err != nil {
+		t.Fatalf("reconcileRepositoryCacheClaim() error = %v", err)
+	}
+	if err := r.reconcileDeployment(ctx, gco); err != nil {
+		t.Fatalf("reconcileDeployment() error = %v", err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "gco-repository-cache", Namespace: gco.Namespace}, pvc); err != nil {
+		t.Fatalf("Get(PersistentVolumeClaim) error = %v", err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "20Gi" {
+		t.Errorf("PersistentVolumeClaim size = %s, want 20Gi", size.String())
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "gco-operator", Namespace: gco.Namespace}, deployment); err != nil {
+		t.Fatalf("Get(Deployment) error = %v", err)
+	}
+	if deployment.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		t.Errorf("Strategy = %s, want Recreate", deployment.Spec.Strategy.Type)
+	}
+	podSpec := deployment.Spec.Template.Spec
+	if len(podSpec.Volumes) != 2 || podSpec.Volumes[1].PersistentVolumeClaim == nil || podSpec.Volumes[1].PersistentVolumeClaim.ClaimName != "gco-repository-cache" {
+		t.Errorf("Volumes = %+v", podSpec.Volumes)
+	}
+	if mounts := podSpec.Containers[0].VolumeMounts; len(mounts) != 2 || mounts[1].MountPath != repositoryCacheMountPath {
+		t.Errorf("VolumeMounts = %+v", mounts)
+	}
+	args := podSpec.Containers[0].Args
+	if !containsString(args, "--repository-cache-dir="+repositoryCacheMountPath) || !containsString(args, "--repository-cache-max-size=16Gi") {
+		t.Errorf("Args = %v", args)
+	}
+
+	// Disabling the cache removes the volume again
+	gco.Spec.Operator.RepositoryCache = nil
+	if err := r.reconcileDeployment(ctx, gco); err != nil {
+		t.Fatalf("reconcileDeployment() error = %v", err)
+	}
+	c.Get(ctx, types.NamespacedName{Name: "gco-operator", Namespace: gco.Namespace}, deployment)
+	if volumes := deployment.Spec.Template.Spec.Volumes; len(volumes) != 1 {
+		t.Errorf("Volumes = %+v after disabling the cache", volumes)
+	}
+}
+
+func TestRepositoryCacheClaimGrows(t *testing.T) {
+	scheme := runtime.NewScheme()
+	gitv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+
+	gco := &gitv1.GitChangeOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "gco", Namespace: "git-change-operator", UID: "uid"},
+		Spec: gitv1.GitChangeOperatorSpec{
+			Operator: gitv1.OperatorConfig{RepositoryCache: &gitv1.RepositoryCacheConfig{}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gco).Build()
+	r := &GitChangeOperatorReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "gco-repository-cache", Namespace: gco.Namespace}
+
+	for _, tt := range []struct{ size, want string }{
+		{size: "", want: "10Gi"},
+		{size: "50Gi", want: "50Gi"},
+		{size: "5Gi", want: "50Gi"},
+	} {
+		if tt.size != "" {
+			gco.Spec.Operator.RepositoryCache.Size = resource.MustParse(tt.size)
+		}
+		if err := r.reconcileRepositoryCacheClaim(ctx, gco); err != nil {
+			t.Fatalf("reconcileRepositoryCacheClaim(%q) error = %v", tt.size, err)
+		}
+		pvc := &corev1.PersistentVolumeClaim{}
+		c.Get(ctx, key, pvc)
+		if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != tt.want {
+			t.Errorf("size %q: PersistentVolumeClaim size = %s, want %s", tt.size, size.String(), tt.want)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/gitsign"
+)
+
+// loadSigner reads the signing key referenced by signing, it returns nil when signing is not configured
+func loadSigner(ctx context.Context, c client.Client, namespace string, signing *gitv1.Signing) (gitsign.Signer, error) {
+	if signing == nil {
+		return nil, nil
+	}
+
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: signing.SecretRef.Name, Namespace: namespace}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get signing secret %s: %w", signing.SecretRef.Name, err)
+	}
+
+	return gitsign.FromSecret(&secret, gitsign.Format(signing.Format), signing.SecretRef.Key)
+}
+
+// signCommit replaces the commit at HEAD with a signed copy when a signer is configured
+func signCommit(repo *git.Repository, commit plumbing.Hash, signer gitsign.Signer) (plumbing.Hash, error) {
+	if signer == nil {
+		return commit, nil
+	}
+	return gitsign.SignCommit(repo, commit, signer)
+}
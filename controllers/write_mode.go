@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/encryption"
+	"github.com/mihaigalos/git-change-operator/pkg/patch"
+)
+
+// errEditSkipped is returned by patchFile when the file is left as it is
+var errEditSkipped = errors.New("edit skipped")
+
+// fileEdit is the write mode of a file or resource reference with its parameters
+type fileEdit struct {
+	mode      gitv1.WriteMode
+	yamlPath  string
+	pattern   string
+	onNoMatch gitv1.NoMatchPolicy
+	lines     *gitv1.LineOptions
+}
+
+func editOfFile(file gitv1.File) fileEdit {
+	return fileEdit{mode: file.WriteMode, yamlPath: file.YAMLPath, pattern: file.Pattern, onNoMatch: file.OnNoMatch, lines: file.Lines}
+}
+
+func editOfStrategy(strategy gitv1.OutputStrategy) fileEdit {
+	return fileEdit{mode: strategy.WriteMode, yamlPath: strategy.YAMLPath, pattern: strategy.Pattern, onNoMatch: strategy.OnNoMatch, lines: strategy.Lines}
+}
+
+// isPatchWriteMode reports whether the write mode edits the existing file
+func isPatchWriteMode(mode gitv1.WriteMode) bool {
+	switch mode {
+	case gitv1.WriteModeAppendLine, gitv1.WriteModePrepend, gitv1.WriteModeJSONPatch, gitv1.WriteModeMergePatch, gitv1.WriteModeYAMLSet,
+		gitv1.WriteModeRegexReplace, gitv1.WriteModeInsertBefore, gitv1.WriteModeInsertAfter,
+		gitv1.WriteModeEnsureLine, gitv1.WriteModeRemoveLine:
+		return true
+	}
+	return false
+}
+
+// patchFile applies content to the file at path in the clone with an editing write mode and
+// returns the new content. A missing file is edited as an empty file. errEditSkipped is returned
+// when the pattern matches nothing and onNoMatch is Skip, or a line is removed from a missing file.
+// rolledOver is the path the previous content was moved to when a line based file rolled over.
+func patchFile(root, path string, edit fileEdit, content []byte, config *gitv1.Encryption) (patched []byte, rolledOver string, err error) {
+	// Only recipients are configured, the current content of an encrypted file is not readable
+	if encryption.ShouldEncryptFile(path, config) {
+		return nil, "", fmt.Errorf("write mode %s cannot edit the encrypted file %s", edit.mode, path)
+	}
+
+	current, err := readWorktreeFile(root, path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, "", err
+	}
+	if os.IsNotExist(err) && edit.mode == gitv1.WriteModeRemoveLine {
+		return nil, "", errEditSkipped
+	}
+
+	switch edit.mode {
+	case gitv1.WriteModeAppendLine, gitv1.WriteModePrepend:
+		patched, rolledOver, err = addLines(root, path, edit, current, content)
+	case gitv1.WriteModeJSONPatch:
+		patched, err = patch.JSONPatch(current, content)
+	case gitv1.WriteModeMergePatch:
+		patched, err = patch.MergePatch(current, content)
+	case gitv1.WriteModeYAMLSet:
+		if edit.yamlPath == "" {
+			return nil, "", fmt.Errorf("write mode %s of %s requires yamlPath", edit.mode, path)
+		}
+		patched, err = patch.SetPath(current, edit.yamlPath, content)
+	case gitv1.WriteModeRegexReplace:
+		patched, err = patch.Replace(current, edit.pattern, string(content))
+	case gitv1.WriteModeInsertBefore:
+		patched, err = patch.InsertBefore(current, edit.pattern, string(content))
+	case gitv1.WriteModeInsertAfter:
+		patched, err = patch.InsertAfter(current, edit.pattern, string(content))
+	case gitv1.WriteModeEnsureLine:
+		patched, err = patch.EnsureLine(current, edit.pattern, string(content))
+	case gitv1.WriteModeRemoveLine:
+		patched, err = patch.RemoveLine(current, edit.pattern, string(content))
+	default:
+		return content, "", nil
+	}
+	if errors.Is(err, patch.ErrNoMatch) && edit.onNoMatch == gitv1.NoMatchPolicySkip {
+		return nil, "", errEditSkipped
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to apply %s to %s: %w", edit.mode, path, err)
+	}
+	return patched, rolledOver, nil
+}
+
+// addLines adds the lines of content to the current file. When the file would exceed its limits
+// and rotation is Rollover, the current file is written to a dated file and a new one is started.
+func addLines(root, path string, edit fileEdit, current, content []byte) ([]byte, string, error) {
+	options := patch.LineOptions{Prepend: edit.mode == gitv1.WriteModePrepend}
+	rollover := false
+	if edit.lines != nil {
+		options.Header = edit.lines.Header
+		options.DedupeKey = edit.lines.DedupeKey
+		options.MaxLines = edit.lines.MaxLines
+		options.MaxBytes = edit.lines.MaxBytes
+		rollover = edit.lines.Rotation == gitv1.RotationPolicyRollover
+	}
+	if !rollover {
+		patched, err := patch.AddLines(current, content, options)
+		return patched, "", err
+	}
+
+	unlimited := options
+	unlimited.MaxLines, unlimited.MaxBytes = 0, 0
+	patched, err := patch.AddLines(current, content, unlimited)
+	if err != nil || len(current) == 0 || !options.Exceeded(patched) {
+		return patched, "", err
+	}
+
+	rolledOver := datedPath(root, path, time.Now().UTC())
+	if err := writeFile(filepath.Join(root, rolledOver), current, 0); err != nil {
+		return nil, "", err
+	}
+	patched, err = patch.AddLines(nil, content, options)
+	return patched, rolledOver, err
+}
+
+// datedPath returns path with the date inserted before the extension, e.g. prices-2024-01-15.csv.
+// A counter is added when the file was already rolled over that day.
+func datedPath(root, path string, now time.Time) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "-" + now.Format("2006-01-02")
+	dated := base + ext
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(root, dated)); os.IsNotExist(err) {
+			return dated
+		}
+		dated = base + "-" + strconv.Itoa(i) + ext
+	}
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+func TestPatchFile(t *testing.T) {
+	root := t.TempDir()
+	values := "# Frontend\nimage:\n  tag: \"1.19\" # pinned\n"
+	if err := os.WriteFile(filepath.Join(root, "values.yaml"), []byte(values), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		edit    fileEdit
+		content string
+		config  *gitv1.Encryption
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "yaml set",
+			path:    "values.yaml",
+			edit:    fileEdit{mode: gitv1.WriteModeYAMLSet, yamlPath: "image.tag"},
+			content: "1.20",
+			want:    "# Frontend\nimage:\n  tag: \"1.20\" # pinned\n",
+		},
+		{
+			name:    "merge patch",
+			path:    "values.yaml",
+			edit:    fileEdit{mode: gitv1.WriteModeMergePatch},
+			content: `{"replicaCount": 2}`,
+			want:    values + "replicaCount: 2\n",
+		},
+		{
+			name:    "json patch of a new file",
+			path:    "config/new.yaml",
+			edit:    fileEdit{mode: gitv1.WriteModeJSONPatch},
+			content: `[{"op": "add", "path": "/enabled", "value": true}]`,
+			want:    "enabled: true\n",
+		},
+		{
+			name:    "append line without trailing newline",
+			path:    "prices.csv",
+			edit:    fileEdit{mode: gitv1.WriteModeAppendLine, lines: &gitv1.LineOptions{Header: "date,price"}},
+			content: "2024-01-15,42.10",
+			want:    "date,price\n2024-01-15,42.10\n",
+		},
+		{
+			name:    "prepend line",
+			path:    "values.yaml",
+			edit:    fileEdit{mode: gitv1.WriteModePrepend},
+			content: "# Generated\n",
+			want:    "# Generated\n" + values,
+		},
+		{
+			name:    "regex replace",
+			path:    "values.yaml",
+			edit:    fileEdit{mode: gitv1.WriteModeRegexReplace, pattern: `tag: "([0-9]+)\.19"`},
+			content: `tag: "$1.20"`,
+			want:    "# Frontend\nimage:\n  tag: \"1.20\" # pinned\n",
+		},
+		{
+			name:    "ensure line of a new file",
+			path:    ".env",
+			edit:    fileEdit{mode: gitv1.WriteModeEnsureLine, pattern: "^LOG_LEVEL="},
+			content: "LOG_LEVEL=debug",
+			want:    "LOG_LEVEL=debug\n",
+		},
+		{
+			name:    "skipped insert",
+			path:    "values.yaml",
+			edit:    fileEdit{mode: gitv1.WriteModeInsertAfter, pattern: "^resources:", onNoMatch: gitv1.NoMatchPolicySkip},
+			content: "  limits: {}",
+			wantErr: errEditSkipped.Error(),
+		},
+		{
+			name:    "remove line of a missing file",
+			path:    "Makefile",
+			edit:    fileEdit{mode: gitv1.WriteModeRemoveLine, pattern: "^include"},
+			wantErr: errEditSkipped.Error(),
+		},
+		{name: "failed insert", path: "values.yaml", edit: fileEdit{mode: gitv1.WriteModeInsertBefore, pattern: "^resources:"}, wantErr: "failed to apply insertBefore to values.yaml: pattern matches nothing"},
+		{name: "missing yaml path", path: "values.yaml", edit: fileEdit{mode: gitv1.WriteModeYAMLSet}, content: "1.20", wantErr: "requires yamlPath"},
+		{name: "invalid patch", path: "values.yaml", edit: fileEdit{mode: gitv1.WriteModeJSONPatch}, content: `[{"op": "remove", "path": "/image/digest"}]`, wantErr: "failed to apply jsonPatch to values.yaml"},
+		{
+			name:    "encrypted file",
+			path:    "values.yaml",
+			edit:    fileEdit{mode: gitv1.WriteModeMergePatch},
+			content: `{}`,
+			config:  &gitv1.Encryption{Enabled: true},
+			wantErr: "cannot edit the encrypted file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := patchFile(root, tt.path, tt.edit, []byte(tt.content), tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("patchFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("patchFile() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("patchFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The write mode of a resource reference is strategy.writeMode, append adds the field to the
+// existing file on a new line
+func TestCommitChangesAppendsResourceRef(t *testing.T) {
+	w, root := committedWorktree(t, "replicas.log")
+	repo, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatalf("PlainOpen() error = %v", err)
+	}
+
+	r := fanOutReconciler(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "scaling", Namespace: "default"},
+		Data:       map[string]string{"replicas": "3"},
+	})
+	gitCommit := &gitv1.GitCommit{
+		ObjectMeta: metav1.ObjectMeta{Name: "scaling", Namespace: "default"},
+		Spec: gitv1.GitCommitSpec{
+			CommitMessage: "Record replicas",
+			ResourceRefs: []gitv1.ResourceRef{{
+				ApiVersion: "v1",
+				Kind:       "ConfigMap",
+				Name:       "scaling",
+				Strategy: gitv1.OutputStrategy{
+					Type:      gitv1.OutputTypeSingleField,
+					Path:      "replicas.log",
+					WriteMode: gitv1.WriteModeAppend,
+					FieldRef:  &gitv1.FieldRef{Key: "replicas"},
+				},
+			}},
+		},
+	}
+
+	hash, err := r.commitChanges(context.Background(), gitCommit, repo, w, root)
+	if err != nil {
+		t.Fatalf("commitChanges() error = %v", err)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatalf("CommitObject() error = %v", err)
+	}
+	file, err := commit.File("replicas.log")
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	if got, _ := file.Contents(); got != "replicas.log\n3" {
+		t.Errorf("replicas.log = %q, want %q", got, "replicas.log\n3")
+	}
+}
+
+func TestPatchFileRollover(t *testing.T) {
+	root := t.TempDir()
+	csv := "date,price\n2024-01-14,41.80\n2024-01-15,42.10\n"
+	if err := os.MkdirAll(filepath.Join(root, "data"), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "data/prices.csv"), []byte(csv), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	edit := fileEdit{
+		mode:  gitv1.WriteModeAppendLine,
+		lines: &gitv1.LineOptions{Header: "date,price", MaxLines: 2, Rotation: gitv1.RotationPolicyRollover},
+	}
+
+	got, rolledOver, err := patchFile(root, "data/prices.csv", edit, []byte("2024-01-16,42.55"), nil)
+	if err != nil {
+		t.Fatalf("patchFile() error = %v", err)
+	}
+	if want := "date,price\n2024-01-16,42.55\n"; string(got) != want {
+		t.Errorf("patchFile() = %q, want %q", got, want)
+	}
+	if want := "data/prices-" + time.Now().UTC().Format("2006-01-02") + ".csv"; rolledOver != want {
+		t.Fatalf("patchFile() rolledOver = %q, want %q", rolledOver, want)
+	}
+	if rolled, err := os.ReadFile(filepath.Join(root, rolledOver)); err != nil || string(rolled) != csv {
+		t.Errorf("rolled over file = %q, %v, want %q", rolled, err, csv)
+	}
+
+	// A second rollover on the same day gets a counter
+	if next := datedPath(root, "data/prices.csv", time.Now().UTC()); next != strings.TrimSuffix(rolledOver, ".csv")+"-2.csv" {
+		t.Errorf("datedPath() = %q, want a counter", next)
+	}
+
+	// Within the limits nothing rolls over
+	if err := os.WriteFile(filepath.Join(root, "data/prices.csv"), got, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	_, rolledOver, err = patchFile(root, "data/prices.csv", edit, []byte("2024-01-17,42.60"), nil)
+	if err != nil || rolledOver != "" {
+		t.Errorf("patchFile() rolledOver = %q, %v, want none", rolledOver, err)
+	}
+}
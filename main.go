@@ -3,7 +3,9 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -16,6 +18,7 @@ import (
 
 	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
 	"github.com/mihaigalos/git-change-operator/controllers"
+	"github.com/mihaigalos/git-change-operator/pkg/gitcache"
 )
 
 var (
@@ -34,12 +37,21 @@ func main() {
 	var probeAddr string
 	var watchNamespace string
 	var mode string
+	var prStatusPollInterval time.Duration
+	var commitAuthor gitv1.CommitIdentity
+	var repositoryCacheDir string
+	var repositoryCacheMaxSize string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election for controller manager.")
 	flag.StringVar(&watchNamespace, "watch-namespace", "", "Namespace to watch for resources. Empty string defaults to the pod's own namespace (POD_NAMESPACE env var).")
 	flag.StringVar(&mode, "mode", "operator", "Run mode: 'manager' (GitChangeOperator controller) or 'operator' (GitCommit/PullRequest controllers).")
+	flag.DurationVar(&prStatusPollInterval, "pullrequest-status-poll-interval", time.Minute, "How often open pull requests are polled for merges, reviews and checks.")
+	flag.StringVar(&commitAuthor.Name, "commit-author-name", controllers.DefaultCommitAuthor.Name, "Author name of commits whose resource does not set spec.author.")
+	flag.StringVar(&commitAuthor.Email, "commit-author-email", controllers.DefaultCommitAuthor.Email, "Author email of commits whose resource does not set spec.author.")
+	flag.StringVar(&repositoryCacheDir, "repository-cache-dir", "", "Directory of the persistent repository cache. Empty clones every repository from scratch on each run.")
+	flag.StringVar(&repositoryCacheMaxSize, "repository-cache-max-size", "0", "Size above which the least recently used cached repositories are evicted, e.g. 10Gi. 0 disables eviction.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -82,17 +94,37 @@ func main() {
 			os.Exit(1)
 		}
 	} else {
+		var repositoryCache *gitcache.Cache
+		if repositoryCacheDir != "" {
+			maxSize, err := resource.ParseQuantity(repositoryCacheMaxSize)
+			if err != nil {
+				setupLog.Error(err, "invalid repository cache size", "size", repositoryCacheMaxSize)
+				os.Exit(1)
+			}
+			if repositoryCache, err = gitcache.New(repositoryCacheDir, maxSize.Value()); err != nil {
+				setupLog.Error(err, "unable to set up repository cache", "dir", repositoryCacheDir)
+				os.Exit(1)
+			}
+			setupLog.Info("using repository cache", "dir", repositoryCacheDir, "maxSize", repositoryCacheMaxSize)
+		}
+
 		if err = (&controllers.GitCommitReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
+			Client:          mgr.GetClient(),
+			Scheme:          mgr.GetScheme(),
+			DefaultAuthor:   commitAuthor,
+			RepositoryCache: repositoryCache,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "GitCommit")
 			os.Exit(1)
 		}
 
 		if err = (&controllers.PullRequestReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
+			Client:             mgr.GetClient(),
+			Scheme:             mgr.GetScheme(),
+			StatusPollInterval: prStatusPollInterval,
+			Recorder:           mgr.GetEventRecorderFor("pullrequest-controller"),
+			DefaultAuthor:      commitAuthor,
+			RepositoryCache:    repositoryCache,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "PullRequest")
 			os.Exit(1)
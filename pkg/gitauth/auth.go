@@ -0,0 +1,183 @@
+package gitauth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultTokenKey is the secret key holding the HTTPS token when no key is configured
+	DefaultTokenKey = "token"
+
+	// UsernameKey optionally overrides the HTTPS basic auth username (default: oauth2)
+	UsernameKey = "username"
+
+	// SSHPrivateKeyKey holds a PEM encoded private key and selects the SSH transport.
+	// It matches the key used by secrets of type kubernetes.io/ssh-auth.
+	SSHPrivateKeyKey = "ssh-privatekey"
+
+	// SSHPassphraseKey optionally holds the passphrase protecting the private key
+	SSHPassphraseKey = "passphrase"
+
+	// SSHKnownHostsKey holds known_hosts entries used to verify the server host key
+	SSHKnownHostsKey = "known_hosts"
+
+	// SSHStrictHostKeyCheckingKey disables host key verification when set to "false" or "no"
+	SSHStrictHostKeyCheckingKey = "strictHostKeyChecking"
+
+	defaultHTTPUsername = "oauth2"
+	defaultSSHUsername  = "git"
+)
+
+// IsSSHSecret reports whether the secret carries SSH credentials
+func IsSSHSecret(secret *corev1.Secret) bool {
+	_, ok := secret.Data[SSHPrivateKeyKey]
+	return ok
+}
+
+// FromSecret builds the go-git authentication method for a repository from a secret.
+// Secrets containing an ssh-privatekey entry select the SSH transport, secrets containing
+// a githubAppID push over HTTPS with an installation token; everything else falls back to
+// HTTPS basic auth using the token stored under tokenKey.
+func FromSecret(ctx context.Context, secret *corev1.Secret, repository, tokenKey string) (transport.AuthMethod, error) {
+	if IsSSHSecret(secret) {
+		return sshAuthFromSecret(secret, repository)
+	}
+
+	if IsGitHubAppSecret(secret) {
+		app, err := GitHubAppFromSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+		token, err := DefaultInstallationTokenCache.Token(ctx, app)
+		if err != nil {
+			return nil, err
+		}
+		return &githttp.BasicAuth{
+			Username: gitHubAppUsername,
+			Password: token.AccessToken,
+		}, nil
+	}
+
+	token, err := tokenFromSecret(secret, tokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	username := defaultHTTPUsername
+	if usernameData, exists := secret.Data[UsernameKey]; exists {
+		username = string(usernameData)
+	}
+
+	return &githttp.BasicAuth{
+		Username: username,
+		Password: token,
+	}, nil
+}
+
+// TokenSourceFromSecret returns the token source used for provider API calls.
+// GitHub App secrets yield short-lived installation tokens that are refreshed on demand,
+// any other secret yields the static token stored under tokenKey.
+func TokenSourceFromSecret(ctx context.Context, secret *corev1.Secret, tokenKey string) (oauth2.TokenSource, error) {
+	if IsGitHubAppSecret(secret) {
+		app, err := GitHubAppFromSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+		return DefaultInstallationTokenCache.TokenSource(ctx, app), nil
+	}
+
+	token, err := tokenFromSecret(secret, tokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+}
+
+func tokenFromSecret(secret *corev1.Secret, tokenKey string) (string, error) {
+	key := tokenKey
+	if key == "" {
+		key = DefaultTokenKey
+	}
+
+	token, exists := secret.Data[key]
+	if !exists {
+		return "", fmt.Errorf("key %s not found in secret %s", key, secret.Name)
+	}
+
+	return string(token), nil
+}
+
+func sshAuthFromSecret(secret *corev1.Secret, repository string) (transport.AuthMethod, error) {
+	user := defaultSSHUsername
+	if endpoint, err := transport.NewEndpoint(repository); err == nil && endpoint.User != "" {
+		user = endpoint.User
+	}
+
+	auth, err := gitssh.NewPublicKeys(user, secret.Data[SSHPrivateKeyKey], string(secret.Data[SSHPassphraseKey]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH private key from secret %s: %w", secret.Name, err)
+	}
+
+	if !strictHostKeyChecking(secret) {
+		auth.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return auth, nil
+	}
+
+	knownHosts, exists := secret.Data[SSHKnownHostsKey]
+	if !exists || len(strings.TrimSpace(string(knownHosts))) == 0 {
+		return nil, fmt.Errorf("key %s not found in secret %s (required for strict host key checking)", SSHKnownHostsKey, secret.Name)
+	}
+
+	callback, err := newKnownHostsCallback(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse known_hosts from secret %s: %w", secret.Name, err)
+	}
+	auth.HostKeyCallback = callback
+
+	return auth, nil
+}
+
+func strictHostKeyChecking(secret *corev1.Secret) bool {
+	value, exists := secret.Data[SSHStrictHostKeyCheckingKey]
+	if !exists {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(string(value))) {
+	case "false", "no", "0":
+		return false
+	default:
+		return true
+	}
+}
+
+// newKnownHostsCallback builds a host key callback from in-memory known_hosts content.
+// The known_hosts parser only reads files, so the content is staged in a temporary file
+// that is removed once it has been loaded.
+func newKnownHostsCallback(knownHosts []byte) (ssh.HostKeyCallback, error) {
+	f, err := os.CreateTemp("", "known_hosts-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(knownHosts); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return gitssh.NewKnownHostsCallback(f.Name())
+}
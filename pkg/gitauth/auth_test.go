@@ -0,0 +1,187 @@
+package gitauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func generateKey(t *testing.T) ([]byte, ssh.PublicKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Failed to convert public key: %v", err)
+	}
+	return pem.EncodeToMemory(block), sshPub
+}
+
+func newSecret(data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "git-credentials", Namespace: "default"},
+		Data:       data,
+	}
+}
+
+func TestFromSecretBasicAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         map[string][]byte
+		tokenKey     string
+		wantUsername string
+		wantPassword string
+		wantError    bool
+	}{
+		{
+			name:         "default token key and username",
+			data:         map[string][]byte{"token": []byte("ghp_abc")},
+			wantUsername: "oauth2",
+			wantPassword: "ghp_abc",
+		},
+		{
+			name:         "custom token key and username",
+			data:         map[string][]byte{"pat": []byte("glpat"), "username": []byte("bot")},
+			tokenKey:     "pat",
+			wantUsername: "bot",
+			wantPassword: "glpat",
+		},
+		{
+			name:      "missing token key",
+			data:      map[string][]byte{"other": []byte("x")},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := FromSecret(context.Background(), newSecret(tt.data), "https://github.com/org/repo.git", tt.tokenKey)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("FromSecret() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			basic, ok := auth.(*githttp.BasicAuth)
+			if !ok {
+				t.Fatalf("Expected *http.BasicAuth, got %T", auth)
+			}
+			if basic.Username != tt.wantUsername || basic.Password != tt.wantPassword {
+				t.Errorf("Got %s:%s, want %s:%s", basic.Username, basic.Password, tt.wantUsername, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestFromSecretSSH(t *testing.T) {
+	privateKey, _ := generateKey(t)
+	_, hostKey := generateKey(t)
+	knownHostsLine := knownhosts.Line([]string{"[git.example.com]:2222"}, hostKey)
+
+	tests := []struct {
+		name       string
+		data       map[string][]byte
+		repository string
+		wantUser   string
+		wantError  bool
+	}{
+		{
+			name: "private key with known_hosts",
+			data: map[string][]byte{
+				SSHPrivateKeyKey: privateKey,
+				SSHKnownHostsKey: []byte(knownHostsLine + "\n"),
+			},
+			repository: "ssh://deploy@git.example.com:2222/org/repo.git",
+			wantUser:   "deploy",
+		},
+		{
+			name: "scp-like URL defaults to git user",
+			data: map[string][]byte{
+				SSHPrivateKeyKey: privateKey,
+				SSHKnownHostsKey: []byte(knownHostsLine),
+			},
+			repository: "git.example.com:org/repo.git",
+			wantUser:   "git",
+		},
+		{
+			name:       "missing known_hosts is rejected by default",
+			data:       map[string][]byte{SSHPrivateKeyKey: privateKey},
+			repository: "git@git.example.com:org/repo.git",
+			wantError:  true,
+		},
+		{
+			name: "strict host key checking can be disabled",
+			data: map[string][]byte{
+				SSHPrivateKeyKey:            privateKey,
+				SSHStrictHostKeyCheckingKey: []byte("false"),
+			},
+			repository: "git@git.example.com:org/repo.git",
+			wantUser:   "git",
+		},
+		{
+			name:       "invalid private key",
+			data:       map[string][]byte{SSHPrivateKeyKey: []byte("not a key"), SSHKnownHostsKey: []byte(knownHostsLine)},
+			repository: "git@git.example.com:org/repo.git",
+			wantError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := FromSecret(context.Background(), newSecret(tt.data), tt.repository, "")
+			if (err != nil) != tt.wantError {
+				t.Fatalf("FromSecret() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			keys, ok := auth.(*gitssh.PublicKeys)
+			if !ok {
+				t.Fatalf("Expected *ssh.PublicKeys, got %T", auth)
+			}
+			if keys.User != tt.wantUser {
+				t.Errorf("User = %s, want %s", keys.User, tt.wantUser)
+			}
+			if keys.HostKeyCallback == nil {
+				t.Errorf("Expected a host key callback to be set")
+			}
+		})
+	}
+}
+
+func TestKnownHostsCallbackVerifiesHostKey(t *testing.T) {
+	_, hostKey := generateKey(t)
+	_, otherKey := generateKey(t)
+
+	callback, err := newKnownHostsCallback([]byte(knownhosts.Line([]string{"[127.0.0.1]:2222"}, hostKey)))
+	if err != nil {
+		t.Fatalf("Failed to build callback: %v", err)
+	}
+
+	addr := &fakeAddr{"127.0.0.1:2222"}
+	if err := callback("127.0.0.1:2222", addr, hostKey); err != nil {
+		t.Errorf("Expected known host key to be accepted, got %v", err)
+	}
+	if err := callback("127.0.0.1:2222", addr, otherKey); err == nil {
+		t.Errorf("Expected mismatching host key to be rejected")
+	}
+}
+
+type fakeAddr struct{ addr string }
+
+func (a *fakeAddr) Network() string { return "tcp" }
+func (a *fakeAddr) String() string  { return a.addr }
@@ -0,0 +1,250 @@
+package gitauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// GitHubAppIDKey holds the numeric GitHub App ID and selects GitHub App authentication
+	GitHubAppIDKey = "githubAppID"
+
+	// GitHubAppInstallationIDKey holds the numeric installation ID of the app
+	GitHubAppInstallationIDKey = "githubAppInstallationID"
+
+	// GitHubAppPrivateKeyKey holds the PEM encoded private key of the app
+	GitHubAppPrivateKeyKey = "githubAppPrivateKey"
+
+	// GitHubAPIURLKey optionally overrides the API root, e.g. https://ghe.example.com/api/v3/
+	GitHubAPIURLKey = "githubAPIURL"
+
+	// gitHubAppUsername is the username GitHub expects for git over HTTPS with installation tokens
+	gitHubAppUsername = "x-access-token"
+
+	// tokenRefreshMargin is how long before expiry a cached installation token is replaced
+	tokenRefreshMargin = 5 * time.Minute
+
+	// jwtLifetime stays below the 10 minute maximum GitHub accepts for app JWTs
+	jwtLifetime = 9 * time.Minute
+)
+
+// GitHubApp identifies a GitHub App installation
+type GitHubApp struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     *rsa.PrivateKey
+	BaseURL        string
+}
+
+// IsGitHubAppSecret reports whether the secret carries GitHub App credentials
+func IsGitHubAppSecret(secret *corev1.Secret) bool {
+	_, ok := secret.Data[GitHubAppIDKey]
+	return ok
+}
+
+// GitHubAppFromSecret parses GitHub App credentials from a secret
+func GitHubAppFromSecret(secret *corev1.Secret) (*GitHubApp, error) {
+	appID, err := parseSecretInt(secret, GitHubAppIDKey)
+	if err != nil {
+		return nil, err
+	}
+
+	installationID, err := parseSecretInt(secret, GitHubAppInstallationIDKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyData, exists := secret.Data[GitHubAppPrivateKeyKey]
+	if !exists {
+		return nil, fmt.Errorf("key %s not found in secret %s", GitHubAppPrivateKeyKey, secret.Name)
+	}
+
+	privateKey, err := parseRSAPrivateKey(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key from secret %s: %w", secret.Name, err)
+	}
+
+	return &GitHubApp{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKey:     privateKey,
+		BaseURL:        strings.TrimSpace(string(secret.Data[GitHubAPIURLKey])),
+	}, nil
+}
+
+func parseSecretInt(secret *corev1.Secret, key string) (int64, error) {
+	value, exists := secret.Data[key]
+	if !exists {
+		return 0, fmt.Errorf("key %s not found in secret %s", key, secret.Name)
+	}
+
+	parsed, err := strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("key %s in secret %s is not a valid ID: %w", key, secret.Name, err)
+	}
+
+	return parsed, nil
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA private key, got %T", parsed)
+	}
+
+	return key, nil
+}
+
+// signJWT creates the RS256 signed JWT used to authenticate as the app itself
+func (a *GitHubApp) signJWT(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+
+	claims, err := json.Marshal(map[string]interface{}{
+		// Backdate issuance to tolerate clock drift between the operator and GitHub
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iss": strconv.FormatInt(a.AppID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// cacheKey includes the SHA-256 fingerprint of the private key, so a token is only handed out
+// to secrets holding the key it was minted with and never to another secret naming the same
+// installation
+func (a *GitHubApp) cacheKey() string {
+	fingerprint := sha256.Sum256(x509.MarshalPKCS1PrivateKey(a.PrivateKey))
+	return fmt.Sprintf("%s/%d/%d/%x", a.BaseURL, a.AppID, a.InstallationID, fingerprint)
+}
+
+// InstallationTokenCache mints GitHub App installation tokens and caches them
+// until shortly before they expire
+type InstallationTokenCache struct {
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*oauth2.Token
+}
+
+// DefaultInstallationTokenCache is shared by all controllers of the operator
+var DefaultInstallationTokenCache = NewInstallationTokenCache(http.DefaultClient)
+
+// NewInstallationTokenCache creates an installation token cache using the given HTTP client
+func NewInstallationTokenCache(httpClient *http.Client) *InstallationTokenCache {
+	return &InstallationTokenCache{
+		httpClient: httpClient,
+		now:        time.Now,
+		tokens:     make(map[string]*oauth2.Token),
+	}
+}
+
+// Token returns a cached installation token or mints a new one. The lock is not held while
+// minting, concurrent callers may both mint a token and the last one is kept.
+func (c *InstallationTokenCache) Token(ctx context.Context, app *GitHubApp) (*oauth2.Token, error) {
+	key := app.cacheKey()
+
+	c.mu.Lock()
+	token, exists := c.tokens[key]
+	c.mu.Unlock()
+	if exists && c.now().Add(tokenRefreshMargin).Before(token.Expiry) {
+		return token, nil
+	}
+
+	token, err := c.mint(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.tokens[key] = token
+	c.mu.Unlock()
+	return token, nil
+}
+
+// TokenSource returns an oauth2.TokenSource backed by the cache, suitable for go-github clients
+func (c *InstallationTokenCache) TokenSource(ctx context.Context, app *GitHubApp) oauth2.TokenSource {
+	return &installationTokenSource{ctx: ctx, cache: c, app: app}
+}
+
+func (c *InstallationTokenCache) mint(ctx context.Context, app *GitHubApp) (*oauth2.Token, error) {
+	jwt, err := app.signJWT(c.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	// WithAuthToken wraps the transport of the client it is given, a copy keeps the JWT out of
+	// the shared client
+	httpClient := *c.httpClient
+	client := github.NewClient(&httpClient).WithAuthToken(jwt)
+	if app.BaseURL != "" {
+		baseURL, err := url.Parse(strings.TrimSuffix(app.BaseURL, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %q: %w", app.BaseURL, err)
+		}
+		client.BaseURL = baseURL
+	}
+
+	installationToken, _, err := client.Apps.CreateInstallationToken(ctx, app.InstallationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installation token for GitHub App %d: %w", app.AppID, err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: installationToken.GetToken(),
+		Expiry:      installationToken.GetExpiresAt().Time,
+	}, nil
+}
+
+type installationTokenSource struct {
+	ctx   context.Context
+	cache *InstallationTokenCache
+	app   *GitHubApp
+}
+
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	return s.cache.Token(s.ctx, s.app)
+}
@@ -0,0 +1,250 @@
+package gitauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+func generateRSAKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// newInstallationTokenServer serves the installation access token endpoint and checks
+// that every request carries a JWT signed by the app key
+func newInstallationTokenServer(t *testing.T, key *rsa.PrivateKey, appID, installationID int64, expiresIn time.Duration) (*httptest.Server, *int32) {
+	var minted int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != fmt.Sprintf("/app/installations/%d/access_tokens", installationID) {
+			http.NotFound(w, r)
+			return
+		}
+
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			http.Error(w, "malformed JWT", http.StatusUnauthorized)
+			return
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			http.Error(w, "malformed signature", http.StatusUnauthorized)
+			return
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		if err := json.Unmarshal(claimsJSON, &claims); err != nil || claims.Iss != fmt.Sprint(appID) || claims.Exp-claims.Iat > 600 {
+			http.Error(w, "bad claims", http.StatusUnauthorized)
+			return
+		}
+
+		n := atomic.AddInt32(&minted, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("ghs_token%d", n),
+			"expires_at": time.Now().Add(expiresIn).UTC().Format(time.RFC3339),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &minted
+}
+
+func TestGitHubAppFromSecret(t *testing.T) {
+	_, keyPEM := generateRSAKey(t)
+
+	tests := []struct {
+		name      string
+		data      map[string][]byte
+		wantError bool
+	}{
+		{
+			name: "valid app secret",
+			data: map[string][]byte{
+				GitHubAppIDKey:             []byte("12345"),
+				GitHubAppInstallationIDKey: []byte("67890\n"),
+				GitHubAppPrivateKeyKey:     keyPEM,
+			},
+		},
+		{
+			name: "missing installation ID",
+			data: map[string][]byte{
+				GitHubAppIDKey:         []byte("12345"),
+				GitHubAppPrivateKeyKey: keyPEM,
+			},
+			wantError: true,
+		},
+		{
+			name: "non numeric app ID",
+			data: map[string][]byte{
+				GitHubAppIDKey:             []byte("my-app"),
+				GitHubAppInstallationIDKey: []byte("67890"),
+				GitHubAppPrivateKeyKey:     keyPEM,
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid private key",
+			data: map[string][]byte{
+				GitHubAppIDKey:             []byte("12345"),
+				GitHubAppInstallationIDKey: []byte("67890"),
+				GitHubAppPrivateKeyKey:     []byte("not a key"),
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, err := GitHubAppFromSecret(newSecret(tt.data))
+			if (err != nil) != tt.wantError {
+				t.Fatalf("GitHubAppFromSecret() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			if app.AppID != 12345 || app.InstallationID != 67890 {
+				t.Errorf("Got app %d installation %d, want 12345/67890", app.AppID, app.InstallationID)
+			}
+		})
+	}
+}
+
+func TestInstallationTokenCache(t *testing.T) {
+	key, _ := generateRSAKey(t)
+	server, minted := newInstallationTokenServer(t, key, 12345, 67890, time.Hour)
+
+	cache := NewInstallationTokenCache(server.Client())
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	app := &GitHubApp{AppID: 12345, InstallationID: 67890, PrivateKey: key, BaseURL: server.URL}
+
+	first, err := cache.Token(context.Background(), app)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if first.AccessToken != "ghs_token1" {
+		t.Errorf("AccessToken = %s, want ghs_token1", first.AccessToken)
+	}
+
+	second, err := cache.TokenSource(context.Background(), app).Token()
+	if err != nil {
+		t.Fatalf("TokenSource().Token() error = %v", err)
+	}
+	if second.AccessToken != first.AccessToken || atomic.LoadInt32(minted) != 1 {
+		t.Errorf("Expected cached token to be reused, minted %d tokens", atomic.LoadInt32(minted))
+	}
+
+	// Move the clock into the refresh margin before expiry
+	now = now.Add(time.Hour - tokenRefreshMargin + time.Second)
+	refreshed, err := cache.Token(context.Background(), app)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if refreshed.AccessToken != "ghs_token2" || atomic.LoadInt32(minted) != 2 {
+		t.Errorf("Expected a new token near expiry, got %s after %d mints", refreshed.AccessToken, atomic.LoadInt32(minted))
+	}
+}
+
+func TestInstallationTokenCacheRejectedJWT(t *testing.T) {
+	key, _ := generateRSAKey(t)
+	otherKey, _ := generateRSAKey(t)
+	server, _ := newInstallationTokenServer(t, key, 12345, 67890, time.Hour)
+
+	cache := NewInstallationTokenCache(server.Client())
+	app := &GitHubApp{AppID: 12345, InstallationID: 67890, PrivateKey: otherKey, BaseURL: server.URL}
+
+	if _, err := cache.Token(context.Background(), app); err == nil {
+		t.Errorf("Expected a JWT signed with the wrong key to be rejected")
+	}
+}
+
+func TestInstallationTokenCacheKeyedByPrivateKey(t *testing.T) {
+	key, _ := generateRSAKey(t)
+	otherKey, _ := generateRSAKey(t)
+	server, minted := newInstallationTokenServer(t, key, 12345, 67890, time.Hour)
+
+	cache := NewInstallationTokenCache(server.Client())
+	app := &GitHubApp{AppID: 12345, InstallationID: 67890, PrivateKey: key, BaseURL: server.URL}
+	if _, err := cache.Token(context.Background(), app); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	// A secret naming the same installation without its key must not receive the cached token
+	other := &GitHubApp{AppID: 12345, InstallationID: 67890, PrivateKey: otherKey, BaseURL: server.URL}
+	if token, err := cache.Token(context.Background(), other); err == nil {
+		t.Errorf("Token() = %s for a different private key, want an error", token.AccessToken)
+	}
+	if atomic.LoadInt32(minted) != 1 {
+		t.Errorf("Minted %d tokens, want 1", atomic.LoadInt32(minted))
+	}
+}
+
+func TestFromSecretGitHubApp(t *testing.T) {
+	key, keyPEM := generateRSAKey(t)
+	server, _ := newInstallationTokenServer(t, key, 12345, 67890, time.Hour)
+
+	previous := DefaultInstallationTokenCache
+	DefaultInstallationTokenCache = NewInstallationTokenCache(server.Client())
+	t.Cleanup(func() { DefaultInstallationTokenCache = previous })
+
+	secret := newSecret(map[string][]byte{
+		GitHubAppIDKey:             []byte("12345"),
+		GitHubAppInstallationIDKey: []byte("67890"),
+		GitHubAppPrivateKeyKey:     keyPEM,
+		GitHubAPIURLKey:            []byte(server.URL),
+	})
+
+	auth, err := FromSecret(context.Background(), secret, "https://github.com/org/repo.git", "")
+	if err != nil {
+		t.Fatalf("FromSecret() error = %v", err)
+	}
+	basic, ok := auth.(*githttp.BasicAuth)
+	if !ok {
+		t.Fatalf("Expected *http.BasicAuth, got %T", auth)
+	}
+	if basic.Username != "x-access-token" || basic.Password != "ghs_token1" {
+		t.Errorf("Got %s:%s, want x-access-token:ghs_token1", basic.Username, basic.Password)
+	}
+
+	tokenSource, err := TokenSourceFromSecret(context.Background(), secret, "")
+	if err != nil {
+		t.Fatalf("TokenSourceFromSecret() error = %v", err)
+	}
+	token, err := tokenSource.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.AccessToken != "ghs_token1" {
+		t.Errorf("Expected the cached installation token, got %s", token.AccessToken)
+	}
+}
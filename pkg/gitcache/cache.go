@@ -0,0 +1,439 @@
+package gitcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/transactional"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	repositoriesDir = "repositories"
+	worktreesDir    = "worktrees"
+)
+
+// Cache keeps a bare repository per remote URL below a directory, usually a persistent volume.
+// Every checkout fetches what changed since the last one and gets a private worktree whose
+// objects are read from the bare repository, so only the new commits are transferred.
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	// evicting is held by the one eviction running at a time
+	evicting sync.Mutex
+}
+
+// entry guards one bare repository. Fetches hold the write lock, checkouts reading from the
+// repository hold the read lock, and users counts checkouts so eviction skips repositories in use.
+// users, size and lastUsed are guarded by Cache.mu, size is only changed while also holding
+// the write lock.
+type entry struct {
+	sync.RWMutex
+	users    int
+	size     int64
+	lastUsed time.Time
+}
+
+// Worktree is a checkout of the default branch of a cached repository in its own directory
+type Worktree struct {
+	Repository *git.Repository
+	Dir        string
+
+	cache *Cache
+	key   string
+	entry *entry
+	once  sync.Once
+}
+
+// New creates a cache in dir that evicts the least recently used repositories once they take
+// more than maxSize bytes. A maxSize of zero disables eviction. Worktrees left behind by a
+// previous process are removed and the repositories it cached are measured once.
+func New(dir string, maxSize int64) (*Cache, error) {
+	if err := os.RemoveAll(filepath.Join(dir, worktreesDir)); err != nil {
+		return nil, err
+	}
+	for _, sub := range []string{repositoriesDir, worktreesDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Cache{dir: dir, maxSize: maxSize, entries: map[string]*entry{}}
+	dirEntries, err := os.ReadDir(filepath.Join(dir, repositoriesDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if err != nil || !dirEntry.IsDir() {
+			continue
+		}
+		c.entries[dirEntry.Name()] = &entry{
+			size:     dirSize(filepath.Join(dir, repositoriesDir, dirEntry.Name())),
+			lastUsed: info.ModTime(),
+		}
+	}
+	return c, nil
+}
+
+// Checkout brings the cached repository of url up to date and checks out the default branch of
+// the remote in a new worktree, like a fresh clone would. The remote branches are available as
+// refs/remotes/origin/<branch>. With noCheckout HEAD points at the default branch but no files
+// are written. Close must be called once the worktree is no longer needed.
+func (c *Cache) Checkout(ctx context.Context, url string, auth transport.AuthMethod, noCheckout bool) (*Worktree, error) {
+	key := repositoryKey(url)
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &entry{}
+		c.entries[key] = e
+	}
+	e.users++
+	c.mu.Unlock()
+
+	wt := &Worktree{cache: c, key: key, entry: e}
+
+	e.Lock()
+	defaultBranch, err := c.fetch(ctx, key, url, auth, e)
+	e.Unlock()
+	if err != nil {
+		c.release(key, e)
+		return nil, err
+	}
+
+	e.RLock()
+	if err := wt.open(defaultBranch, noCheckout); err != nil {
+		wt.Close()
+		return nil, err
+	}
+	return wt, nil
+}
+
+// fetch creates or updates the bare repository of url and returns the default branch of the
+// remote. The repository is measured once afterwards to update the size of e, whose write lock
+// must be held.
+func (c *Cache) fetch(ctx context.Context, key, url string, auth transport.AuthMethod, e *entry) (plumbing.ReferenceName, error) {
+	log := log.FromContext(ctx)
+	path := filepath.Join(c.dir, repositoriesDir, key)
+
+	repo, err := git.PlainOpen(path)
+	hit := err == nil
+	if err == git.ErrRepositoryNotExists {
+		repo, err = initRepository(path, url)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return "", err
+	}
+	if remote.Config().URLs[0] != url {
+		return "", fmt.Errorf("cached repository %s belongs to %s", key, remote.Config().URLs[0])
+	}
+
+	advertised, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return "", err
+	}
+
+	err = remote.FetchContext(ctx, &git.FetchOptions{Auth: auth, Force: true})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return "", err
+	}
+	size := dirSize(path)
+	fetched := size - e.size
+	if fetched < 0 {
+		fetched = 0
+	}
+	c.mu.Lock()
+	e.size = size
+	c.mu.Unlock()
+
+	if hit {
+		cacheRequests.WithLabelValues("hit").Inc()
+	} else {
+		cacheRequests.WithLabelValues("miss").Inc()
+	}
+	fetchedBytes.Add(float64(fetched))
+	log.V(1).Info("Fetched into repository cache", "repository", url, "hit", hit, "bytes", fetched)
+
+	if err := pruneBranches(repo, advertised); err != nil {
+		return "", err
+	}
+	return defaultBranch(advertised)
+}
+
+// initRepository creates a bare repository mirroring the branches of url as remote branches
+func initRepository(path, url string) (*git.Repository, error) {
+	repo, err := git.PlainInit(path, true)
+	if err != nil {
+		return nil, err
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name:  git.DefaultRemoteName,
+		URLs:  []string{url},
+		Fetch: []config.RefSpec{config.RefSpec(fmt.Sprintf(config.DefaultFetchRefSpec, git.DefaultRemoteName))},
+	})
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+	return repo, nil
+}
+
+// pruneBranches removes the remote branches that were deleted on the server
+func pruneBranches(repo *git.Repository, advertised []*plumbing.Reference) error {
+	branches := map[string]bool{}
+	for _, ref := range advertised {
+		if ref.Name().IsBranch() {
+			branches[ref.Name().Short()] = true
+		}
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return err
+	}
+	var stale []plumbing.ReferenceName
+	prefix := git.DefaultRemoteName + "/"
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() && !branches[strings.TrimPrefix(ref.Name().Short(), prefix)] {
+			stale = append(stale, ref.Name())
+		}
+		return nil
+	})
+	for _, name := range stale {
+		if err := repo.Storer.RemoveReference(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultBranch resolves HEAD of the remote, preferring the symbolic reference the server
+// advertises and falling back to the branch HEAD points at
+func defaultBranch(advertised []*plumbing.Reference) (plumbing.ReferenceName, error) {
+	var head *plumbing.Reference
+	for _, ref := range advertised {
+		if ref.Name() == plumbing.HEAD {
+			head = ref
+		}
+	}
+	if head == nil {
+		return "", fmt.Errorf("remote repository does not advertise HEAD")
+	}
+	if head.Type() == plumbing.SymbolicReference {
+		return head.Target(), nil
+	}
+
+	var candidates []plumbing.ReferenceName
+	for _, ref := range advertised {
+		if ref.Name().IsBranch() && ref.Hash() == head.Hash() {
+			candidates = append(candidates, ref.Name())
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no branch matches HEAD %s of the remote repository", head.Hash())
+	}
+	rank := func(name plumbing.ReferenceName) int {
+		switch name {
+		case plumbing.Main:
+			return 0
+		case plumbing.Master:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return rank(candidates[i]) < rank(candidates[j]) })
+	return candidates[0], nil
+}
+
+// open creates the worktree directory and checks out branch from the cached remote branches.
+// Objects, references and the index written during the run stay in the worktree directory.
+func (w *Worktree) open(branch plumbing.ReferenceName, noCheckout bool) error {
+	dir, err := os.MkdirTemp(filepath.Join(w.cache.dir, worktreesDir), w.key+"-")
+	if err != nil {
+		return err
+	}
+	w.Dir = dir
+
+	base := filesystem.NewStorage(osfs.New(filepath.Join(w.cache.dir, repositoriesDir, w.key)), cache.NewObjectLRUDefault())
+	temporal := filesystem.NewStorage(osfs.New(filepath.Join(dir, git.GitDirName)), cache.NewObjectLRUDefault())
+	storage := transactional.NewStorage(base, temporal)
+
+	remoteRef, err := base.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch.Short()))
+	if err != nil {
+		return fmt.Errorf("default branch %s not found in the repository cache: %w", branch.Short(), err)
+	}
+	if err := storage.SetReference(plumbing.NewHashReference(branch, remoteRef.Hash())); err != nil {
+		return err
+	}
+	if err := storage.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch)); err != nil {
+		return err
+	}
+
+	repo, err := git.Open(storage, osfs.New(dir))
+	if err != nil {
+		return err
+	}
+	w.Repository = repo
+	if noCheckout {
+		return nil
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(&git.CheckoutOptions{Branch: branch, Force: true})
+}
+
+// Close removes the worktree directory, marks the repository as recently used and evicts
+// repositories above the size limit. It is safe to call more than once.
+func (w *Worktree) Close() error {
+	var err error
+	w.once.Do(func() {
+		if w.Dir != "" {
+			err = os.RemoveAll(w.Dir)
+		}
+		// The modification time keeps the order of use for the next process
+		now := time.Now()
+		os.Chtimes(filepath.Join(w.cache.dir, repositoriesDir, w.key), now, now)
+		w.entry.RUnlock()
+
+		w.cache.mu.Lock()
+		w.entry.lastUsed = now
+		w.cache.mu.Unlock()
+		w.cache.release(w.key, w.entry)
+	})
+	return err
+}
+
+func (c *Cache) release(key string, e *entry) {
+	c.mu.Lock()
+	e.users--
+	c.mu.Unlock()
+	c.evict()
+}
+
+// evict removes the least recently used repositories that are not in use until the cache fits
+// into maxSize. The sizes measured after every fetch are summed, so nothing is walked, and the
+// repositories are removed without holding c.mu. Only one eviction runs at a time, a release
+// during an eviction skips it.
+func (c *Cache) evict() {
+	if !c.evicting.TryLock() {
+		return
+	}
+	defer c.evicting.Unlock()
+
+	type repository struct {
+		key   string
+		entry *entry
+		size  int64
+	}
+	var repositories []repository
+	var total int64
+	c.mu.Lock()
+	for key, e := range c.entries {
+		repositories = append(repositories, repository{key: key, entry: e, size: e.size})
+		total += e.size
+	}
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].entry.lastUsed.Before(repositories[j].entry.lastUsed)
+	})
+
+	// Victims are counted as users so that they are not evicted twice, checkouts of them wait
+	// for the write lock and clone the repository again
+	var victims []repository
+	for _, repository := range repositories {
+		if c.maxSize <= 0 || total <= c.maxSize {
+			break
+		}
+		if repository.entry.users > 0 {
+			continue
+		}
+		repository.entry.users++
+		victims = append(victims, repository)
+		total -= repository.size
+	}
+	c.mu.Unlock()
+
+	for _, victim := range victims {
+		e := victim.entry
+		e.Lock()
+		c.mu.Lock()
+		// A checkout started since the victim was chosen keeps it
+		inUse := e.users > 1
+		c.mu.Unlock()
+
+		var err error
+		if !inUse {
+			err = os.RemoveAll(filepath.Join(c.dir, repositoriesDir, victim.key))
+		}
+
+		c.mu.Lock()
+		if inUse || err != nil {
+			total += victim.size
+		} else {
+			e.size = 0
+			evictions.Inc()
+		}
+		e.users--
+		if e.users == 0 && e.size == 0 {
+			delete(c.entries, victim.key)
+		}
+		c.mu.Unlock()
+		e.Unlock()
+	}
+	cacheSize.Set(float64(total))
+}
+
+// repositoryKey names the directory of url, readable for operators but unique per URL
+func repositoryKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	name := strings.TrimSuffix(filepath.Base(strings.TrimRight(url, "/")), ".git")
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	return name + "-" + hex.EncodeToString(sum[:8])
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
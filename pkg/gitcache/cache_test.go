@@ -0,0 +1,274 @@
+package gitcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// upstream is a repository on disk standing in for the remote, cloned over the file transport
+type upstream struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+}
+
+func newUpstream(t *testing.T) *upstream {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	return &upstream{t: t, dir: dir, repo: repo}
+}
+
+func (u *upstream) commit(path, content string) plumbing.Hash {
+	u.t.Helper()
+	if err := os.WriteFile(filepath.Join(u.dir, path), []byte(content), 0644); err != nil {
+		u.t.Fatalf("WriteFile() error = %v", err)
+	}
+	w, _ := u.repo.Worktree()
+	if _, err := w.Add(path); err != nil {
+		u.t.Fatalf("Add() error = %v", err)
+	}
+	hash, err := w.Commit("Update "+path, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		u.t.Fatalf("Commit() error = %v", err)
+	}
+	return hash
+}
+
+func checkout(t *testing.T, c *Cache, url string) *Worktree {
+	t.Helper()
+	wt, err := c.Checkout(context.Background(), url, nil, false)
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	return wt
+}
+
+func TestCheckout(t *testing.T) {
+	remote := newUpstream(t)
+	first := remote.commit("config.txt", "replicas: 1\n")
+
+	c, err := New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	misses := testutil.ToFloat64(cacheRequests.WithLabelValues("miss"))
+	hits := testutil.ToFloat64(cacheRequests.WithLabelValues("hit"))
+
+	wt := checkout(t, c, remote.dir)
+	head, err := wt.Repository.Head()
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if head.Name() != plumbing.Master || head.Hash() != first {
+		t.Errorf("HEAD = %s at %s, want master at %s", head.Name(), head.Hash(), first)
+	}
+	if content, _ := os.ReadFile(filepath.Join(wt.Dir, "config.txt")); string(content) != "replicas: 1\n" {
+		t.Errorf("config.txt = %q", content)
+	}
+
+	// Commits made in the worktree must not leak into the cached repository
+	os.WriteFile(filepath.Join(wt.Dir, "local.txt"), []byte("local\n"), 0644)
+	w, _ := wt.Repository.Worktree()
+	w.Add("local.txt")
+	local, err := w.Commit("Local change", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := wt.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(wt.Dir); !os.IsNotExist(err) {
+		t.Errorf("Worktree %s still exists after Close()", wt.Dir)
+	}
+
+	second := remote.commit("config.txt", "replicas: 2\n")
+	wt = checkout(t, c, remote.dir)
+	defer wt.Close()
+	if head, _ := wt.Repository.Head(); head.Hash() != second {
+		t.Errorf("HEAD = %s, want %s after fetching", head.Hash(), second)
+	}
+	if _, err := wt.Repository.CommitObject(local); err == nil {
+		t.Error("Commit of a previous worktree is visible in the cache")
+	}
+	if ref, err := wt.Repository.Reference(plumbing.NewRemoteReferenceName("origin", "master"), true); err != nil || ref.Hash() != second {
+		t.Errorf("origin/master = %v, %v, want %s", ref, err, second)
+	}
+
+	if got := testutil.ToFloat64(cacheRequests.WithLabelValues("miss")) - misses; got != 1 {
+		t.Errorf("cache misses = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(cacheRequests.WithLabelValues("hit")) - hits; got != 1 {
+		t.Errorf("cache hits = %v, want 1", got)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	first, second := newUpstream(t), newUpstream(t)
+	first.commit("a.txt", "a\n")
+	second.commit("b.txt", "b\n")
+
+	dir := t.TempDir()
+	c, err := New(dir, 1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	inUse := checkout(t, c, first.dir)
+	checkout(t, c, second.dir).Close()
+
+	// The repository still checked out survives, the other one is evicted
+	if _, err := os.Stat(filepath.Join(dir, repositoriesDir, repositoryKey(first.dir))); err != nil {
+		t.Errorf("Repository in use was evicted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, repositoriesDir, repositoryKey(second.dir))); !os.IsNotExist(err) {
+		t.Errorf("Least recently used repository was not evicted: %v", err)
+	}
+
+	inUse.Close()
+	if entries, _ := os.ReadDir(filepath.Join(dir, repositoriesDir)); len(entries) != 0 {
+		t.Errorf("Repositories left after eviction: %v", entries)
+	}
+
+	// Evicted repositories are cloned again on the next checkout
+	wt := checkout(t, c, second.dir)
+	defer wt.Close()
+	if _, err := os.Stat(filepath.Join(wt.Dir, "b.txt")); err != nil {
+		t.Errorf("b.txt missing after checkout: %v", err)
+	}
+}
+
+func TestEvictionAfterRestart(t *testing.T) {
+	first, second := newUpstream(t), newUpstream(t)
+	first.commit("a.txt", "a\n")
+	second.commit("b.txt", "b\n")
+
+	dir := t.TempDir()
+	c, err := New(dir, 0)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	checkout(t, c, first.dir).Close()
+	size := c.entries[repositoryKey(first.dir)].size
+	if size <= 0 {
+		t.Fatalf("Size of the fetched repository = %d", size)
+	}
+
+	// A new process measures the repositories it finds and evicts them like its own
+	c, err = New(dir, size+1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := c.entries[repositoryKey(first.dir)].size; got != size {
+		t.Errorf("Size after restart = %d, want %d", got, size)
+	}
+	checkout(t, c, second.dir).Close()
+	if _, err := os.Stat(filepath.Join(dir, repositoriesDir, repositoryKey(first.dir))); !os.IsNotExist(err) {
+		t.Errorf("Repository of the previous process was not evicted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, repositoriesDir, repositoryKey(second.dir))); err != nil {
+		t.Errorf("Repository fitting into the cache was evicted: %v", err)
+	}
+}
+
+func TestConcurrentCheckouts(t *testing.T) {
+	upstreams := []*upstream{newUpstream(t), newUpstream(t)}
+	upstreams[0].commit("a.txt", "a\n")
+	upstreams[1].commit("b.txt", "b\n")
+
+	c, err := New(t.TempDir(), 1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(u *upstream) {
+			defer wg.Done()
+			wt, err := c.Checkout(context.Background(), u.dir, nil, false)
+			if err != nil {
+				errs <- err
+				return
+			}
+			wt.Close()
+		}(upstreams[i%2])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Checkout() error = %v", err)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	other := plumbing.NewHash("89abcdef0123456789abcdef0123456789abcdef")
+
+	tests := []struct {
+		name       string
+		advertised []*plumbing.Reference
+		want       plumbing.ReferenceName
+	}{
+		{
+			name: "symbolic HEAD",
+			advertised: []*plumbing.Reference{
+				plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/develop"),
+				plumbing.NewHashReference(plumbing.Main, hash),
+			},
+			want: "refs/heads/develop",
+		},
+		{
+			name: "HEAD hash prefers main",
+			advertised: []*plumbing.Reference{
+				plumbing.NewHashReference(plumbing.HEAD, hash),
+				plumbing.NewHashReference("refs/heads/release", hash),
+				plumbing.NewHashReference(plumbing.Master, hash),
+				plumbing.NewHashReference(plumbing.Main, hash),
+			},
+			want: plumbing.Main,
+		},
+		{
+			name: "HEAD hash matches a single branch",
+			advertised: []*plumbing.Reference{
+				plumbing.NewHashReference(plumbing.HEAD, hash),
+				plumbing.NewHashReference(plumbing.Main, other),
+				plumbing.NewHashReference("refs/heads/trunk", hash),
+			},
+			want: "refs/heads/trunk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := defaultBranch(tt.advertised)
+			if err != nil {
+				t.Fatalf("defaultBranch() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("defaultBranch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := defaultBranch(nil); err == nil {
+		t.Error("Expected an error without HEAD")
+	}
+}
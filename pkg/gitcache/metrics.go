@@ -0,0 +1,44 @@
+package gitcache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	cacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gitchange_repository_cache_requests_total",
+			Help: "Total number of repository checkouts from the cache, by whether the repository was cached (hit) or cloned (miss)",
+		},
+		[]string{"result"},
+	)
+
+	fetchedBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gitchange_repository_cache_fetched_bytes_total",
+			Help: "Total number of bytes fetched into the repository cache",
+		},
+	)
+
+	cacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gitchange_repository_cache_size_bytes",
+			Help: "Size of the cached bare repositories in bytes",
+		},
+	)
+
+	evictions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gitchange_repository_cache_evictions_total",
+			Help: "Total number of repositories evicted from the cache to stay below its maximum size",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(cacheRequests)
+	metrics.Registry.MustRegister(fetchedBytes)
+	metrics.Registry.MustRegister(cacheSize)
+	metrics.Registry.MustRegister(evictions)
+}
@@ -0,0 +1,388 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// bitbucketServerProvider talks to the Bitbucket Server (Data Center) REST API 1.0.
+// HTTP access tokens and personal access tokens are sent as bearer tokens.
+type bitbucketServerProvider struct {
+	restClient
+	project string
+	repo    string
+
+	// buildStatus talks to the build status API, which lives next to the core REST API
+	buildStatus restClient
+}
+
+func newBitbucketServerProvider(repo *Repository, opts Options) (Provider, error) {
+	// HTTPS clone URLs look like https://host[/context]/scm/PROJECT/repo.git,
+	// SSH clone URLs like ssh://git@host:7999/PROJECT/repo.git
+	contextPath := ""
+	repoPath := repo.Path
+	if i := strings.Index("/"+repoPath, "/scm/"); i >= 0 {
+		contextPath = strings.TrimSuffix(repoPath[:i], "/")
+		repoPath = repoPath[i+len("scm/"):]
+	}
+
+	parts := strings.Split(repoPath, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid Bitbucket Server repository path %q: expected [scm/]<project>/<repo>", repo.Path)
+	}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = repo.WebURL()
+		if contextPath != "" {
+			baseURL += "/" + contextPath
+		}
+		baseURL += "/rest/api/1.0"
+	}
+
+	authorize := func(req *http.Request, token string) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	return &bitbucketServerProvider{
+		restClient:  newRESTClient(opts, baseURL, authorize),
+		buildStatus: newRESTClient(opts, strings.TrimSuffix(baseURL, "/api/1.0")+"/build-status/1.0", authorize),
+		project:     url.PathEscape(parts[0]),
+		repo:        url.PathEscape(parts[1]),
+	}, nil
+}
+
+type bitbucketServerRef struct {
+	ID           string `json:"id"`
+	LatestCommit string `json:"latestCommit,omitempty"`
+}
+
+type bitbucketServerPullRequest struct {
+	ID          int                `json:"id"`
+	Version     int                `json:"version"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	State       string             `json:"state"`
+	FromRef     bitbucketServerRef `json:"fromRef"`
+	ToRef       bitbucketServerRef `json:"toRef"`
+	Properties  struct {
+		MergeCommit *struct {
+			ID string `json:"id"`
+		} `json:"mergeCommit"`
+	} `json:"properties"`
+	// Reviewers are sent back unchanged on update so they are not removed
+	Reviewers []json.RawMessage `json:"reviewers"`
+	Links     struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+func (p *bitbucketServerProvider) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]interface{}{
+		"title":       opts.Title,
+		"description": opts.Body,
+		"fromRef":     bitbucketServerRef{ID: plumbing.NewBranchReferenceName(opts.Head).String()},
+		"toRef":       bitbucketServerRef{ID: plumbing.NewBranchReferenceName(opts.Base).String()},
+	}
+	// Drafts need Bitbucket Server 8.18 or later, older versions ignore the flag
+	if opts.Draft {
+		body["draft"] = true
+	}
+
+	var pr bitbucketServerPullRequest
+	if err := p.do(ctx, http.MethodPost, p.repoPath()+"/pull-requests", body, &pr); err != nil {
+		return nil, fmt.Errorf("failed to create Bitbucket Server pull request: %w", err)
+	}
+
+	return pr.toPullRequest(), nil
+}
+
+func (pr *bitbucketServerPullRequest) toPullRequest() *PullRequest {
+	result := &PullRequest{Number: pr.ID}
+	if len(pr.Links.Self) > 0 {
+		result.URL = pr.Links.Self[0].Href
+	}
+	return result
+}
+
+type bitbucketServerPage struct {
+	Values        []bitbucketServerPullRequest `json:"values"`
+	IsLastPage    bool                         `json:"isLastPage"`
+	NextPageStart int                          `json:"nextPageStart"`
+}
+
+func (p *bitbucketServerProvider) FindOpenPullRequest(ctx context.Context, head, base string) (*PullRequest, error) {
+	baseRef := plumbing.NewBranchReferenceName(base).String()
+	query := url.Values{
+		"state":     {"OPEN"},
+		"direction": {"OUTGOING"},
+		"at":        {plumbing.NewBranchReferenceName(head).String()},
+	}
+
+	for start := 0; ; {
+		query.Set("start", strconv.Itoa(start))
+
+		var page bitbucketServerPage
+		if err := p.do(ctx, http.MethodGet, p.repoPath()+"/pull-requests?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list Bitbucket Server pull requests: %w", err)
+		}
+
+		for _, pr := range page.Values {
+			if pr.ToRef.ID == baseRef {
+				return pr.toPullRequest(), nil
+			}
+		}
+
+		if page.IsLastPage || len(page.Values) == 0 {
+			return nil, nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+func (p *bitbucketServerProvider) UpdatePullRequest(ctx context.Context, number int, opts PullRequestOptions) (*PullRequest, error) {
+	path := fmt.Sprintf("%s/pull-requests/%d", p.repoPath(), number)
+
+	// Updates are rejected unless they carry the current version of the pull request
+	var current bitbucketServerPullRequest
+	if err := p.do(ctx, http.MethodGet, path, nil, &current); err != nil {
+		return nil, fmt.Errorf("failed to get Bitbucket Server pull request #%d: %w", number, err)
+	}
+
+	body := map[string]interface{}{
+		"version":     current.Version,
+		"title":       opts.Title,
+		"description": opts.Body,
+		"reviewers":   current.Reviewers,
+	}
+
+	var pr bitbucketServerPullRequest
+	if err := p.do(ctx, http.MethodPut, path, body, &pr); err != nil {
+		return nil, fmt.Errorf("failed to update Bitbucket Server pull request #%d: %w", number, err)
+	}
+
+	return pr.toPullRequest(), nil
+}
+
+func (p *bitbucketServerProvider) repoPath() string {
+	return fmt.Sprintf("/projects/%s/repos/%s", p.project, p.repo)
+}
+
+type bitbucketServerReviewer struct {
+	Status string `json:"status"`
+	User   struct {
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+type bitbucketServerMergeStatus struct {
+	CanMerge   bool `json:"canMerge"`
+	Conflicted bool `json:"conflicted"`
+}
+
+type bitbucketServerBuildStatuses struct {
+	Values []struct {
+		State string `json:"state"`
+	} `json:"values"`
+}
+
+func (p *bitbucketServerProvider) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	path := fmt.Sprintf("%s/pull-requests/%d", p.repoPath(), number)
+
+	var pr bitbucketServerPullRequest
+	if err := p.do(ctx, http.MethodGet, path, nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get Bitbucket Server pull request #%d: %w", number, err)
+	}
+
+	result := pr.toPullRequest()
+	result.State = StateOpen
+	result.HeadSHA = pr.FromRef.LatestCommit
+	switch pr.State {
+	case "MERGED":
+		result.State = StateMerged
+		if pr.Properties.MergeCommit != nil {
+			result.MergeCommitSHA = pr.Properties.MergeCommit.ID
+		}
+	case "DECLINED":
+		result.State = StateClosed
+	}
+
+	latest := make(map[string]ReviewDecision)
+	pendingReviewers := false
+	for _, raw := range pr.Reviewers {
+		var reviewer bitbucketServerReviewer
+		if err := json.Unmarshal(raw, &reviewer); err != nil {
+			return nil, err
+		}
+		switch reviewer.Status {
+		case "APPROVED":
+			latest[reviewer.User.Name] = ReviewApproved
+		case "NEEDS_WORK":
+			latest[reviewer.User.Name] = ReviewChangesRequested
+		default:
+			pendingReviewers = true
+		}
+	}
+	result.ReviewDecision = reviewDecision(latest, pendingReviewers)
+
+	// The merge endpoint is only meaningful for open pull requests
+	if result.State == StateOpen {
+		var merge bitbucketServerMergeStatus
+		if err := p.do(ctx, http.MethodGet, path+"/merge", nil, &merge); err != nil {
+			return nil, fmt.Errorf("failed to get merge status of Bitbucket Server pull request #%d: %w", number, err)
+		}
+		switch {
+		case merge.Conflicted:
+			result.Mergeable = MergeableConflicting
+		case merge.CanMerge:
+			result.Mergeable = MergeableMergeable
+		default:
+			result.Mergeable = MergeableBlocked
+		}
+	}
+
+	if result.HeadSHA != "" {
+		var builds bitbucketServerBuildStatuses
+		if err := p.buildStatus.do(ctx, http.MethodGet, "/commits/"+result.HeadSHA, nil, &builds); err != nil {
+			return nil, fmt.Errorf("failed to get build status of %s: %w", result.HeadSHA, err)
+		}
+		statuses := make([]CheckStatus, 0, len(builds.Values))
+		for _, build := range builds.Values {
+			switch build.State {
+			case "SUCCESSFUL":
+				statuses = append(statuses, CheckSuccess)
+			case "INPROGRESS":
+				statuses = append(statuses, CheckPending)
+			default:
+				statuses = append(statuses, CheckFailure)
+			}
+		}
+		result.CheckStatus = combineCheckStatus(statuses...)
+	}
+
+	return result, nil
+}
+
+func (p *bitbucketServerProvider) ApplyMetadata(ctx context.Context, number int, metadata PullRequestMetadata) error {
+	var errs []error
+	if len(metadata.Labels) > 0 {
+		errs = append(errs, errUnsupported("Bitbucket Server", "labels"))
+	}
+	if len(metadata.TeamReviewers) > 0 {
+		errs = append(errs, errUnsupported("Bitbucket Server", "team reviewers"))
+	}
+	if len(metadata.Assignees) > 0 {
+		errs = append(errs, errUnsupported("Bitbucket Server", "assignees"))
+	}
+	if metadata.Milestone != "" {
+		errs = append(errs, errUnsupported("Bitbucket Server", "milestones"))
+	}
+
+	if len(metadata.Reviewers) > 0 {
+		if err := p.addReviewers(ctx, number, metadata.Reviewers); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return joinMetadataErrors(errs)
+}
+
+// addReviewers extends the reviewers of a pull request, which can only be replaced as a whole
+func (p *bitbucketServerProvider) addReviewers(ctx context.Context, number int, usernames []string) error {
+	path := fmt.Sprintf("%s/pull-requests/%d", p.repoPath(), number)
+
+	var current bitbucketServerPullRequest
+	if err := p.do(ctx, http.MethodGet, path, nil, &current); err != nil {
+		return fmt.Errorf("failed to get pull request #%d: %w", number, err)
+	}
+
+	reviewers := current.Reviewers
+	existing := make(map[string]bool, len(reviewers))
+	for _, raw := range reviewers {
+		var reviewer bitbucketServerReviewer
+		if err := json.Unmarshal(raw, &reviewer); err == nil {
+			existing[reviewer.User.Name] = true
+		}
+	}
+	for _, username := range usernames {
+		if existing[username] {
+			continue
+		}
+		raw, err := json.Marshal(map[string]interface{}{"user": map[string]string{"name": username}})
+		if err != nil {
+			return err
+		}
+		reviewers = append(reviewers, raw)
+	}
+
+	body := map[string]interface{}{
+		"version":     current.Version,
+		"title":       current.Title,
+		"description": current.Description,
+		"reviewers":   reviewers,
+	}
+	if err := p.do(ctx, http.MethodPut, path, body, nil); err != nil {
+		return fmt.Errorf("failed to add reviewers: %w", err)
+	}
+
+	return nil
+}
+
+// bitbucketServerMergeStrategies maps merge methods to the strategy IDs of Bitbucket Server
+var bitbucketServerMergeStrategies = map[MergeMethod]string{
+	MergeMethodMerge:  "no-ff",
+	MergeMethodSquash: "squash",
+	MergeMethodRebase: "rebase-no-ff",
+}
+
+func (p *bitbucketServerProvider) MergePullRequest(ctx context.Context, number int, opts MergeOptions) error {
+	if opts.Auto {
+		return fmt.Errorf("native auto-merge is not supported by Bitbucket Server")
+	}
+
+	path := fmt.Sprintf("%s/pull-requests/%d", p.repoPath(), number)
+
+	// Merges are rejected unless they carry the current version of the pull request
+	var current bitbucketServerPullRequest
+	if err := p.do(ctx, http.MethodGet, path, nil, &current); err != nil {
+		return fmt.Errorf("failed to get Bitbucket Server pull request #%d: %w", number, err)
+	}
+	if opts.SHA != "" && current.FromRef.LatestCommit != opts.SHA {
+		return fmt.Errorf("head of Bitbucket Server pull request #%d moved to %s", number, current.FromRef.LatestCommit)
+	}
+
+	body := map[string]interface{}{
+		"strategyId": bitbucketServerMergeStrategies[opts.Method],
+	}
+	if err := p.do(ctx, http.MethodPost, fmt.Sprintf("%s/merge?version=%d", path, current.Version), body, nil); err != nil {
+		return fmt.Errorf("failed to merge Bitbucket Server pull request #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// ClosePullRequest declines the pull request, Bitbucket Server's equivalent of closing it
+func (p *bitbucketServerProvider) ClosePullRequest(ctx context.Context, number int) error {
+	path := fmt.Sprintf("%s/pull-requests/%d", p.repoPath(), number)
+
+	var current bitbucketServerPullRequest
+	if err := p.do(ctx, http.MethodGet, path, nil, &current); err != nil {
+		return fmt.Errorf("failed to get Bitbucket Server pull request #%d: %w", number, err)
+	}
+
+	if err := p.do(ctx, http.MethodPost, fmt.Sprintf("%s/decline?version=%d", path, current.Version), map[string]interface{}{}, nil); err != nil {
+		return fmt.Errorf("failed to decline Bitbucket Server pull request #%d: %w", number, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestBitbucketServerCreatePullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer bbs-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/config/pull-requests" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var body struct {
+			Title   string             `json:"title"`
+			FromRef bitbucketServerRef `json:"fromRef"`
+			ToRef   bitbucketServerRef `json:"toRef"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.FromRef.ID == body.ToRef.ID {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"errors":[{"message":"Only one pull request may be open for a given source and target branch"}]}`))
+			return
+		}
+		if body.FromRef.ID != "refs/heads/update" || body.ToRef.ID != "refs/heads/main" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":12,"links":{"self":[{"href":"https://bitbucket.example.com/projects/PROJ/repos/config/pull-requests/12"}]}}`))
+	}))
+	defer server.Close()
+
+	provider, err := New("https://bitbucket.example.com/scm/PROJ/config.git", Options{
+		TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "bbs-token"}),
+		BaseURL:     server.URL + "/rest/api/1.0",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	pr, err := provider.CreatePullRequest(context.Background(), PullRequestOptions{Title: "Update", Head: "update", Base: "main"})
+	if err != nil {
+		t.Fatalf("CreatePullRequest() error = %v", err)
+	}
+	if pr.Number != 12 || pr.URL != "https://bitbucket.example.com/projects/PROJ/repos/config/pull-requests/12" {
+		t.Errorf("Got #%d %s", pr.Number, pr.URL)
+	}
+
+	_, err = provider.CreatePullRequest(context.Background(), PullRequestOptions{Title: "Update", Head: "main", Base: "main"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || !strings.Contains(apiErr.Message, "Only one pull request") {
+		t.Errorf("Expected a conflict error with the server message, got %v", err)
+	}
+}
+
+func TestBitbucketServerBaseURL(t *testing.T) {
+	tests := []struct {
+		url         string
+		wantBaseURL string
+		wantProject string
+		wantRepo    string
+	}{
+		{
+			url:         "https://bitbucket.example.com/scm/PROJ/config.git",
+			wantBaseURL: "https://bitbucket.example.com/rest/api/1.0", wantProject: "PROJ", wantRepo: "config",
+		},
+		{
+			url:         "https://git.example.com/bitbucket/scm/~jdoe/config.git",
+			wantBaseURL: "https://git.example.com/bitbucket/rest/api/1.0", wantProject: "~jdoe", wantRepo: "config",
+		},
+		{
+			url:         "ssh://git@bitbucket.example.com:7999/proj/config.git",
+			wantBaseURL: "https://bitbucket.example.com/rest/api/1.0", wantProject: "proj", wantRepo: "config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			provider, err := New(tt.url, Options{})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			bbs := provider.(*bitbucketServerProvider)
+			if bbs.baseURL != tt.wantBaseURL || bbs.project != tt.wantProject || bbs.repo != tt.wantRepo {
+				t.Errorf("Got %s %s/%s", bbs.baseURL, bbs.project, bbs.repo)
+			}
+		})
+	}
+}
+
+func TestBitbucketServerFindAndUpdatePullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/rest/api/1.0/projects/PROJ/repos/config/pull-requests"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == prefix:
+			// The first page only holds a pull request into another target branch
+			if r.URL.Query().Get("at") != "refs/heads/update" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if r.URL.Query().Get("start") == "0" {
+				w.Write([]byte(`{"values":[{"id":3,"toRef":{"id":"refs/heads/release"}}],"isLastPage":false,"nextPageStart":1}`))
+				return
+			}
+			w.Write([]byte(`{"values":[{"id":4,"toRef":{"id":"refs/heads/main"},"links":{"self":[{"href":"https://bitbucket.example.com/pr/4"}]}}],"isLastPage":true}`))
+		case r.Method == http.MethodGet && r.URL.Path == prefix+"/4":
+			w.Write([]byte(`{"id":4,"version":7,"reviewers":[{"user":{"name":"alice"}}]}`))
+		case r.Method == http.MethodPut && r.URL.Path == prefix+"/4":
+			var body struct {
+				Version   int               `json:"version"`
+				Title     string            `json:"title"`
+				Reviewers []json.RawMessage `json:"reviewers"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Version != 7 {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"errors":[{"message":"out of date version"}]}`))
+				return
+			}
+			if body.Title != "New title" || len(body.Reviewers) != 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id":4,"version":8,"links":{"self":[{"href":"https://bitbucket.example.com/pr/4"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://bitbucket.example.com/scm/PROJ/config.git", Options{BaseURL: server.URL + "/rest/api/1.0"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	existing, err := provider.FindOpenPullRequest(context.Background(), "update", "main")
+	if err != nil || existing == nil || existing.Number != 4 {
+		t.Fatalf("Expected pull request #4, got %v, %v", existing, err)
+	}
+
+	updated, err := provider.UpdatePullRequest(context.Background(), 4, PullRequestOptions{Title: "New title"})
+	if err != nil {
+		t.Fatalf("UpdatePullRequest() error = %v", err)
+	}
+	if updated.URL != "https://bitbucket.example.com/pr/4" {
+		t.Errorf("URL = %s", updated.URL)
+	}
+}
+
+func TestBitbucketServerGetPullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/1.0/projects/PROJ/repos/config/pull-requests/3":
+			w.Write([]byte(`{"id":3,"version":1,"state":"OPEN","fromRef":{"id":"refs/heads/feature","latestCommit":"def456"},
+				"reviewers":[{"status":"NEEDS_WORK","user":{"name":"alice"}},{"status":"APPROVED","user":{"name":"bob"}}],
+				"links":{"self":[{"href":"https://bitbucket.example.com/projects/PROJ/repos/config/pull-requests/3"}]}}`))
+		case "/rest/api/1.0/projects/PROJ/repos/config/pull-requests/3/merge":
+			w.Write([]byte(`{"canMerge":false,"conflicted":false}`))
+		case "/rest/build-status/1.0/commits/def456":
+			w.Write([]byte(`{"values":[{"state":"SUCCESSFUL"},{"state":"INPROGRESS"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://bitbucket.example.com/scm/PROJ/config.git", Options{BaseURL: server.URL + "/rest/api/1.0"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	pr, err := provider.GetPullRequest(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+
+	want := PullRequest{
+		Number:         3,
+		URL:            "https://bitbucket.example.com/projects/PROJ/repos/config/pull-requests/3",
+		State:          StateOpen,
+		HeadSHA:        "def456",
+		Mergeable:      MergeableBlocked,
+		ReviewDecision: ReviewChangesRequested,
+		CheckStatus:    CheckPending,
+	}
+	if *pr != want {
+		t.Errorf("GetPullRequest() = %+v, want %+v", *pr, want)
+	}
+}
+
+func TestBitbucketServerApplyMetadata(t *testing.T) {
+	var update struct {
+		Version   int               `json:"version"`
+		Title     string            `json:"title"`
+		Reviewers []json.RawMessage `json:"reviewers"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/config/pull-requests/3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"id":3,"version":2,"title":"Update config","reviewers":[{"user":{"name":"alice"},"status":"APPROVED"}]}`))
+		case http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&update)
+			w.Write([]byte(`{"id":3,"version":3}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://bitbucket.example.com/scm/PROJ/config.git", Options{BaseURL: server.URL + "/rest/api/1.0"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = provider.ApplyMetadata(context.Background(), 3, PullRequestMetadata{
+		Labels:    []string{"automated"},
+		Reviewers: []string{"alice", "bob"},
+	})
+	if err == nil || err.Error() != "labels are not supported by Bitbucket Server" {
+		t.Fatalf("Expected only the labels to fail, got %v", err)
+	}
+
+	// The existing reviewer keeps its approval, the new one is appended
+	if update.Version != 2 || update.Title != "Update config" || len(update.Reviewers) != 2 {
+		t.Fatalf("Unexpected update: %+v", update)
+	}
+	if !strings.Contains(string(update.Reviewers[0]), "APPROVED") || string(update.Reviewers[1]) != `{"user":{"name":"bob"}}` {
+		t.Errorf("Unexpected reviewers: %s, %s", update.Reviewers[0], update.Reviewers[1])
+	}
+}
+
+func TestBitbucketServerMergePullRequest(t *testing.T) {
+	var version string
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/1.0/projects/PROJ/repos/config/pull-requests/3":
+			w.Write([]byte(`{"id":3,"version":4,"fromRef":{"latestCommit":"abc123"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/1.0/projects/PROJ/repos/config/pull-requests/3/merge":
+			version = r.URL.Query().Get("version")
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"id":3,"state":"MERGED"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://bitbucket.example.com/scm/PROJ/config.git", Options{BaseURL: server.URL + "/rest/api/1.0"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := provider.MergePullRequest(context.Background(), 3, MergeOptions{Method: MergeMethodSquash, SHA: "abc123"}); err != nil {
+		t.Fatalf("MergePullRequest() error = %v", err)
+	}
+	if version != "4" || body["strategyId"] != "squash" {
+		t.Errorf("Got version=%s body=%v", version, body)
+	}
+
+	// A head that moved on since the checks ran must not be merged
+	body = nil
+	if err := provider.MergePullRequest(context.Background(), 3, MergeOptions{SHA: "def456"}); err == nil || body != nil {
+		t.Errorf("Expected the stale head to be rejected, got err=%v body=%v", err, body)
+	}
+
+	if err := provider.MergePullRequest(context.Background(), 3, MergeOptions{Auto: true}); err == nil {
+		t.Error("Expected native auto-merge to be rejected")
+	}
+}
+
+func TestBitbucketServerClosePullRequest(t *testing.T) {
+	var version string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/1.0/projects/PROJ/repos/config/pull-requests/3":
+			w.Write([]byte(`{"id":3,"version":6}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/1.0/projects/PROJ/repos/config/pull-requests/3/decline":
+			version = r.URL.Query().Get("version")
+			w.Write([]byte(`{"id":3,"state":"DECLINED"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://bitbucket.example.com/scm/PROJ/config.git", Options{BaseURL: server.URL + "/rest/api/1.0"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := provider.ClosePullRequest(context.Background(), 3); err != nil {
+		t.Fatalf("ClosePullRequest() error = %v", err)
+	}
+	if version != "6" {
+		t.Errorf("Declined with version %q, want 6", version)
+	}
+}
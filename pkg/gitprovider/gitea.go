@@ -0,0 +1,298 @@
+package gitprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// giteaProvider talks to the Gitea API v1, which Forgejo serves unchanged
+type giteaProvider struct {
+	restClient
+	owner string
+	repo  string
+}
+
+func newGiteaProvider(repo *Repository, opts Options) (Provider, error) {
+	if strings.Contains(repo.Owner(), "/") {
+		return nil, fmt.Errorf("invalid Gitea repository path %q: expected <owner>/<name>", repo.Path)
+	}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = repo.WebURL() + "/api/v1"
+	}
+
+	return &giteaProvider{
+		restClient: newRESTClient(opts, baseURL, func(req *http.Request, token string) {
+			req.Header.Set("Authorization", "token "+token)
+		}),
+		owner: url.PathEscape(repo.Owner()),
+		repo:  url.PathEscape(repo.Name()),
+	}, nil
+}
+
+type giteaPullRequest struct {
+	Number         int    `json:"number"`
+	HTMLURL        string `json:"html_url"`
+	State          string `json:"state"`
+	Merged         bool   `json:"merged"`
+	MergeCommitSHA string `json:"merge_commit_sha"`
+	Mergeable      bool   `json:"mergeable"`
+	Head           struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Assignees []struct {
+		Login string `json:"login"`
+	} `json:"assignees"`
+}
+
+// giteaPageLimit is the page size used when listing pull requests
+const giteaPageLimit = 50
+
+// giteaTitle marks drafts with the default work in progress prefix, Gitea has no separate draft flag
+func giteaTitle(opts PullRequestOptions) string {
+	if opts.Draft && !strings.HasPrefix(strings.ToUpper(opts.Title), "WIP:") {
+		return "WIP: " + opts.Title
+	}
+	return opts.Title
+}
+
+func (p *giteaProvider) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]interface{}{
+		"head":  opts.Head,
+		"base":  opts.Base,
+		"title": giteaTitle(opts),
+		"body":  opts.Body,
+	}
+
+	var pr giteaPullRequest
+	if err := p.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls", p.owner, p.repo), body, &pr); err != nil {
+		return nil, fmt.Errorf("failed to create Gitea pull request: %w", err)
+	}
+
+	return &PullRequest{Number: pr.Number, URL: pr.HTMLURL}, nil
+}
+
+func (p *giteaProvider) FindOpenPullRequest(ctx context.Context, head, base string) (*PullRequest, error) {
+	// The list endpoint cannot filter by branch, so walk the open pull requests page by page
+	for page := 1; ; page++ {
+		var prs []giteaPullRequest
+		path := fmt.Sprintf("/repos/%s/%s/pulls?state=open&limit=%d&page=%d", p.owner, p.repo, giteaPageLimit, page)
+		if err := p.do(ctx, http.MethodGet, path, nil, &prs); err != nil {
+			return nil, fmt.Errorf("failed to list Gitea pull requests: %w", err)
+		}
+
+		for _, pr := range prs {
+			if pr.Head.Ref == head && pr.Base.Ref == base {
+				return &PullRequest{Number: pr.Number, URL: pr.HTMLURL}, nil
+			}
+		}
+
+		if len(prs) < giteaPageLimit {
+			return nil, nil
+		}
+	}
+}
+
+func (p *giteaProvider) UpdatePullRequest(ctx context.Context, number int, opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]interface{}{
+		"title": giteaTitle(opts),
+		"body":  opts.Body,
+	}
+
+	var pr giteaPullRequest
+	if err := p.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/%s/pulls/%d", p.owner, p.repo, number), body, &pr); err != nil {
+		return nil, fmt.Errorf("failed to update Gitea pull request #%d: %w", number, err)
+	}
+
+	return &PullRequest{Number: pr.Number, URL: pr.HTMLURL}, nil
+}
+
+type giteaReview struct {
+	State     string `json:"state"`
+	Dismissed bool   `json:"dismissed"`
+	Stale     bool   `json:"stale"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+type giteaCombinedStatus struct {
+	State      string `json:"state"`
+	TotalCount int    `json:"total_count"`
+}
+
+func (p *giteaProvider) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d", p.owner, p.repo, number)
+
+	var pr giteaPullRequest
+	if err := p.do(ctx, http.MethodGet, path, nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get Gitea pull request #%d: %w", number, err)
+	}
+
+	result := &PullRequest{
+		Number:    pr.Number,
+		URL:       pr.HTMLURL,
+		State:     StateOpen,
+		HeadSHA:   pr.Head.SHA,
+		Mergeable: MergeableConflicting,
+	}
+	switch {
+	case pr.Merged:
+		result.State = StateMerged
+		result.MergeCommitSHA = pr.MergeCommitSHA
+	case pr.State == "closed":
+		result.State = StateClosed
+	}
+	// Gitea only reports whether the branches merge cleanly
+	if pr.Mergeable {
+		result.Mergeable = MergeableMergeable
+	}
+
+	var reviews []giteaReview
+	if err := p.do(ctx, http.MethodGet, path+"/reviews", nil, &reviews); err != nil {
+		return nil, fmt.Errorf("failed to list reviews of Gitea pull request #%d: %w", number, err)
+	}
+	latest := make(map[string]ReviewDecision)
+	pendingReviewers := false
+	for _, review := range reviews {
+		if review.Dismissed || review.Stale {
+			continue
+		}
+		switch review.State {
+		case "APPROVED":
+			latest[review.User.Login] = ReviewApproved
+		case "REQUEST_CHANGES":
+			latest[review.User.Login] = ReviewChangesRequested
+		case "REQUEST_REVIEW":
+			pendingReviewers = true
+		}
+	}
+	result.ReviewDecision = reviewDecision(latest, pendingReviewers)
+
+	if result.HeadSHA != "" {
+		var status giteaCombinedStatus
+		if err := p.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/commits/%s/status", p.owner, p.repo, result.HeadSHA), nil, &status); err != nil {
+			return nil, fmt.Errorf("failed to get commit status of %s: %w", result.HeadSHA, err)
+		}
+		if status.TotalCount > 0 {
+			switch status.State {
+			case "success", "warning":
+				result.CheckStatus = CheckSuccess
+			case "pending":
+				result.CheckStatus = CheckPending
+			default:
+				result.CheckStatus = CheckFailure
+			}
+		}
+	}
+
+	return result, nil
+}
+
+type giteaMilestone struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+func (p *giteaProvider) ApplyMetadata(ctx context.Context, number int, metadata PullRequestMetadata) error {
+	var errs []error
+
+	// Labels, assignees and milestones live on the issue backing the pull request
+	issuePath := fmt.Sprintf("/repos/%s/%s/issues/%d", p.owner, p.repo, number)
+
+	if len(metadata.Labels) > 0 {
+		if err := p.do(ctx, http.MethodPost, issuePath+"/labels", map[string]interface{}{"labels": metadata.Labels}, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add labels: %w", err))
+		}
+	}
+
+	edit := map[string]interface{}{}
+
+	// Assignees are replaced as a whole, so extend the current ones
+	if len(metadata.Assignees) > 0 {
+		var current giteaPullRequest
+		if err := p.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls/%d", p.owner, p.repo, number), nil, &current); err != nil {
+			errs = append(errs, fmt.Errorf("failed to get pull request #%d: %w", number, err))
+		} else {
+			assignees := make([]string, 0, len(current.Assignees)+len(metadata.Assignees))
+			for _, assignee := range current.Assignees {
+				assignees = append(assignees, assignee.Login)
+			}
+			for _, assignee := range metadata.Assignees {
+				if !slices.Contains(assignees, assignee) {
+					assignees = append(assignees, assignee)
+				}
+			}
+			edit["assignees"] = assignees
+		}
+	}
+
+	if metadata.Milestone != "" {
+		query := url.Values{"name": {metadata.Milestone}, "state": {"all"}}
+		var milestones []giteaMilestone
+		if err := p.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/milestones?%s", p.owner, p.repo, query.Encode()), nil, &milestones); err != nil {
+			errs = append(errs, fmt.Errorf("failed to list milestones: %w", err))
+		} else if i := slices.IndexFunc(milestones, func(m giteaMilestone) bool { return m.Title == metadata.Milestone }); i < 0 {
+			errs = append(errs, fmt.Errorf("milestone %q not found", metadata.Milestone))
+		} else {
+			edit["milestone"] = milestones[i].ID
+		}
+	}
+
+	if len(edit) > 0 {
+		if err := p.do(ctx, http.MethodPatch, issuePath, edit, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update pull request #%d: %w", number, err))
+		}
+	}
+
+	if len(metadata.Reviewers) > 0 || len(metadata.TeamReviewers) > 0 {
+		body := map[string]interface{}{
+			"reviewers":      metadata.Reviewers,
+			"team_reviewers": metadata.TeamReviewers,
+		}
+		if err := p.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls/%d/requested_reviewers", p.owner, p.repo, number), body, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to request reviewers: %w", err))
+		}
+	}
+
+	return joinMetadataErrors(errs)
+}
+
+func (p *giteaProvider) MergePullRequest(ctx context.Context, number int, opts MergeOptions) error {
+	body := map[string]interface{}{
+		"Do": string(opts.Method),
+	}
+	if opts.SHA != "" {
+		body["head_commit_id"] = opts.SHA
+	}
+	if opts.Auto {
+		body["merge_when_checks_succeed"] = true
+	}
+
+	if err := p.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls/%d/merge", p.owner, p.repo, number), body, nil); err != nil {
+		return fmt.Errorf("failed to merge Gitea pull request #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+func (p *giteaProvider) ClosePullRequest(ctx context.Context, number int) error {
+	body := map[string]interface{}{
+		"state": "closed",
+	}
+
+	if err := p.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/%s/pulls/%d", p.owner, p.repo, number), body, nil); err != nil {
+		return fmt.Errorf("failed to close Gitea pull request #%d: %w", number, err)
+	}
+
+	return nil
+}
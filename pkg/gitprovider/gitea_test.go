@@ -0,0 +1,213 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestGiteaCreatePullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token gitea-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"token is required"}`))
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/repos/org/repo/pulls" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["head"] != "feature" || body["base"] != "main" || body["title"] != "Add feature" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"invalid pull request"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number":7,"html_url":"https://gitea.internal/org/repo/pulls/7"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		token      string
+		wantNumber int
+		wantStatus int
+	}{
+		{name: "created", token: "gitea-token", wantNumber: 7},
+		{name: "unauthorized", token: "wrong", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := New("git@gitea.internal:org/repo.git", Options{
+				TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: tt.token}),
+				BaseURL:     server.URL + "/api/v1",
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			pr, err := provider.CreatePullRequest(context.Background(), PullRequestOptions{Title: "Add feature", Head: "feature", Base: "main"})
+			if tt.wantStatus != 0 {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+					t.Fatalf("Expected API error %d, got %v", tt.wantStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreatePullRequest() error = %v", err)
+			}
+			if pr.Number != tt.wantNumber || pr.URL != "https://gitea.internal/org/repo/pulls/7" {
+				t.Errorf("Got #%d %s", pr.Number, pr.URL)
+			}
+		})
+	}
+}
+
+func TestGiteaGetPullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/org/repo/pulls/7":
+			w.Write([]byte(`{"number":7,"html_url":"https://gitea.internal/org/repo/pulls/7","state":"open","mergeable":true,"head":{"ref":"feature","sha":"def456"}}`))
+		case "/api/v1/repos/org/repo/pulls/7/reviews":
+			w.Write([]byte(`[{"state":"APPROVED","user":{"login":"alice"}},{"state":"REQUEST_CHANGES","stale":true,"user":{"login":"bob"}}]`))
+		case "/api/v1/repos/org/repo/commits/def456/status":
+			w.Write([]byte(`{"state":"failure","total_count":2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitea.internal/org/repo.git", Options{BaseURL: server.URL + "/api/v1"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	pr, err := provider.GetPullRequest(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+
+	want := PullRequest{
+		Number:         7,
+		URL:            "https://gitea.internal/org/repo/pulls/7",
+		State:          StateOpen,
+		HeadSHA:        "def456",
+		Mergeable:      MergeableMergeable,
+		ReviewDecision: ReviewApproved,
+		CheckStatus:    CheckFailure,
+	}
+	if *pr != want {
+		t.Errorf("GetPullRequest() = %+v, want %+v", *pr, want)
+	}
+}
+
+func TestGiteaApplyMetadata(t *testing.T) {
+	var labels, reviewers, edit map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/repos/org/repo/issues/7/labels":
+			json.NewDecoder(r.Body).Decode(&labels)
+			w.Write([]byte(`[]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/org/repo/pulls/7":
+			w.Write([]byte(`{"number":7,"assignees":[{"login":"carol"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/org/repo/milestones":
+			w.Write([]byte(`[{"id":4,"title":"v1.0"}]`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/repos/org/repo/issues/7":
+			json.NewDecoder(r.Body).Decode(&edit)
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/repos/org/repo/pulls/7/requested_reviewers":
+			json.NewDecoder(r.Body).Decode(&reviewers)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitea.internal/org/repo.git", Options{BaseURL: server.URL + "/api/v1"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = provider.ApplyMetadata(context.Background(), 7, PullRequestMetadata{
+		Labels:        []string{"automated"},
+		Assignees:     []string{"alice", "carol"},
+		Milestone:     "v1.0",
+		Reviewers:     []string{"bob"},
+		TeamReviewers: []string{"platform"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyMetadata() error = %v", err)
+	}
+
+	got, _ := json.Marshal(map[string]interface{}{"labels": labels, "edit": edit, "reviewers": reviewers})
+	want := `{"edit":{"assignees":["carol","alice"],"milestone":4},"labels":{"labels":["automated"]},"reviewers":{"reviewers":["bob"],"team_reviewers":["platform"]}}`
+	if string(got) != want {
+		t.Errorf("Got %s\nwant %s", got, want)
+	}
+}
+
+func TestGiteaMergePullRequest(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/repos/org/repo/pulls/7/merge" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitea.internal/org/repo.git", Options{BaseURL: server.URL + "/api/v1"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = provider.MergePullRequest(context.Background(), 7, MergeOptions{Method: MergeMethodRebase, SHA: "abc123", Auto: true})
+	if err != nil {
+		t.Fatalf("MergePullRequest() error = %v", err)
+	}
+	got, _ := json.Marshal(body)
+	if want := `{"Do":"rebase","head_commit_id":"abc123","merge_when_checks_succeed":true}`; string(got) != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+}
+
+func TestGiteaClosePullRequest(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/api/v1/repos/org/repo/pulls/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"number":7,"state":"closed"}`))
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitea.internal/org/repo.git", Options{BaseURL: server.URL + "/api/v1"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := provider.ClosePullRequest(context.Background(), 7); err != nil {
+		t.Fatalf("ClosePullRequest() error = %v", err)
+	}
+	if body["state"] != "closed" {
+		t.Errorf("Unexpected edit: %v", body)
+	}
+}
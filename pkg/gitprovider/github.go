@@ -0,0 +1,376 @@
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+	"golang.org/x/oauth2"
+)
+
+type gitHubProvider struct {
+	client *github.Client
+	owner  string
+	repo   string
+}
+
+func newGitHubProvider(repo *Repository, opts Options) (Provider, error) {
+	if strings.Contains(repo.Owner(), "/") {
+		return nil, fmt.Errorf("invalid GitHub repository path %q: expected <owner>/<name>", repo.Path)
+	}
+
+	httpClient := opts.HTTPClient
+	if opts.TokenSource != nil {
+		httpClient = oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, opts.HTTPClient), opts.TokenSource)
+	}
+	client := github.NewClient(httpClient)
+
+	// GitHub Enterprise Server serves its API below /api/v3 on the repository host
+	baseURL := opts.BaseURL
+	if baseURL == "" && !strings.EqualFold(repo.Host, "github.com") {
+		baseURL = repo.WebURL() + "/api/v3/"
+	}
+	if baseURL != "" {
+		var err error
+		client, err = client.WithEnterpriseURLs(baseURL, baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %q: %w", baseURL, err)
+		}
+	}
+
+	return &gitHubProvider{client: client, owner: repo.Owner(), repo: repo.Name()}, nil
+}
+
+func (p *gitHubProvider) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (*PullRequest, error) {
+	newPR := &github.NewPullRequest{
+		Title:               github.String(opts.Title),
+		Head:                github.String(opts.Head),
+		Base:                github.String(opts.Base),
+		Body:                github.String(opts.Body),
+		MaintainerCanModify: github.Bool(true),
+		Draft:               github.Bool(opts.Draft),
+	}
+
+	pullRequest, _, err := p.client.PullRequests.Create(ctx, p.owner, p.repo, newPR)
+	if err != nil {
+		// Check for common GitHub API permission issues
+		if strings.Contains(err.Error(), "Resource not accessible by personal access token") {
+			return nil, fmt.Errorf("insufficient GitHub token permissions - token needs 'repo' and 'pull_requests:write' scopes to create pull requests: %w", err)
+		}
+		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Forbidden") {
+			return nil, fmt.Errorf("GitHub API access denied - check token permissions and repository access: %w", err)
+		}
+		return nil, fmt.Errorf("failed to create GitHub pull request: %w", err)
+	}
+
+	return &PullRequest{Number: pullRequest.GetNumber(), URL: pullRequest.GetHTMLURL()}, nil
+}
+
+func (p *gitHubProvider) FindOpenPullRequest(ctx context.Context, head, base string) (*PullRequest, error) {
+	pullRequests, _, err := p.client.PullRequests.List(ctx, p.owner, p.repo, &github.PullRequestListOptions{
+		State: "open",
+		// The head filter expects <owner>:<branch>
+		Head: p.owner + ":" + head,
+		Base: base,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GitHub pull requests: %w", err)
+	}
+	if len(pullRequests) == 0 {
+		return nil, nil
+	}
+
+	return &PullRequest{Number: pullRequests[0].GetNumber(), URL: pullRequests[0].GetHTMLURL()}, nil
+}
+
+func (p *gitHubProvider) UpdatePullRequest(ctx context.Context, number int, opts PullRequestOptions) (*PullRequest, error) {
+	pullRequest, _, err := p.client.PullRequests.Edit(ctx, p.owner, p.repo, number, &github.PullRequest{
+		Title: github.String(opts.Title),
+		Body:  github.String(opts.Body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update GitHub pull request #%d: %w", number, err)
+	}
+
+	return &PullRequest{Number: pullRequest.GetNumber(), URL: pullRequest.GetHTMLURL()}, nil
+}
+
+func (p *gitHubProvider) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	pr, _, err := p.client.PullRequests.Get(ctx, p.owner, p.repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub pull request #%d: %w", number, err)
+	}
+
+	result := &PullRequest{
+		Number:  pr.GetNumber(),
+		URL:     pr.GetHTMLURL(),
+		State:   StateOpen,
+		HeadSHA: pr.GetHead().GetSHA(),
+	}
+	switch {
+	case pr.GetMerged():
+		result.State = StateMerged
+		result.MergeCommitSHA = pr.GetMergeCommitSHA()
+	case pr.GetState() == "closed":
+		result.State = StateClosed
+	}
+
+	// https://docs.github.com/en/graphql/reference/enums#mergestatestatus
+	switch pr.GetMergeableState() {
+	case "clean", "unstable", "has_hooks":
+		result.Mergeable = MergeableMergeable
+	case "dirty":
+		result.Mergeable = MergeableConflicting
+	case "blocked", "behind", "draft":
+		result.Mergeable = MergeableBlocked
+	default:
+		result.Mergeable = MergeableUnknown
+	}
+
+	if result.ReviewDecision, err = p.reviewDecision(ctx, number, len(pr.RequestedReviewers)+len(pr.RequestedTeams) > 0); err != nil {
+		return nil, err
+	}
+	if result.CheckStatus, err = p.checkStatus(ctx, result.HeadSHA); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// reviewDecision mirrors the GraphQL reviewDecision field, which the REST API does not expose
+func (p *gitHubProvider) reviewDecision(ctx context.Context, number int, pendingReviewers bool) (ReviewDecision, error) {
+	latest := make(map[string]ReviewDecision)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := p.client.PullRequests.ListReviews(ctx, p.owner, p.repo, number, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to list reviews of GitHub pull request #%d: %w", number, err)
+		}
+		// Reviews are returned in chronological order, so later reviews replace earlier ones
+		for _, review := range reviews {
+			switch review.GetState() {
+			case "APPROVED":
+				latest[review.GetUser().GetLogin()] = ReviewApproved
+			case "CHANGES_REQUESTED":
+				latest[review.GetUser().GetLogin()] = ReviewChangesRequested
+			case "DISMISSED":
+				delete(latest, review.GetUser().GetLogin())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return reviewDecision(latest, pendingReviewers), nil
+}
+
+// checkStatus combines commit statuses and check runs of the head commit
+func (p *gitHubProvider) checkStatus(ctx context.Context, sha string) (CheckStatus, error) {
+	if sha == "" {
+		return "", nil
+	}
+
+	var statuses []CheckStatus
+
+	combined, _, err := p.client.Repositories.GetCombinedStatus(ctx, p.owner, p.repo, sha, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit status of %s: %w", sha, err)
+	}
+	// The combined state is "pending" when there are no statuses at all
+	if combined.GetTotalCount() > 0 {
+		switch combined.GetState() {
+		case "success":
+			statuses = append(statuses, CheckSuccess)
+		case "pending":
+			statuses = append(statuses, CheckPending)
+		default:
+			statuses = append(statuses, CheckFailure)
+		}
+	}
+
+	opts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := p.client.Checks.ListCheckRunsForRef(ctx, p.owner, p.repo, sha, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to list check runs of %s: %w", sha, err)
+		}
+		for _, run := range runs.CheckRuns {
+			if run.GetStatus() != "completed" {
+				statuses = append(statuses, CheckPending)
+				continue
+			}
+			switch run.GetConclusion() {
+			case "success", "neutral", "skipped":
+				statuses = append(statuses, CheckSuccess)
+			default:
+				statuses = append(statuses, CheckFailure)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return combineCheckStatus(statuses...), nil
+}
+
+func (p *gitHubProvider) ApplyMetadata(ctx context.Context, number int, metadata PullRequestMetadata) error {
+	var errs []error
+
+	// Labels, assignees and milestones live on the issue backing the pull request
+	if len(metadata.Labels) > 0 {
+		if _, _, err := p.client.Issues.AddLabelsToIssue(ctx, p.owner, p.repo, number, metadata.Labels); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add labels: %w", err))
+		}
+	}
+
+	if len(metadata.Assignees) > 0 {
+		if _, _, err := p.client.Issues.AddAssignees(ctx, p.owner, p.repo, number, metadata.Assignees); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add assignees: %w", err))
+		}
+	}
+
+	if metadata.Milestone != "" {
+		if err := p.setMilestone(ctx, number, metadata.Milestone); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(metadata.Reviewers) > 0 || len(metadata.TeamReviewers) > 0 {
+		_, _, err := p.client.PullRequests.RequestReviewers(ctx, p.owner, p.repo, number, github.ReviewersRequest{
+			Reviewers:     metadata.Reviewers,
+			TeamReviewers: metadata.TeamReviewers,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to request reviewers: %w", err))
+		}
+	}
+
+	return joinMetadataErrors(errs)
+}
+
+func (p *gitHubProvider) setMilestone(ctx context.Context, number int, title string) error {
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		milestones, resp, err := p.client.Issues.ListMilestones(ctx, p.owner, p.repo, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list milestones: %w", err)
+		}
+		for _, milestone := range milestones {
+			if milestone.GetTitle() == title {
+				_, _, err := p.client.Issues.Edit(ctx, p.owner, p.repo, number, &github.IssueRequest{Milestone: milestone.Number})
+				if err != nil {
+					return fmt.Errorf("failed to set milestone: %w", err)
+				}
+				return nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return fmt.Errorf("milestone %q not found", title)
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (p *gitHubProvider) MergePullRequest(ctx context.Context, number int, opts MergeOptions) error {
+	if opts.Auto {
+		return p.enableAutoMerge(ctx, number, opts.Method)
+	}
+
+	_, _, err := p.client.PullRequests.Merge(ctx, p.owner, p.repo, number, "", &github.PullRequestOptions{
+		SHA:         opts.SHA,
+		MergeMethod: string(opts.Method),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge GitHub pull request #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// enableAutoMerge arms native auto-merge, which is only exposed through the GraphQL API
+func (p *gitHubProvider) enableAutoMerge(ctx context.Context, number int, method MergeMethod) error {
+	pr, _, err := p.client.PullRequests.Get(ctx, p.owner, p.repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to get GitHub pull request #%d: %w", number, err)
+	}
+
+	// GitHub Enterprise Server serves GraphQL at /api/graphql next to the REST API at /api/v3
+	graphqlURL := "graphql"
+	if base := p.client.BaseURL.String(); strings.HasSuffix(base, "/api/v3/") {
+		graphqlURL = strings.TrimSuffix(base, "v3/") + "graphql"
+	}
+
+	req, err := p.client.NewRequest("POST", graphqlURL, map[string]interface{}{
+		"query": `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+}`,
+		"variables": map[string]string{
+			"id":     pr.GetNodeID(),
+			"method": strings.ToUpper(string(method)),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := p.client.Do(ctx, req, &resp); err != nil {
+		return fmt.Errorf("failed to enable auto-merge on GitHub pull request #%d: %w", number, err)
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("failed to enable auto-merge on GitHub pull request #%d: %s", number, resp.Errors[0].Message)
+	}
+
+	return nil
+}
+
+func (p *gitHubProvider) ClosePullRequest(ctx context.Context, number int) error {
+	_, _, err := p.client.PullRequests.Edit(ctx, p.owner, p.repo, number, &github.PullRequest{
+		State: github.String("closed"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to close GitHub pull request #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+func (p *gitHubProvider) CreateRelease(ctx context.Context, opts ReleaseOptions) (*Release, error) {
+	existing, _, err := p.client.Repositories.GetReleaseByTag(ctx, p.owner, p.repo, opts.Tag)
+	var errResp *github.ErrorResponse
+	switch {
+	case err == nil:
+		return &Release{ID: existing.GetID(), URL: existing.GetHTMLURL()}, nil
+	case !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusNotFound:
+		return nil, fmt.Errorf("failed to get GitHub release for tag %s: %w", opts.Tag, err)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = opts.Tag
+	}
+	release, _, err := p.client.Repositories.CreateRelease(ctx, p.owner, p.repo, &github.RepositoryRelease{
+		TagName:              github.String(opts.Tag),
+		Name:                 github.String(name),
+		Body:                 github.String(opts.Body),
+		Draft:                github.Bool(opts.Draft),
+		Prerelease:           github.Bool(opts.Prerelease),
+		GenerateReleaseNotes: github.Bool(opts.GenerateNotes),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub release for tag %s: %w", opts.Tag, err)
+	}
+
+	return &Release{ID: release.GetID(), URL: release.GetHTMLURL()}, nil
+}
@@ -0,0 +1,313 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHubFindAndUpdatePullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/org/repo/pulls":
+			q := r.URL.Query()
+			if q.Get("state") != "open" || q.Get("base") != "main" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if q.Get("head") != "org:update" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"number":9,"html_url":"https://github.example.com/org/repo/pull/9"}]`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v3/repos/org/repo/pulls/9":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["title"] != "New title" || body["body"] != "New body" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"number":9,"html_url":"https://github.example.com/org/repo/pull/9"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://github.example.com/org/repo.git", Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	missing, err := provider.FindOpenPullRequest(context.Background(), "other", "main")
+	if err != nil || missing != nil {
+		t.Fatalf("Expected no pull request, got %v, %v", missing, err)
+	}
+
+	existing, err := provider.FindOpenPullRequest(context.Background(), "update", "main")
+	if err != nil || existing == nil || existing.Number != 9 {
+		t.Fatalf("Expected pull request #9, got %v, %v", existing, err)
+	}
+
+	updated, err := provider.UpdatePullRequest(context.Background(), 9, PullRequestOptions{Title: "New title", Body: "New body"})
+	if err != nil {
+		t.Fatalf("UpdatePullRequest() error = %v", err)
+	}
+	if updated.URL != "https://github.example.com/org/repo/pull/9" {
+		t.Errorf("URL = %s", updated.URL)
+	}
+}
+
+func TestGitHubEnterpriseBaseURL(t *testing.T) {
+	provider, err := New("git@github.example.com:org/repo.git", Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := provider.(*gitHubProvider).client.BaseURL.String(); got != "https://github.example.com/api/v3/" {
+		t.Errorf("BaseURL = %s", got)
+	}
+
+	provider, err = New("https://github.com/org/repo.git", Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := provider.(*gitHubProvider).client.BaseURL.String(); got != "https://api.github.com/" {
+		t.Errorf("BaseURL = %s", got)
+	}
+}
+
+func TestGitHubGetPullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/org/repo/pulls/9":
+			w.Write([]byte(`{"number":9,"html_url":"https://github.example.com/org/repo/pull/9","state":"closed","merged":true,
+				"merge_commit_sha":"abc123","mergeable_state":"unknown","head":{"sha":"def456"}}`))
+		case "/api/v3/repos/org/repo/pulls/9/reviews":
+			w.Write([]byte(`[{"state":"CHANGES_REQUESTED","user":{"login":"alice"}},{"state":"COMMENTED","user":{"login":"bob"}},
+				{"state":"APPROVED","user":{"login":"alice"}}]`))
+		case "/api/v3/repos/org/repo/commits/def456/status":
+			w.Write([]byte(`{"state":"success","total_count":1}`))
+		case "/api/v3/repos/org/repo/commits/def456/check-runs":
+			w.Write([]byte(`{"total_count":2,"check_runs":[{"status":"completed","conclusion":"success"},{"status":"in_progress"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://github.example.com/org/repo.git", Options{BaseURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	pr, err := provider.GetPullRequest(context.Background(), 9)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+
+	want := PullRequest{
+		Number:         9,
+		URL:            "https://github.example.com/org/repo/pull/9",
+		State:          StateMerged,
+		HeadSHA:        "def456",
+		MergeCommitSHA: "abc123",
+		Mergeable:      MergeableUnknown,
+		ReviewDecision: ReviewApproved,
+		CheckStatus:    CheckPending,
+	}
+	if *pr != want {
+		t.Errorf("GetPullRequest() = %+v, want %+v", *pr, want)
+	}
+}
+
+func TestGitHubApplyMetadata(t *testing.T) {
+	var labels, assignees []string
+	var reviewers map[string][]string
+	var milestone int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/org/repo/issues/9/labels":
+			json.NewDecoder(r.Body).Decode(&labels)
+			w.Write([]byte(`[]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/org/repo/issues/9/assignees":
+			var body map[string][]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assignees = body["assignees"]
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/org/repo/milestones":
+			w.Write([]byte(`[{"number":3,"title":"v1.0"}]`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v3/repos/org/repo/issues/9":
+			var body map[string]int
+			json.NewDecoder(r.Body).Decode(&body)
+			milestone = body["milestone"]
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/org/repo/pulls/9/requested_reviewers":
+			json.NewDecoder(r.Body).Decode(&reviewers)
+			if len(reviewers["team_reviewers"]) > 0 {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"message":"Reviews may only be requested from collaborators"}`))
+				return
+			}
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://github.example.com/org/repo.git", Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = provider.ApplyMetadata(context.Background(), 9, PullRequestMetadata{
+		Labels:    []string{"automated", "config"},
+		Assignees: []string{"alice"},
+		Milestone: "v1.0",
+		Reviewers: []string{"bob"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyMetadata() error = %v", err)
+	}
+	if len(labels) != 2 || len(assignees) != 1 || milestone != 3 || len(reviewers["reviewers"]) != 1 {
+		t.Errorf("Got labels=%v assignees=%v milestone=%d reviewers=%v", labels, assignees, milestone, reviewers)
+	}
+
+	// Failing parts are reported together, the remaining parts are still applied
+	labels = nil
+	err = provider.ApplyMetadata(context.Background(), 9, PullRequestMetadata{
+		Labels:        []string{"automated"},
+		Milestone:     "v2.0",
+		TeamReviewers: []string{"platform"},
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if !strings.Contains(err.Error(), `milestone "v2.0" not found`) || !strings.Contains(err.Error(), "failed to request reviewers") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(labels) != 1 {
+		t.Errorf("Expected labels to be applied despite the failures, got %v", labels)
+	}
+}
+
+func TestGitHubMergePullRequest(t *testing.T) {
+	var merge map[string]string
+	var graphql struct {
+		Query     string            `json:"query"`
+		Variables map[string]string `json:"variables"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v3/repos/org/repo/pulls/9/merge":
+			json.NewDecoder(r.Body).Decode(&merge)
+			w.Write([]byte(`{"merged":true,"sha":"abc123"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/org/repo/pulls/9":
+			w.Write([]byte(`{"number":9,"node_id":"PR_kwDOAbc"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/graphql":
+			json.NewDecoder(r.Body).Decode(&graphql)
+			w.Write([]byte(`{"data":{"enablePullRequestAutoMerge":{"clientMutationId":null}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://github.example.com/org/repo.git", Options{BaseURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := provider.MergePullRequest(context.Background(), 9, MergeOptions{Method: MergeMethodSquash, SHA: "def456"}); err != nil {
+		t.Fatalf("MergePullRequest() error = %v", err)
+	}
+	if merge["merge_method"] != "squash" || merge["sha"] != "def456" {
+		t.Errorf("Unexpected merge request: %v", merge)
+	}
+
+	// Native auto-merge goes through GraphQL, which GitHub Enterprise Server serves next to /api/v3
+	if err := provider.MergePullRequest(context.Background(), 9, MergeOptions{Method: MergeMethodRebase, Auto: true}); err != nil {
+		t.Fatalf("MergePullRequest(Auto) error = %v", err)
+	}
+	if !strings.Contains(graphql.Query, "enablePullRequestAutoMerge") ||
+		graphql.Variables["id"] != "PR_kwDOAbc" || graphql.Variables["method"] != "REBASE" {
+		t.Errorf("Unexpected GraphQL request: %+v", graphql)
+	}
+}
+
+func TestGitHubClosePullRequest(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/api/v3/repos/org/repo/pulls/9" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"number":9,"state":"closed"}`))
+	}))
+	defer server.Close()
+
+	provider, err := New("https://github.example.com/org/repo.git", Options{BaseURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := provider.ClosePullRequest(context.Background(), 9); err != nil {
+		t.Fatalf("ClosePullRequest() error = %v", err)
+	}
+	if body["state"] != "closed" {
+		t.Errorf("Unexpected edit: %v", body)
+	}
+}
+
+func TestGitHubCreateRelease(t *testing.T) {
+	var created map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/org/repo/releases/tags/v1.0.0":
+			w.Write([]byte(`{"id":1,"html_url":"https://github.example.com/org/repo/releases/tag/v1.0.0"}`))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v3/repos/org/repo/releases/tags/"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/org/repo/releases":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":2,"html_url":"https://github.example.com/org/repo/releases/tag/v1.1.0"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://github.example.com/org/repo.git", Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	publisher := provider.(ReleasePublisher)
+
+	// An existing release is returned as is
+	release, err := publisher.CreateRelease(context.Background(), ReleaseOptions{Tag: "v1.0.0"})
+	if err != nil || release.ID != 1 {
+		t.Fatalf("Expected release 1, got %v, %v", release, err)
+	}
+	if created != nil {
+		t.Errorf("Expected no release to be created, got %v", created)
+	}
+
+	release, err = publisher.CreateRelease(context.Background(), ReleaseOptions{Tag: "v1.1.0", Body: "Highlights", GenerateNotes: true, Prerelease: true})
+	if err != nil {
+		t.Fatalf("CreateRelease() error = %v", err)
+	}
+	if release.URL != "https://github.example.com/org/repo/releases/tag/v1.1.0" {
+		t.Errorf("URL = %s", release.URL)
+	}
+	want := map[string]interface{}{"tag_name": "v1.1.0", "name": "v1.1.0", "body": "Highlights", "draft": false, "prerelease": true, "generate_release_notes": true}
+	for key, value := range want {
+		if created[key] != value {
+			t.Errorf("%s = %v, want %v", key, created[key], value)
+		}
+	}
+}
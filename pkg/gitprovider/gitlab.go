@@ -0,0 +1,310 @@
+package gitprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// gitLabProvider talks to the GitLab REST API v4. Personal, group and project access
+// tokens are all sent in the PRIVATE-TOKEN header.
+type gitLabProvider struct {
+	restClient
+	project string
+}
+
+func newGitLabProvider(repo *Repository, opts Options) (Provider, error) {
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = repo.WebURL() + "/api/v4"
+	}
+
+	return &gitLabProvider{
+		restClient: newRESTClient(opts, baseURL, func(req *http.Request, token string) {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}),
+		// Nested group paths are addressed by their URL encoded full path
+		project: url.PathEscape(repo.Path),
+	}, nil
+}
+
+type gitLabMergeRequest struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+}
+
+// gitLabTitle marks drafts with the title prefix GitLab uses for draft merge requests
+func gitLabTitle(opts PullRequestOptions) string {
+	if opts.Draft && !strings.HasPrefix(strings.ToLower(opts.Title), "draft:") {
+		return "Draft: " + opts.Title
+	}
+	return opts.Title
+}
+
+func (p *gitLabProvider) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]interface{}{
+		"source_branch": opts.Head,
+		"target_branch": opts.Base,
+		"title":         gitLabTitle(opts),
+		"description":   opts.Body,
+	}
+
+	var mr gitLabMergeRequest
+	if err := p.do(ctx, http.MethodPost, "/projects/"+p.project+"/merge_requests", body, &mr); err != nil {
+		return nil, fmt.Errorf("failed to create GitLab merge request: %w", err)
+	}
+
+	return &PullRequest{Number: mr.IID, URL: mr.WebURL}, nil
+}
+
+func (p *gitLabProvider) FindOpenPullRequest(ctx context.Context, head, base string) (*PullRequest, error) {
+	query := url.Values{
+		"state":         {"opened"},
+		"source_branch": {head},
+		"target_branch": {base},
+	}
+
+	var mrs []gitLabMergeRequest
+	if err := p.do(ctx, http.MethodGet, "/projects/"+p.project+"/merge_requests?"+query.Encode(), nil, &mrs); err != nil {
+		return nil, fmt.Errorf("failed to list GitLab merge requests: %w", err)
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+
+	return &PullRequest{Number: mrs[0].IID, URL: mrs[0].WebURL}, nil
+}
+
+func (p *gitLabProvider) UpdatePullRequest(ctx context.Context, number int, opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]interface{}{
+		"title":       gitLabTitle(opts),
+		"description": opts.Body,
+	}
+
+	var mr gitLabMergeRequest
+	if err := p.do(ctx, http.MethodPut, fmt.Sprintf("/projects/%s/merge_requests/%d", p.project, number), body, &mr); err != nil {
+		return nil, fmt.Errorf("failed to update GitLab merge request !%d: %w", number, err)
+	}
+
+	return &PullRequest{Number: mr.IID, URL: mr.WebURL}, nil
+}
+
+type gitLabMergeRequestDetails struct {
+	gitLabMergeRequest
+	State               string  `json:"state"`
+	SHA                 string  `json:"sha"`
+	MergeCommitSHA      *string `json:"merge_commit_sha"`
+	SquashCommitSHA     *string `json:"squash_commit_sha"`
+	MergeStatus         string  `json:"merge_status"`
+	DetailedMergeStatus string  `json:"detailed_merge_status"`
+	HasConflicts        bool    `json:"has_conflicts"`
+	HeadPipeline        *struct {
+		Status string `json:"status"`
+	} `json:"head_pipeline"`
+}
+
+type gitLabApprovals struct {
+	ApprovalsLeft int `json:"approvals_left"`
+	ApprovedBy    []struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"approved_by"`
+}
+
+func (p *gitLabProvider) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	path := fmt.Sprintf("/projects/%s/merge_requests/%d", p.project, number)
+
+	var mr gitLabMergeRequestDetails
+	if err := p.do(ctx, http.MethodGet, path, nil, &mr); err != nil {
+		return nil, fmt.Errorf("failed to get GitLab merge request !%d: %w", number, err)
+	}
+
+	result := &PullRequest{
+		Number:  mr.IID,
+		URL:     mr.WebURL,
+		State:   StateOpen,
+		HeadSHA: mr.SHA,
+	}
+	switch mr.State {
+	case "merged":
+		result.State = StateMerged
+		// Fast-forward merges create no merge commit, the head commit lands on the target branch
+		switch {
+		case mr.MergeCommitSHA != nil:
+			result.MergeCommitSHA = *mr.MergeCommitSHA
+		case mr.SquashCommitSHA != nil:
+			result.MergeCommitSHA = *mr.SquashCommitSHA
+		default:
+			result.MergeCommitSHA = mr.SHA
+		}
+	case "closed", "locked":
+		result.State = StateClosed
+	}
+
+	// https://docs.gitlab.com/ee/api/merge_requests.html#merge-status
+	switch {
+	case mr.HasConflicts || mr.DetailedMergeStatus == "conflict":
+		result.Mergeable = MergeableConflicting
+	case mr.DetailedMergeStatus == "mergeable" || (mr.DetailedMergeStatus == "" && mr.MergeStatus == "can_be_merged"):
+		result.Mergeable = MergeableMergeable
+	case mr.DetailedMergeStatus == "" || mr.DetailedMergeStatus == "unchecked" || mr.DetailedMergeStatus == "checking" || mr.DetailedMergeStatus == "preparing":
+		result.Mergeable = MergeableUnknown
+	default:
+		result.Mergeable = MergeableBlocked
+	}
+
+	if mr.HeadPipeline != nil {
+		switch mr.HeadPipeline.Status {
+		case "success":
+			result.CheckStatus = CheckSuccess
+		case "failed", "canceled":
+			result.CheckStatus = CheckFailure
+		case "skipped":
+		default:
+			result.CheckStatus = CheckPending
+		}
+	}
+
+	var approvals gitLabApprovals
+	if err := p.do(ctx, http.MethodGet, path+"/approvals", nil, &approvals); err != nil {
+		return nil, fmt.Errorf("failed to get approvals of GitLab merge request !%d: %w", number, err)
+	}
+	switch {
+	case approvals.ApprovalsLeft > 0:
+		result.ReviewDecision = ReviewRequired
+	case len(approvals.ApprovedBy) > 0:
+		result.ReviewDecision = ReviewApproved
+	}
+
+	return result, nil
+}
+
+type gitLabUser struct {
+	ID int `json:"id"`
+}
+
+type gitLabMilestone struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func (p *gitLabProvider) ApplyMetadata(ctx context.Context, number int, metadata PullRequestMetadata) error {
+	var errs []error
+	if len(metadata.TeamReviewers) > 0 {
+		errs = append(errs, errUnsupported("GitLab", "team reviewers"))
+	}
+
+	path := fmt.Sprintf("/projects/%s/merge_requests/%d", p.project, number)
+	body := map[string]interface{}{}
+
+	if len(metadata.Labels) > 0 {
+		body["add_labels"] = strings.Join(metadata.Labels, ",")
+	}
+
+	// Assignees and reviewers are replaced as a whole, so extend the current ones
+	if len(metadata.Assignees) > 0 || len(metadata.Reviewers) > 0 {
+		var current struct {
+			Assignees []gitLabUser `json:"assignees"`
+			Reviewers []gitLabUser `json:"reviewers"`
+		}
+		if err := p.do(ctx, http.MethodGet, path, nil, &current); err != nil {
+			errs = append(errs, fmt.Errorf("failed to get merge request !%d: %w", number, err))
+		} else {
+			if len(metadata.Assignees) > 0 {
+				ids, lookupErrs := p.userIDs(ctx, current.Assignees, metadata.Assignees)
+				errs = append(errs, lookupErrs...)
+				body["assignee_ids"] = ids
+			}
+			if len(metadata.Reviewers) > 0 {
+				ids, lookupErrs := p.userIDs(ctx, current.Reviewers, metadata.Reviewers)
+				errs = append(errs, lookupErrs...)
+				body["reviewer_ids"] = ids
+			}
+		}
+	}
+
+	if metadata.Milestone != "" {
+		query := url.Values{"title": {metadata.Milestone}, "include_ancestors": {"true"}}
+		var milestones []gitLabMilestone
+		if err := p.do(ctx, http.MethodGet, "/projects/"+p.project+"/milestones?"+query.Encode(), nil, &milestones); err != nil {
+			errs = append(errs, fmt.Errorf("failed to list milestones: %w", err))
+		} else if len(milestones) == 0 {
+			errs = append(errs, fmt.Errorf("milestone %q not found", metadata.Milestone))
+		} else {
+			body["milestone_id"] = milestones[0].ID
+		}
+	}
+
+	if len(body) > 0 {
+		if err := p.do(ctx, http.MethodPut, path, body, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update merge request !%d: %w", number, err))
+		}
+	}
+
+	return joinMetadataErrors(errs)
+}
+
+// userIDs resolves usernames to user IDs and appends them to the current users
+func (p *gitLabProvider) userIDs(ctx context.Context, current []gitLabUser, usernames []string) ([]int, []error) {
+	ids := make([]int, 0, len(current)+len(usernames))
+	for _, user := range current {
+		ids = append(ids, user.ID)
+	}
+
+	var errs []error
+	for _, username := range usernames {
+		var users []gitLabUser
+		if err := p.do(ctx, http.MethodGet, "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+			errs = append(errs, fmt.Errorf("failed to look up user %s: %w", username, err))
+			continue
+		}
+		if len(users) == 0 {
+			errs = append(errs, fmt.Errorf("user %s not found", username))
+			continue
+		}
+		if !slices.Contains(ids, users[0].ID) {
+			ids = append(ids, users[0].ID)
+		}
+	}
+
+	return ids, errs
+}
+
+func (p *gitLabProvider) MergePullRequest(ctx context.Context, number int, opts MergeOptions) error {
+	// Rebase merges are a project setting on GitLab rather than a per merge request choice
+	if opts.Method == MergeMethodRebase {
+		return fmt.Errorf("rebase merges are not supported by GitLab, configure fast-forward merges on the project instead")
+	}
+
+	body := map[string]interface{}{
+		"squash": opts.Method == MergeMethodSquash,
+	}
+	if opts.SHA != "" {
+		body["sha"] = opts.SHA
+	}
+	if opts.Auto {
+		body["merge_when_pipeline_succeeds"] = true
+	}
+
+	if err := p.do(ctx, http.MethodPut, fmt.Sprintf("/projects/%s/merge_requests/%d/merge", p.project, number), body, nil); err != nil {
+		return fmt.Errorf("failed to merge GitLab merge request !%d: %w", number, err)
+	}
+
+	return nil
+}
+
+func (p *gitLabProvider) ClosePullRequest(ctx context.Context, number int) error {
+	body := map[string]interface{}{
+		"state_event": "close",
+	}
+
+	if err := p.do(ctx, http.MethodPut, fmt.Sprintf("/projects/%s/merge_requests/%d", p.project, number), body, nil); err != nil {
+		return fmt.Errorf("failed to close GitLab merge request !%d: %w", number, err)
+	}
+
+	return nil
+}
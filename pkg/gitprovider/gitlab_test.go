@@ -0,0 +1,382 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// newGitLabServer stands in for the GitLab API, serving the merge request endpoint of a single project
+func newGitLabServer(t *testing.T, projectPath, token string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"401 Unauthorized"}`))
+			return
+		}
+		// Nested groups must arrive as a single URL encoded path segment
+		wantPath := "/api/v4/projects/" + strings.ReplaceAll(projectPath, "/", "%2F") + "/merge_requests"
+		if r.Method != http.MethodPost || r.URL.EscapedPath() != wantPath {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"404 Project Not Found"}`))
+			return
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if body["source_branch"] == body["target_branch"] {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":["Another open merge request already exists for this source branch"]}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"iid":     42,
+			"title":   body["title"],
+			"web_url": "https://gitlab.example.com/" + projectPath + "/-/merge_requests/42",
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitLabCreateMergeRequest(t *testing.T) {
+	server := newGitLabServer(t, "platform/infra/config", "glpat-project")
+
+	provider, err := New("https://gitlab.example.com/platform/infra/config.git", Options{
+		TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "glpat-project"}),
+		BaseURL:     server.URL + "/api/v4",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	mr, err := provider.CreatePullRequest(context.Background(), PullRequestOptions{
+		Title: "Update config",
+		Body:  "Automated change",
+		Head:  "update-config",
+		Base:  "main",
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest() error = %v", err)
+	}
+	if mr.Number != 42 || mr.URL != "https://gitlab.example.com/platform/infra/config/-/merge_requests/42" {
+		t.Errorf("Got !%d %s", mr.Number, mr.URL)
+	}
+}
+
+func TestGitLabCreateMergeRequestErrors(t *testing.T) {
+	server := newGitLabServer(t, "group/project", "glpat-project")
+
+	tests := []struct {
+		name       string
+		token      string
+		head       string
+		wantStatus int
+		wantText   string
+	}{
+		{name: "invalid token", token: "wrong", head: "feature", wantStatus: http.StatusUnauthorized, wantText: "401 Unauthorized"},
+		{name: "existing merge request", token: "glpat-project", head: "main", wantStatus: http.StatusConflict, wantText: "Another open merge request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := New("git@gitlab.example.com:group/project.git", Options{
+				TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: tt.token}),
+				BaseURL:     server.URL + "/api/v4/",
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			_, err = provider.CreatePullRequest(context.Background(), PullRequestOptions{Title: "t", Head: tt.head, Base: "main"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected an APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus || !strings.Contains(apiErr.Message, tt.wantText) {
+				t.Errorf("Got %d %q, want %d containing %q", apiErr.StatusCode, apiErr.Message, tt.wantStatus, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestGitLabDefaultBaseURL(t *testing.T) {
+	provider, err := New("ssh://git@gitlab.internal.example.com:2222/a/b/c.git", Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	gitlab := provider.(*gitLabProvider)
+	if gitlab.baseURL != "https://gitlab.internal.example.com/api/v4" {
+		t.Errorf("baseURL = %s", gitlab.baseURL)
+	}
+	if gitlab.project != "a%2Fb%2Fc" {
+		t.Errorf("project = %s", gitlab.project)
+	}
+}
+
+func TestGitLabFindAndUpdateMergeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/api/v4/projects/group%2Fsub%2Fproject/merge_requests":
+			q := r.URL.Query()
+			if q.Get("state") != "opened" || q.Get("target_branch") != "main" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if q.Get("source_branch") != "update" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"iid":5,"web_url":"https://gitlab.example.com/group/sub/project/-/merge_requests/5"}]`))
+		case r.Method == http.MethodPut && r.URL.EscapedPath() == "/api/v4/projects/group%2Fsub%2Fproject/merge_requests/5":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["title"] != "New title" || body["description"] != "New body" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"iid":5,"web_url":"https://gitlab.example.com/group/sub/project/-/merge_requests/5"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitlab.example.com/group/sub/project.git", Options{BaseURL: server.URL + "/api/v4"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	missing, err := provider.FindOpenPullRequest(context.Background(), "other", "main")
+	if err != nil || missing != nil {
+		t.Fatalf("Expected no merge request, got %v, %v", missing, err)
+	}
+
+	existing, err := provider.FindOpenPullRequest(context.Background(), "update", "main")
+	if err != nil || existing == nil || existing.Number != 5 {
+		t.Fatalf("Expected merge request !5, got %v, %v", existing, err)
+	}
+
+	updated, err := provider.UpdatePullRequest(context.Background(), existing.Number, PullRequestOptions{Title: "New title", Body: "New body"})
+	if err != nil {
+		t.Fatalf("UpdatePullRequest() error = %v", err)
+	}
+	if updated.Number != 5 {
+		t.Errorf("Got !%d, want !5", updated.Number)
+	}
+}
+
+func TestGitLabGetMergeRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		mergeRequest string
+		approvals    string
+		want         PullRequest
+	}{
+		{
+			name:         "open with running pipeline",
+			mergeRequest: `{"iid":5,"state":"opened","sha":"def456","detailed_merge_status":"ci_still_running","head_pipeline":{"status":"running"}}`,
+			approvals:    `{"approvals_left":1,"approved_by":[]}`,
+			want:         PullRequest{Number: 5, State: StateOpen, HeadSHA: "def456", Mergeable: MergeableBlocked, ReviewDecision: ReviewRequired, CheckStatus: CheckPending},
+		},
+		{
+			name:         "merged",
+			mergeRequest: `{"iid":5,"state":"merged","sha":"def456","merge_commit_sha":"abc123","detailed_merge_status":"mergeable","head_pipeline":{"status":"success"}}`,
+			approvals:    `{"approvals_left":0,"approved_by":[{"user":{"username":"alice"}}]}`,
+			want:         PullRequest{Number: 5, State: StateMerged, HeadSHA: "def456", MergeCommitSHA: "abc123", Mergeable: MergeableMergeable, ReviewDecision: ReviewApproved, CheckStatus: CheckSuccess},
+		},
+		{
+			name:         "fast-forward merged",
+			mergeRequest: `{"iid":5,"state":"merged","sha":"def456","merge_commit_sha":null,"detailed_merge_status":"mergeable"}`,
+			approvals:    `{"approvals_left":0,"approved_by":[]}`,
+			want:         PullRequest{Number: 5, State: StateMerged, HeadSHA: "def456", MergeCommitSHA: "def456", Mergeable: MergeableMergeable},
+		},
+		{
+			name:         "closed with conflicts",
+			mergeRequest: `{"iid":5,"state":"closed","sha":"def456","has_conflicts":true,"detailed_merge_status":"conflict","head_pipeline":{"status":"failed"}}`,
+			approvals:    `{"approvals_left":0,"approved_by":[]}`,
+			want:         PullRequest{Number: 5, State: StateClosed, HeadSHA: "def456", Mergeable: MergeableConflicting, CheckStatus: CheckFailure},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.EscapedPath() {
+				case "/api/v4/projects/group%2Fsub%2Fproject/merge_requests/5":
+					w.Write([]byte(tt.mergeRequest))
+				case "/api/v4/projects/group%2Fsub%2Fproject/merge_requests/5/approvals":
+					w.Write([]byte(tt.approvals))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			provider, err := New("https://gitlab.example.com/group/sub/project.git", Options{BaseURL: server.URL + "/api/v4"})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			pr, err := provider.GetPullRequest(context.Background(), 5)
+			if err != nil {
+				t.Fatalf("GetPullRequest() error = %v", err)
+			}
+			if *pr != tt.want {
+				t.Errorf("GetPullRequest() = %+v, want %+v", *pr, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitLabApplyMetadata(t *testing.T) {
+	var update map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/users":
+			switch r.URL.Query().Get("username") {
+			case "alice":
+				w.Write([]byte(`[{"id":11}]`))
+			case "bob":
+				w.Write([]byte(`[{"id":12}]`))
+			default:
+				w.Write([]byte(`[]`))
+			}
+		case r.URL.EscapedPath() == "/api/v4/projects/group%2Fproject/milestones":
+			if r.URL.Query().Get("title") != "v1.0" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"id":7,"title":"v1.0"}]`))
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/api/v4/projects/group%2Fproject/merge_requests/5":
+			w.Write([]byte(`{"iid":5,"assignees":[{"id":10}],"reviewers":[]}`))
+		case r.Method == http.MethodPut && r.URL.EscapedPath() == "/api/v4/projects/group%2Fproject/merge_requests/5":
+			json.NewDecoder(r.Body).Decode(&update)
+			w.Write([]byte(`{"iid":5}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitlab.example.com/group/project.git", Options{BaseURL: server.URL + "/api/v4"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = provider.ApplyMetadata(context.Background(), 5, PullRequestMetadata{
+		Labels:        []string{"automated", "config"},
+		Assignees:     []string{"alice"},
+		Reviewers:     []string{"bob", "nobody"},
+		TeamReviewers: []string{"platform"},
+		Milestone:     "v1.0",
+	})
+	if err == nil {
+		t.Fatal("Expected an error for the unknown user and team reviewers")
+	}
+	if !strings.Contains(err.Error(), "team reviewers are not supported by GitLab") || !strings.Contains(err.Error(), "user nobody not found") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Everything that could be resolved is still applied, existing assignees are kept
+	if update["add_labels"] != "automated,config" || update["milestone_id"] != float64(7) {
+		t.Errorf("Unexpected update: %v", update)
+	}
+	if assignees, _ := json.Marshal(update["assignee_ids"]); string(assignees) != "[10,11]" {
+		t.Errorf("assignee_ids = %s", assignees)
+	}
+	if reviewers, _ := json.Marshal(update["reviewer_ids"]); string(reviewers) != "[12]" {
+		t.Errorf("reviewer_ids = %s", reviewers)
+	}
+}
+
+func TestGitLabDraftTitle(t *testing.T) {
+	tests := []struct {
+		opts PullRequestOptions
+		want string
+	}{
+		{opts: PullRequestOptions{Title: "Update config"}, want: "Update config"},
+		{opts: PullRequestOptions{Title: "Update config", Draft: true}, want: "Draft: Update config"},
+		{opts: PullRequestOptions{Title: "Draft: Update config", Draft: true}, want: "Draft: Update config"},
+	}
+
+	for _, tt := range tests {
+		if got := gitLabTitle(tt.opts); got != tt.want {
+			t.Errorf("gitLabTitle(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestGitLabMergeMergeRequest(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/merge_requests/5/merge" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"iid":5,"state":"merged"}`))
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitlab.example.com/group/project.git", Options{BaseURL: server.URL + "/api/v4"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = provider.MergePullRequest(context.Background(), 5, MergeOptions{Method: MergeMethodSquash, SHA: "abc123", Auto: true})
+	if err != nil {
+		t.Fatalf("MergePullRequest() error = %v", err)
+	}
+	got, _ := json.Marshal(body)
+	if want := `{"merge_when_pipeline_succeeds":true,"sha":"abc123","squash":true}`; string(got) != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+
+	body = nil
+	if err := provider.MergePullRequest(context.Background(), 5, MergeOptions{Method: MergeMethodRebase}); err == nil {
+		t.Error("Expected rebase merges to be rejected")
+	}
+	if body != nil {
+		t.Errorf("Expected no API call, got %v", body)
+	}
+}
+
+func TestGitLabCloseMergeRequest(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/merge_requests/5" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"iid":5,"state":"closed"}`))
+	}))
+	defer server.Close()
+
+	provider, err := New("https://gitlab.example.com/group/project.git", Options{BaseURL: server.URL + "/api/v4"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := provider.ClosePullRequest(context.Background(), 5); err != nil {
+		t.Fatalf("ClosePullRequest() error = %v", err)
+	}
+	if body["state_event"] != "close" {
+		t.Errorf("Unexpected update: %v", body)
+	}
+}
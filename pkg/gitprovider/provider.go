@@ -0,0 +1,426 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"golang.org/x/oauth2"
+)
+
+// Kind identifies the hosting provider serving a repository
+type Kind string
+
+const (
+	KindGitHub          Kind = "github"
+	KindGitLab          Kind = "gitlab"
+	KindGitea           Kind = "gitea"
+	KindForgejo         Kind = "forgejo"
+	KindBitbucketServer Kind = "bitbucket-server"
+)
+
+// Provider opens pull requests (merge requests on GitLab) against a single repository
+type Provider interface {
+	// CreatePullRequest opens a pull request from opts.Head into opts.Base
+	CreatePullRequest(ctx context.Context, opts PullRequestOptions) (*PullRequest, error)
+
+	// FindOpenPullRequest returns the open pull request from head into base, or nil if there is none
+	FindOpenPullRequest(ctx context.Context, head, base string) (*PullRequest, error)
+
+	// UpdatePullRequest refreshes the title and body of an existing pull request
+	UpdatePullRequest(ctx context.Context, number int, opts PullRequestOptions) (*PullRequest, error)
+
+	// GetPullRequest returns the pull request including its lifecycle state, review decision and checks
+	GetPullRequest(ctx context.Context, number int) (*PullRequest, error)
+
+	// ApplyMetadata adds labels, reviewers, assignees and the milestone to an existing pull request.
+	// Every part is attempted, failures are collected into a single error.
+	ApplyMetadata(ctx context.Context, number int, metadata PullRequestMetadata) error
+
+	// MergePullRequest merges the pull request right away, or arms the provider's own
+	// auto-merge when opts.Auto is set so it merges once its requirements are met
+	MergePullRequest(ctx context.Context, number int, opts MergeOptions) error
+
+	// ClosePullRequest closes the pull request without merging it
+	ClosePullRequest(ctx context.Context, number int) error
+}
+
+// ReleasePublisher is implemented by the providers that publish releases for tags
+type ReleasePublisher interface {
+	// CreateRelease publishes a release for an existing tag, or returns the release that
+	// was already published for it
+	CreateRelease(ctx context.Context, opts ReleaseOptions) (*Release, error)
+}
+
+// ReleaseOptions describes the release to publish
+type ReleaseOptions struct {
+	Tag  string
+	Name string
+	Body string
+
+	// GenerateNotes appends notes generated by the provider from the changes since the previous release
+	GenerateNotes bool
+
+	Draft      bool
+	Prerelease bool
+}
+
+// Release is a published release
+type Release struct {
+	ID  int64
+	URL string
+}
+
+// MergeMethod selects how the pull request is merged into the base branch
+type MergeMethod string
+
+const (
+	MergeMethodMerge  MergeMethod = "merge"
+	MergeMethodSquash MergeMethod = "squash"
+	MergeMethodRebase MergeMethod = "rebase"
+)
+
+// MergeOptions describes how to merge a pull request
+type MergeOptions struct {
+	Method MergeMethod
+
+	// SHA is the expected head commit, the merge is rejected if the branch moved on
+	SHA string
+
+	// Auto enables native auto-merge instead of merging immediately
+	Auto bool
+}
+
+// PullRequestOptions describes the pull request to open
+type PullRequestOptions struct {
+	Title string
+	Body  string
+	Head  string
+	Base  string
+
+	// Draft opens the pull request as a draft, providers without draft support mark the title instead
+	Draft bool
+}
+
+// PullRequestMetadata is applied after the pull request has been opened
+type PullRequestMetadata struct {
+	Labels        []string
+	Reviewers     []string
+	TeamReviewers []string
+	Assignees     []string
+
+	// Milestone is the title of an existing milestone
+	Milestone string
+}
+
+// IsEmpty reports whether there is nothing to apply
+func (m PullRequestMetadata) IsEmpty() bool {
+	return len(m.Labels) == 0 && len(m.Reviewers) == 0 && len(m.TeamReviewers) == 0 &&
+		len(m.Assignees) == 0 && m.Milestone == ""
+}
+
+// metadataError collects the parts of PullRequestMetadata that could not be applied
+type metadataError []error
+
+func (e metadataError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e metadataError) Unwrap() []error {
+	return e
+}
+
+// joinMetadataErrors returns nil when every part was applied
+func joinMetadataErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return metadataError(errs)
+}
+
+// errUnsupported reports a metadata field the provider has no concept of
+func errUnsupported(provider, field string) error {
+	return fmt.Errorf("%s are not supported by %s", field, provider)
+}
+
+// PullRequest is the provider independent view of an opened pull request.
+// The lifecycle fields are only populated by GetPullRequest.
+type PullRequest struct {
+	Number int
+	URL    string
+
+	State          State
+	HeadSHA        string
+	MergeCommitSHA string
+	Mergeable      Mergeable
+	ReviewDecision ReviewDecision
+	CheckStatus    CheckStatus
+}
+
+// State is the lifecycle state of a pull request
+type State string
+
+const (
+	StateOpen   State = "Open"
+	StateMerged State = "Merged"
+	StateClosed State = "Closed"
+)
+
+// Mergeable summarizes whether a pull request can be merged right now
+type Mergeable string
+
+const (
+	MergeableMergeable   Mergeable = "Mergeable"
+	MergeableConflicting Mergeable = "Conflicting"
+	// MergeableBlocked means there are no conflicts but branch protection, reviews or checks prevent merging
+	MergeableBlocked Mergeable = "Blocked"
+	// MergeableUnknown is reported while the provider is still computing mergeability
+	MergeableUnknown Mergeable = "Unknown"
+)
+
+// ReviewDecision summarizes the reviews of a pull request
+type ReviewDecision string
+
+const (
+	ReviewApproved         ReviewDecision = "Approved"
+	ReviewChangesRequested ReviewDecision = "ChangesRequested"
+	ReviewRequired         ReviewDecision = "ReviewRequired"
+)
+
+// CheckStatus is the combined result of all checks on the head commit, empty when there are none
+type CheckStatus string
+
+const (
+	CheckSuccess CheckStatus = "Success"
+	CheckPending CheckStatus = "Pending"
+	CheckFailure CheckStatus = "Failure"
+)
+
+// combineCheckStatus folds individual check results, failures win over pending checks
+func combineCheckStatus(statuses ...CheckStatus) CheckStatus {
+	combined := CheckStatus("")
+	for _, status := range statuses {
+		switch {
+		case status == CheckFailure:
+			return CheckFailure
+		case status == CheckPending:
+			combined = CheckPending
+		case status == CheckSuccess && combined == "":
+			combined = CheckSuccess
+		}
+	}
+	return combined
+}
+
+// reviewDecision derives the decision from the latest review state of every reviewer
+func reviewDecision(latest map[string]ReviewDecision, pendingReviewers bool) ReviewDecision {
+	approved := false
+	for _, decision := range latest {
+		if decision == ReviewChangesRequested {
+			return ReviewChangesRequested
+		}
+		if decision == ReviewApproved {
+			approved = true
+		}
+	}
+	switch {
+	case approved:
+		return ReviewApproved
+	case pendingReviewers:
+		return ReviewRequired
+	default:
+		return ""
+	}
+}
+
+// Options configures how a provider talks to its API
+type Options struct {
+	// Kind selects the provider explicitly, it is detected from the repository URL when empty
+	Kind Kind
+
+	// TokenSource supplies the API token, e.g. a personal, project or installation token
+	TokenSource oauth2.TokenSource
+
+	// HTTPClient is used for API calls, defaults to http.DefaultClient
+	HTTPClient *http.Client
+
+	// BaseURL overrides the API root derived from the repository URL
+	BaseURL string
+}
+
+// APIError is returned when a provider API answers with a non-2xx status
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// newAPIError extracts the message from a JSON error body, falling back to the raw body
+func newAPIError(method string, u *url.URL, statusCode int, body []byte) *APIError {
+	message := strings.TrimSpace(string(body))
+	var parsed struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		switch {
+		case len(parsed.Message) > 0:
+			var text string
+			if json.Unmarshal(parsed.Message, &text) == nil {
+				message = text
+			} else {
+				message = string(parsed.Message)
+			}
+		case parsed.Error != "":
+			message = parsed.Error
+		case len(parsed.Errors) > 0:
+			messages := make([]string, 0, len(parsed.Errors))
+			for _, e := range parsed.Errors {
+				messages = append(messages, e.Message)
+			}
+			message = strings.Join(messages, "; ")
+		}
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+
+	// Never leak credentials that may be part of the URL
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+
+	return &APIError{Method: method, URL: redacted.String(), StatusCode: statusCode, Message: message}
+}
+
+// Repository is a repository URL split into the parts needed to reach the provider API
+type Repository struct {
+	// Scheme is the scheme used for API calls, SSH remotes map to https
+	Scheme string
+
+	// Host is the API host, including the port for HTTP(S) remotes
+	Host string
+
+	// Path is the full project path without the .git suffix, e.g. group/subgroup/project
+	Path string
+}
+
+// Owner returns everything but the last path segment (the namespace of the project)
+func (r *Repository) Owner() string {
+	if i := strings.LastIndex(r.Path, "/"); i >= 0 {
+		return r.Path[:i]
+	}
+	return ""
+}
+
+// Name returns the last path segment
+func (r *Repository) Name() string {
+	return r.Path[strings.LastIndex(r.Path, "/")+1:]
+}
+
+// WebURL returns the root of the web UI, e.g. https://gitlab.example.com
+func (r *Repository) WebURL() string {
+	return r.Scheme + "://" + r.Host
+}
+
+// ParseRepository parses HTTPS, SSH and scp-like repository URLs
+func ParseRepository(repoURL string) (*Repository, error) {
+	endpoint, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL %q: %w", repoURL, err)
+	}
+
+	repo := &Repository{
+		Scheme: "https",
+		Host:   endpoint.Host,
+		Path:   strings.TrimSuffix(strings.Trim(endpoint.Path, "/"), ".git"),
+	}
+
+	switch endpoint.Protocol {
+	case "http", "https":
+		repo.Scheme = endpoint.Protocol
+		if endpoint.Port != 0 {
+			repo.Host = endpoint.Host + ":" + strconv.Itoa(endpoint.Port)
+		}
+	case "ssh":
+	default:
+		return nil, fmt.Errorf("unsupported repository URL scheme %q", endpoint.Protocol)
+	}
+
+	if repo.Host == "" || !strings.Contains(repo.Path, "/") {
+		return nil, fmt.Errorf("invalid repository URL %q: expected <host>/<owner>/<name>", repoURL)
+	}
+
+	return repo, nil
+}
+
+// Detect infers the provider from the repository host, falling back to the
+// Bitbucket Server /scm/ path layout for hosts without a telling name
+func Detect(repo *Repository) (Kind, error) {
+	host := strings.ToLower(repo.Host)
+	switch {
+	case strings.Contains(host, "github"):
+		return KindGitHub, nil
+	case strings.Contains(host, "gitlab"):
+		return KindGitLab, nil
+	case strings.Contains(host, "gitea"), host == "codeberg.org":
+		return KindGitea, nil
+	case strings.Contains(host, "forgejo"):
+		return KindForgejo, nil
+	case host == "bitbucket.org":
+		return "", fmt.Errorf("bitbucket cloud is not supported, only Bitbucket Server")
+	case strings.Contains(host, "bitbucket"), strings.HasPrefix(repo.Path, "scm/"), strings.Contains(repo.Path, "/scm/"):
+		return KindBitbucketServer, nil
+	default:
+		return "", fmt.Errorf("unable to detect git provider for host %s, set spec.provider explicitly", repo.Host)
+	}
+}
+
+// New returns the provider serving repoURL
+func New(repoURL string, opts Options) (Provider, error) {
+	repo, err := ParseRepository(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := opts.Kind
+	if kind == "" {
+		if kind, err = Detect(repo); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	switch kind {
+	case KindGitHub:
+		return newGitHubProvider(repo, opts)
+	case KindGitLab:
+		return newGitLabProvider(repo, opts)
+	case KindGitea, KindForgejo:
+		return newGiteaProvider(repo, opts)
+	case KindBitbucketServer:
+		return newBitbucketServerProvider(repo, opts)
+	default:
+		return nil, fmt.Errorf("unsupported git provider %q", kind)
+	}
+}
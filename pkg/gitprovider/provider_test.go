@@ -0,0 +1,118 @@
+package gitprovider
+
+import "testing"
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantScheme string
+		wantHost   string
+		wantPath   string
+		wantOwner  string
+		wantName   string
+		wantError  bool
+	}{
+		{
+			url:        "https://github.com/org/repo.git",
+			wantScheme: "https", wantHost: "github.com", wantPath: "org/repo", wantOwner: "org", wantName: "repo",
+		},
+		{
+			url:        "git@github.com:org/repo.git",
+			wantScheme: "https", wantHost: "github.com", wantPath: "org/repo", wantOwner: "org", wantName: "repo",
+		},
+		{
+			url:        "ssh://git@gitlab.example.com:2222/group/sub/project.git",
+			wantScheme: "https", wantHost: "gitlab.example.com", wantPath: "group/sub/project", wantOwner: "group/sub", wantName: "project",
+		},
+		{
+			url:        "http://gitlab.local:8080/group/project",
+			wantScheme: "http", wantHost: "gitlab.local:8080", wantPath: "group/project", wantOwner: "group", wantName: "project",
+		},
+		{url: "https://github.com/repo-only", wantError: true},
+		{url: "file:///tmp/repo.git", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			repo, err := ParseRepository(tt.url)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("ParseRepository() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			if repo.Scheme != tt.wantScheme || repo.Host != tt.wantHost || repo.Path != tt.wantPath {
+				t.Errorf("Got %s://%s/%s, want %s://%s/%s", repo.Scheme, repo.Host, repo.Path, tt.wantScheme, tt.wantHost, tt.wantPath)
+			}
+			if repo.Owner() != tt.wantOwner || repo.Name() != tt.wantName {
+				t.Errorf("Got owner %s name %s, want %s %s", repo.Owner(), repo.Name(), tt.wantOwner, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		host      string
+		path      string
+		want      Kind
+		wantError bool
+	}{
+		{host: "github.com", path: "org/repo", want: KindGitHub},
+		{host: "github.example.com", path: "org/repo", want: KindGitHub},
+		{host: "gitlab.com", path: "group/sub/project", want: KindGitLab},
+		{host: "gitlab.internal:8443", path: "group/project", want: KindGitLab},
+		{host: "gitea.internal", path: "org/repo", want: KindGitea},
+		{host: "codeberg.org", path: "org/repo", want: KindGitea},
+		{host: "forgejo.example.com", path: "org/repo", want: KindForgejo},
+		{host: "bitbucket.example.com", path: "PROJ/repo", want: KindBitbucketServer},
+		{host: "git.example.com", path: "scm/PROJ/repo", want: KindBitbucketServer},
+		{host: "git.example.com", path: "bitbucket/scm/PROJ/repo", want: KindBitbucketServer},
+		{host: "bitbucket.org", path: "workspace/repo", wantError: true},
+		{host: "git.example.com", path: "org/repo", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+"/"+tt.path, func(t *testing.T) {
+			kind, err := Detect(&Repository{Host: tt.host, Path: tt.path})
+			if (err != nil) != tt.wantError {
+				t.Fatalf("Detect() error = %v, wantError %v", err, tt.wantError)
+			}
+			if kind != tt.want {
+				t.Errorf("Detect() = %s, want %s", kind, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewExplicitKind(t *testing.T) {
+	provider, err := New("https://git.example.com/org/repo.git", Options{Kind: KindForgejo})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, ok := provider.(*giteaProvider); !ok {
+		t.Errorf("Expected the Gitea provider for forgejo, got %T", provider)
+	}
+
+	if _, err := New("https://git.example.com/org/repo.git", Options{Kind: "unknown"}); err == nil {
+		t.Errorf("Expected an error for an unknown provider")
+	}
+}
+
+func TestCombineCheckStatus(t *testing.T) {
+	tests := []struct {
+		statuses []CheckStatus
+		want     CheckStatus
+	}{
+		{statuses: nil, want: ""},
+		{statuses: []CheckStatus{CheckSuccess, CheckSuccess}, want: CheckSuccess},
+		{statuses: []CheckStatus{CheckSuccess, CheckPending}, want: CheckPending},
+		{statuses: []CheckStatus{CheckPending, CheckFailure, CheckSuccess}, want: CheckFailure},
+	}
+
+	for _, tt := range tests {
+		if got := combineCheckStatus(tt.statuses...); got != tt.want {
+			t.Errorf("combineCheckStatus(%v) = %q, want %q", tt.statuses, got, tt.want)
+		}
+	}
+}
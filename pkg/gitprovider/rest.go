@@ -0,0 +1,80 @@
+package gitprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// restClient is a minimal JSON client shared by the providers without a Go SDK
+type restClient struct {
+	httpClient  *http.Client
+	tokenSource oauth2.TokenSource
+	baseURL     string
+
+	// authorize sets the provider specific authentication header
+	authorize func(req *http.Request, token string)
+}
+
+func newRESTClient(opts Options, baseURL string, authorize func(req *http.Request, token string)) restClient {
+	return restClient{
+		httpClient:  opts.HTTPClient,
+		tokenSource: opts.TokenSource,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		authorize:   authorize,
+	}
+}
+
+// do sends a JSON request to the API and decodes the JSON response into out
+func (c *restClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var reqBody io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokenSource != nil {
+		token, err := c.tokenSource.Token()
+		if err != nil {
+			return fmt.Errorf("failed to get API token: %w", err)
+		}
+		c.authorize(req, token.AccessToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(method, req.URL, resp.StatusCode, respBody)
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
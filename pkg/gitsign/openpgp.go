@@ -0,0 +1,53 @@
+package gitsign
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+type openPGPSigner struct {
+	entity *openpgp.Entity
+}
+
+// NewOpenPGPSigner parses an ASCII armored private key, decrypting it with passphrase if it is protected
+func NewOpenPGPSigner(armoredKey, passphrase []byte) (Signer, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armoredKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenPGP key: %w", err)
+	}
+	if len(entities) == 0 || entities[0].PrivateKey == nil {
+		return nil, fmt.Errorf("no OpenPGP private key found")
+	}
+
+	entity := entities[0]
+	if entity.PrivateKey.Encrypted || hasEncryptedSubkeys(entity) {
+		if len(passphrase) == 0 {
+			return nil, fmt.Errorf("OpenPGP private key is encrypted but no passphrase was provided")
+		}
+		if err := entity.DecryptPrivateKeys(passphrase); err != nil {
+			return nil, fmt.Errorf("failed to decrypt OpenPGP private key: %w", err)
+		}
+	}
+
+	return &openPGPSigner{entity: entity}, nil
+}
+
+func hasEncryptedSubkeys(entity *openpgp.Entity) bool {
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *openPGPSigner) Sign(message io.Reader) ([]byte, error) {
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, s.entity, message, nil); err != nil {
+		return nil, err
+	}
+	return signature.Bytes(), nil
+}
@@ -0,0 +1,123 @@
+// Package gitsign signs the commits and tags created by the operator with an OpenPGP or SSH key
+package gitsign
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultKeyKey is the secret key holding the private signing key when no key is configured
+	DefaultKeyKey = "signing-key"
+
+	// PassphraseKey optionally holds the passphrase protecting the private key
+	PassphraseKey = "passphrase"
+)
+
+// Format is the signature format written into the commit
+type Format string
+
+const (
+	FormatOpenPGP Format = "openpgp"
+	FormatSSH     Format = "ssh"
+)
+
+// Signer creates an armored detached signature over a commit payload
+type Signer interface {
+	Sign(message io.Reader) ([]byte, error)
+}
+
+// FromSecret loads the private key stored under key (DefaultKeyKey when empty) together
+// with the optional passphrase and returns a signer for the given format
+func FromSecret(secret *corev1.Secret, format Format, key string) (Signer, error) {
+	if key == "" {
+		key = DefaultKeyKey
+	}
+
+	privateKey, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in signing secret %s", key, secret.Name)
+	}
+	passphrase := secret.Data[PassphraseKey]
+
+	switch format {
+	case FormatOpenPGP, "":
+		return NewOpenPGPSigner(privateKey, passphrase)
+	case FormatSSH:
+		return NewSSHSigner(privateKey, passphrase)
+	default:
+		return nil, fmt.Errorf("unsupported signing format %q", format)
+	}
+}
+
+// SignCommit replaces the commit HEAD points to with a signed copy and moves the current
+// branch to it. The tree and parents are unchanged, so the worktree stays clean.
+func SignCommit(repo *git.Repository, hash plumbing.Hash, signer Signer) (plumbing.Hash, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if head.Hash() != hash {
+		return plumbing.ZeroHash, fmt.Errorf("commit %s to sign is not at HEAD", hash)
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	signature, err := sign(commit.EncodeWithoutSignature, signer)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to sign commit: %w", err)
+	}
+	commit.PGPSignature = signature
+
+	signed := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(signed); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	signedHash, err := repo.Storer.SetEncodedObject(signed)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), signedHash)); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return signedHash, nil
+}
+
+// SignTag adds a signature over the payload of an annotated tag that has not been stored yet
+func SignTag(tag *object.Tag, signer Signer) error {
+	signature, err := sign(tag.EncodeWithoutSignature, signer)
+	if err != nil {
+		return fmt.Errorf("failed to sign tag: %w", err)
+	}
+	tag.PGPSignature = signature
+	return nil
+}
+
+// sign signs the payload written by encode and returns the signature as stored in the object
+func sign(encode func(plumbing.EncodedObject) error, signer Signer) (string, error) {
+	payload := &plumbing.MemoryObject{}
+	if err := encode(payload); err != nil {
+		return "", err
+	}
+	reader, err := payload.Reader()
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := signer.Sign(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(signature)) + "\n", nil
+}
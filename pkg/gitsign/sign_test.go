@@ -0,0 +1,295 @@
+package gitsign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newCommit creates an in-memory repository with a single unsigned commit at HEAD
+func newCommit(t *testing.T) (*git.Repository, plumbing.Hash) {
+	t.Helper()
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	file, err := fs.Create("config.txt")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	file.Write([]byte("signed\n"))
+	file.Close()
+
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree() error = %v", err)
+	}
+	if _, err := w.Add("config.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	hash, err := w.Commit("Signed change", &git.CommitOptions{
+		Author: &object.Signature{Name: "Operator", Email: "operator@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	return repo, hash
+}
+
+// signHead signs the commit at HEAD and checks the branch moved to the signed commit
+func signHead(t *testing.T, signer Signer) *object.Commit {
+	t.Helper()
+	repo, hash := newCommit(t)
+
+	signedHash, err := SignCommit(repo, hash, signer)
+	if err != nil {
+		t.Fatalf("SignCommit() error = %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if head.Hash() != signedHash || head.Name() != plumbing.Master {
+		t.Fatalf("HEAD = %s %s, want master at %s", head.Name(), head.Hash(), signedHash)
+	}
+
+	commit, err := repo.CommitObject(signedHash)
+	if err != nil {
+		t.Fatalf("CommitObject() error = %v", err)
+	}
+	original, _ := repo.CommitObject(hash)
+	if commit.TreeHash != original.TreeHash || commit.Message != original.Message {
+		t.Errorf("Signing changed the commit: %+v", commit)
+	}
+	return commit
+}
+
+func newOpenPGPKey(t *testing.T, passphrase []byte) (private, public string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Operator", "", "operator@example.com", nil)
+	if err != nil {
+		t.Fatalf("NewEntity() error = %v", err)
+	}
+
+	var publicKey bytes.Buffer
+	w, _ := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	entity.Serialize(w)
+	w.Close()
+
+	if passphrase != nil {
+		if err := entity.EncryptPrivateKeys(passphrase, nil); err != nil {
+			t.Fatalf("EncryptPrivateKeys() error = %v", err)
+		}
+	}
+	var privateKey bytes.Buffer
+	w, _ = armor.Encode(&privateKey, openpgp.PrivateKeyType, nil)
+	entity.SerializePrivateWithoutSigning(w, nil)
+	w.Close()
+
+	return privateKey.String(), publicKey.String()
+}
+
+func TestOpenPGPSignature(t *testing.T) {
+	privateKey, publicKey := newOpenPGPKey(t, []byte("s3cret"))
+
+	if _, err := NewOpenPGPSigner([]byte(privateKey), nil); err == nil {
+		t.Error("Expected an error for an encrypted key without passphrase")
+	}
+	if _, err := NewOpenPGPSigner([]byte(privateKey), []byte("wrong")); err == nil {
+		t.Error("Expected an error for a wrong passphrase")
+	}
+
+	signer, err := FromSecret(&corev1.Secret{Data: map[string][]byte{
+		DefaultKeyKey: []byte(privateKey),
+		PassphraseKey: []byte("s3cret"),
+	}}, FormatOpenPGP, "")
+	if err != nil {
+		t.Fatalf("FromSecret() error = %v", err)
+	}
+
+	commit := signHead(t, signer)
+	if !strings.HasPrefix(commit.PGPSignature, "-----BEGIN PGP SIGNATURE-----") {
+		t.Fatalf("Unexpected signature: %q", commit.PGPSignature)
+	}
+	entity, err := commit.Verify(publicKey)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if _, ok := entity.Identities["Operator <operator@example.com>"]; !ok {
+		t.Errorf("Signed by %v", entity.Identities)
+	}
+
+	// A different key must not verify the signature
+	_, otherPublicKey := newOpenPGPKey(t, nil)
+	if _, err := commit.Verify(otherPublicKey); err == nil {
+		t.Error("Expected verification with another key to fail")
+	}
+}
+
+// verifySSHSignature checks an sshsig signature over the commit payload, as ssh-keygen -Y verify does
+func verifySSHSignature(t *testing.T, commit *object.Commit, publicKey ssh.PublicKey) {
+	t.Helper()
+	block, _ := pem.Decode([]byte(commit.PGPSignature))
+	if block == nil || block.Type != "SSH SIGNATURE" || !bytes.HasPrefix(block.Bytes, []byte("SSHSIG")) {
+		t.Fatalf("Unexpected signature: %q", commit.PGPSignature)
+	}
+
+	var blob sshSigBlob
+	if err := ssh.Unmarshal(block.Bytes[len("SSHSIG"):], &blob); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if blob.Version != 1 || blob.Namespace != "git" || blob.HashAlgorithm != "sha512" {
+		t.Errorf("Unexpected signature header: %+v", blob)
+	}
+	if !bytes.Equal(blob.PublicKey, publicKey.Marshal()) {
+		t.Errorf("Signature carries a different public key")
+	}
+
+	var signature ssh.Signature
+	if err := ssh.Unmarshal(blob.Signature, &signature); err != nil {
+		t.Fatalf("Unmarshal(signature) error = %v", err)
+	}
+	if signature.Format == ssh.KeyAlgoRSA {
+		t.Errorf("RSA keys must not sign with SHA-1")
+	}
+
+	payload := &plumbing.MemoryObject{}
+	commit.EncodeWithoutSignature(payload)
+	reader, _ := payload.Reader()
+	hash := sha512.New()
+	var content bytes.Buffer
+	content.ReadFrom(reader)
+	hash.Write(content.Bytes())
+
+	signedData := append([]byte("SSHSIG"), ssh.Marshal(sshSigSignedData{
+		Namespace:     "git",
+		HashAlgorithm: "sha512",
+		Hash:          hash.Sum(nil),
+	})...)
+	if err := publicKey.Verify(signedData, &signature); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestSSHSignature(t *testing.T) {
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		key        interface{}
+		passphrase []byte
+	}{
+		{name: "ed25519", key: ed25519Key},
+		{name: "ed25519 with passphrase", key: ed25519Key, passphrase: []byte("s3cret")},
+		{name: "rsa", key: rsaKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var block *pem.Block
+			if tt.passphrase != nil {
+				block, err = ssh.MarshalPrivateKeyWithPassphrase(tt.key, "", tt.passphrase)
+			} else {
+				block, err = ssh.MarshalPrivateKey(tt.key, "")
+			}
+			if err != nil {
+				t.Fatalf("MarshalPrivateKey() error = %v", err)
+			}
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "signing"},
+				Data:       map[string][]byte{"ssh-signing-key": pem.EncodeToMemory(block)},
+			}
+			if tt.passphrase != nil {
+				secret.Data[PassphraseKey] = tt.passphrase
+			}
+
+			signer, err := FromSecret(secret, FormatSSH, "ssh-signing-key")
+			if err != nil {
+				t.Fatalf("FromSecret() error = %v", err)
+			}
+
+			sshSigner, _ := ssh.NewSignerFromKey(tt.key)
+			verifySSHSignature(t, signHead(t, signer), sshSigner.PublicKey())
+		})
+	}
+}
+
+func TestSignTag(t *testing.T) {
+	privateKey, publicKey := newOpenPGPKey(t, nil)
+	signer, err := NewOpenPGPSigner([]byte(privateKey), nil)
+	if err != nil {
+		t.Fatalf("NewOpenPGPSigner() error = %v", err)
+	}
+
+	_, hash := newCommit(t)
+	tag := &object.Tag{
+		Name:       "v1.0.0",
+		Tagger:     object.Signature{Name: "Operator", Email: "operator@example.com", When: time.Now()},
+		Message:    "Release 1.0.0\n",
+		TargetType: plumbing.CommitObject,
+		Target:     hash,
+	}
+	if err := SignTag(tag, signer); err != nil {
+		t.Fatalf("SignTag() error = %v", err)
+	}
+	if !strings.HasPrefix(tag.PGPSignature, "-----BEGIN PGP SIGNATURE-----") {
+		t.Fatalf("Unexpected signature: %q", tag.PGPSignature)
+	}
+	if _, err := tag.Verify(publicKey); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestFromSecretErrors(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing"}, Data: map[string][]byte{"other": []byte("x")}}
+	if _, err := FromSecret(secret, FormatOpenPGP, ""); err == nil || !strings.Contains(err.Error(), "signing-key") {
+		t.Errorf("Expected a missing key error, got %v", err)
+	}
+	if _, err := FromSecret(secret, "x509", "other"); err == nil {
+		t.Error("Expected an unsupported format error")
+	}
+}
+
+func TestSignCommitRequiresHead(t *testing.T) {
+	repo, _ := newCommit(t)
+	signer, err := NewSSHSigner(mustMarshalEd25519(t), nil)
+	if err != nil {
+		t.Fatalf("NewSSHSigner() error = %v", err)
+	}
+	if _, err := SignCommit(repo, plumbing.NewHash("0123456789abcdef0123456789abcdef01234567"), signer); err == nil {
+		t.Error("Expected an error when the commit is not at HEAD")
+	}
+}
+
+func mustMarshalEd25519(t *testing.T) []byte {
+	t.Helper()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey() error = %v", err)
+	}
+	return pem.EncodeToMemory(block)
+}
@@ -0,0 +1,97 @@
+package gitsign
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSH signatures follow the sshsig format of OpenSSH (PROTOCOL.sshsig), which is what
+// git verifies with ssh-keygen -Y verify when gpg.format is ssh
+const (
+	sshSigMagic         = "SSHSIG"
+	sshSigVersion       = 1
+	sshSigNamespace     = "git"
+	sshSigHashAlgorithm = "sha512"
+	sshSigPEMType       = "SSH SIGNATURE"
+)
+
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+// NewSSHSigner parses an OpenSSH or PEM private key, decrypting it with passphrase if it is protected
+func NewSSHSigner(privateKey, passphrase []byte) (Signer, error) {
+	var signer ssh.Signer
+	var err error
+	if len(passphrase) > 0 {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(privateKey, passphrase)
+	} else {
+		signer, err = ssh.ParsePrivateKey(privateKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH signing key: %w", err)
+	}
+
+	return &sshSigner{signer: signer}, nil
+}
+
+// sshSigSignedData is the blob the key actually signs, prefixed with the magic preamble
+type sshSigSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// sshSigBlob is the armored signature, prefixed with the magic preamble
+type sshSigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	hash := sha512.New()
+	if _, err := io.Copy(hash, message); err != nil {
+		return nil, err
+	}
+
+	signedData := append([]byte(sshSigMagic), ssh.Marshal(sshSigSignedData{
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: sshSigHashAlgorithm,
+		Hash:          hash.Sum(nil),
+	})...)
+
+	signature, err := s.sign(signedData)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := append([]byte(sshSigMagic), ssh.Marshal(sshSigBlob{
+		Version:       sshSigVersion,
+		PublicKey:     s.signer.PublicKey().Marshal(),
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: sshSigHashAlgorithm,
+		Signature:     ssh.Marshal(signature),
+	})...)
+
+	return pem.EncodeToMemory(&pem.Block{Type: sshSigPEMType, Bytes: blob}), nil
+}
+
+// sign uses rsa-sha2-512 for RSA keys, OpenSSH rejects SHA-1 based ssh-rsa signatures
+func (s *sshSigner) sign(data []byte) (*ssh.Signature, error) {
+	if s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		if algorithmSigner, ok := s.signer.(ssh.AlgorithmSigner); ok {
+			return algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+		}
+	}
+	return s.signer.Sign(rand.Reader, data)
+}
@@ -0,0 +1,323 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergePatch applies an RFC 7386 JSON merge patch, written as JSON or YAML, to the document.
+// Null values remove keys, mappings are merged and all other values replace the existing ones.
+// An empty document is written as JSON when the patch is JSON.
+func MergePatch(data, mergePatch []byte) ([]byte, error) {
+	patchNode, err := value(mergePatch)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		doc.json = isJSON(mergePatch)
+	}
+	if isJSON(mergePatch) && !doc.json {
+		restyle(patchNode)
+	}
+
+	root := doc.root.Content[0]
+	if merged := merge(root, patchNode); merged != root {
+		replace(root, merged)
+	}
+	return doc.bytes()
+}
+
+// merge returns the target with the patch merged into it, target may be nil
+func merge(target, patchNode *yaml.Node) *yaml.Node {
+	if patchNode.Kind != yaml.MappingNode {
+		return patchNode
+	}
+	if target == nil || target.Kind != yaml.MappingNode {
+		target = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	for i := 0; i+1 < len(patchNode.Content); i += 2 {
+		key, v := patchNode.Content[i], patchNode.Content[i+1]
+		j := mappingIndex(target, key.Value)
+		switch {
+		case isNull(v):
+			if j >= 0 {
+				target.Content = append(target.Content[:j], target.Content[j+2:]...)
+			}
+		case j >= 0:
+			existing := target.Content[j+1]
+			if merged := merge(existing, v); merged != existing {
+				replace(existing, merged)
+			}
+		default:
+			target.Content = append(target.Content, newKey(key.Value), merge(nil, v))
+		}
+	}
+	return target
+}
+
+// operation is one operation of an RFC 6902 JSON Patch
+type operation struct {
+	Op    string    `yaml:"op"`
+	Path  string    `yaml:"path"`
+	From  string    `yaml:"from"`
+	Value yaml.Node `yaml:"value"`
+}
+
+// JSONPatch applies an RFC 6902 JSON Patch, written as JSON or YAML, to the document. The
+// operations are applied in order, the document is left unchanged when any of them fails.
+func JSONPatch(data, jsonPatch []byte) ([]byte, error) {
+	var operations []operation
+	if err := yaml.Unmarshal(jsonPatch, &operations); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON patch: %w", err)
+	}
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range operations {
+		if isJSON(jsonPatch) && !doc.json {
+			restyle(&op.Value)
+		}
+		if err := apply(doc.root, op); err != nil {
+			return nil, fmt.Errorf("JSON patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc.bytes()
+}
+
+func apply(root *yaml.Node, op operation) error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value.Kind == 0 {
+			return fmt.Errorf("missing value")
+		}
+	case "move", "copy":
+		if _, err := pointer(op.From); err != nil {
+			return err
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown operation")
+	}
+
+	switch op.Op {
+	case "add":
+		return add(root, op.Path, &op.Value)
+	case "remove":
+		_, err := remove(root, op.Path)
+		return err
+	case "replace":
+		node, err := get(root, op.Path)
+		if err != nil {
+			return err
+		}
+		replace(node, &op.Value)
+		return nil
+	case "move":
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		node, err := remove(root, op.From)
+		if err != nil {
+			return err
+		}
+		return add(root, op.Path, node)
+	case "copy":
+		node, err := get(root, op.From)
+		if err != nil {
+			return err
+		}
+		return add(root, op.Path, deepCopy(node))
+	default:
+		node, err := get(root, op.Path)
+		if err != nil {
+			return err
+		}
+		equal, err := equalValues(node, &op.Value)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return fmt.Errorf("test failed")
+		}
+		return nil
+	}
+}
+
+// pointer splits an RFC 6901 JSON pointer into its unescaped reference tokens
+func pointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// parent returns the node containing the location of the pointer and the last token,
+// the document node and an empty token for the root
+func parent(root *yaml.Node, path string) (*yaml.Node, string, error) {
+	tokens, err := pointer(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tokens) == 0 {
+		return root, "", nil
+	}
+
+	node := root.Content[0]
+	for i, token := range tokens[:len(tokens)-1] {
+		child, err := child(node, token)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", "/"+strings.Join(tokens[:i+1], "/"), err)
+		}
+		node = child
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node, tokens[len(tokens)-1], nil
+}
+
+// child returns the value of a mapping key or a list element
+func child(node *yaml.Node, token string) (*yaml.Node, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		if j := mappingIndex(node, token); j >= 0 {
+			return node.Content[j+1], nil
+		}
+		return nil, fmt.Errorf("key not found")
+	case yaml.SequenceNode:
+		index, err := listIndex(token, len(node.Content)-1)
+		if err != nil {
+			return nil, err
+		}
+		return node.Content[index], nil
+	default:
+		return nil, fmt.Errorf("not a mapping or list")
+	}
+}
+
+// listIndex parses the index of a list element, which must not be greater than max
+func listIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid list index %q", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("list index %d out of range", index)
+	}
+	return index, nil
+}
+
+func get(root *yaml.Node, path string) (*yaml.Node, error) {
+	node, token, err := parent(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if node == root {
+		return root.Content[0], nil
+	}
+	return child(node, token)
+}
+
+func add(root *yaml.Node, path string, v *yaml.Node) error {
+	node, token, err := parent(root, path)
+	if err != nil {
+		return err
+	}
+	if node == root {
+		replace(root.Content[0], v)
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if j := mappingIndex(node, token); j >= 0 {
+			replace(node.Content[j+1], v)
+		} else {
+			node.Content = append(node.Content, newKey(token), v)
+		}
+	case yaml.SequenceNode:
+		index := len(node.Content)
+		if token != "-" {
+			if index, err = listIndex(token, len(node.Content)); err != nil {
+				return err
+			}
+		}
+		node.Content = append(node.Content[:index], append([]*yaml.Node{v}, node.Content[index:]...)...)
+	default:
+		return fmt.Errorf("parent is not a mapping or list")
+	}
+	return nil
+}
+
+func remove(root *yaml.Node, path string) (*yaml.Node, error) {
+	node, token, err := parent(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if node == root {
+		return nil, fmt.Errorf("cannot remove the document")
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		j := mappingIndex(node, token)
+		if j < 0 {
+			return nil, fmt.Errorf("key not found")
+		}
+		removed := node.Content[j+1]
+		node.Content = append(node.Content[:j], node.Content[j+2:]...)
+		return removed, nil
+	case yaml.SequenceNode:
+		index, err := listIndex(token, len(node.Content)-1)
+		if err != nil {
+			return nil, err
+		}
+		removed := node.Content[index]
+		node.Content = append(node.Content[:index], node.Content[index+1:]...)
+		return removed, nil
+	default:
+		return nil, fmt.Errorf("parent is not a mapping or list")
+	}
+}
+
+// equalValues compares the JSON values of two nodes, so that 1 and 1.0 are equal
+func equalValues(a, b *yaml.Node) (bool, error) {
+	var values [2]interface{}
+	for i, node := range []*yaml.Node{a, b} {
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return false, err
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(out, &values[i]); err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(values[0], values[1]), nil
+}
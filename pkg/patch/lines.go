@@ -0,0 +1,125 @@
+package patch
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LineOptions shape files that grow by a line per entry, such as logs and CSV time series
+type LineOptions struct {
+	// Prepend adds the entries at the top, below the header, instead of at the end
+	Prepend bool
+
+	// Header is the first line of the file, written unless the file already starts with it
+	Header string
+
+	// DedupeKey is a regular expression extracting the key of an entry, its first capture group
+	// or else the whole match. A new entry replaces the existing entries with the same key.
+	DedupeKey string
+
+	// MaxLines and MaxBytes limit the number of entries and the size of the file, 0 is unlimited
+	MaxLines int
+	MaxBytes int
+}
+
+// AddLines adds every line of entries to the file. Line breaks are normalized so that each entry
+// is a line of its own, and the oldest entries beyond the limits are dropped.
+func AddLines(data, entries []byte, options LineOptions) ([]byte, error) {
+	var key *regexp.Regexp
+	if options.DedupeKey != "" {
+		var err error
+		if key, err = compile(options.DedupeKey); err != nil {
+			return nil, err
+		}
+	}
+
+	f := splitLines(data)
+	header, existing := options.split(f.lines)
+	added := splitLines(entries).lines
+	for i, line := range added {
+		added[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	if key != nil {
+		keys := make(map[string]bool, len(added))
+		for _, line := range added {
+			if k, ok := entryKey(key, line); ok {
+				keys[k] = true
+			}
+		}
+		var kept []string
+		for _, line := range existing {
+			if k, ok := entryKey(key, line); !ok || !keys[k] {
+				kept = append(kept, line)
+			}
+		}
+		existing = kept
+	}
+
+	var all []string
+	if options.Prepend {
+		all = append(append(all, added...), existing...)
+	} else {
+		all = append(append(all, existing...), added...)
+	}
+
+	// The oldest entries are at the top of appended files and at the bottom of prepended ones
+	for len(all) > 0 && options.exceeded(header, all, f.eol) {
+		if options.Prepend {
+			all = all[:len(all)-1]
+		} else {
+			all = all[1:]
+		}
+	}
+
+	f.lines = append(header, all...)
+	return f.bytes(), nil
+}
+
+// Exceeded reports whether the file has more entries or bytes than the limits allow
+func (o LineOptions) Exceeded(data []byte) bool {
+	f := splitLines(data)
+	header, entries := o.split(f.lines)
+	return o.exceeded(header, entries, f.eol)
+}
+
+// split returns the header, which is always present when configured, and the entries
+func (o LineOptions) split(lines []string) ([]string, []string) {
+	header := strings.TrimRight(o.Header, "\r\n")
+	if header == "" {
+		return nil, lines
+	}
+	if len(lines) > 0 && strings.TrimSuffix(lines[0], "\r") == header {
+		return []string{lines[0]}, lines[1:]
+	}
+	return []string{header}, lines
+}
+
+func (o LineOptions) exceeded(header, entries []string, eol string) bool {
+	if o.MaxLines > 0 && len(entries) > o.MaxLines {
+		return true
+	}
+	if o.MaxBytes > 0 {
+		size := 0
+		for _, lines := range [][]string{header, entries} {
+			for _, line := range lines {
+				size += len(line) + len(eol)
+			}
+		}
+		return size > o.MaxBytes
+	}
+	return false
+}
+
+// entryKey returns the key of an entry, false when the entry has none
+func entryKey(key *regexp.Regexp, line string) (string, bool) {
+	match := key.FindStringSubmatch(line)
+	switch {
+	case match == nil:
+		return "", false
+	case len(match) > 1:
+		return match[1], true
+	default:
+		return match[0], true
+	}
+}
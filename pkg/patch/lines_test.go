@@ -0,0 +1,101 @@
+package patch
+
+import (
+	"testing"
+)
+
+func TestAddLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		entries string
+		options LineOptions
+		want    string
+	}{
+		{
+			name:    "missing line break",
+			data:    "2024-01-01,41.0",
+			entries: "2024-01-02,42.5",
+			want:    "2024-01-01,41.0\n2024-01-02,42.5\n",
+		},
+		{
+			name:    "new file with header",
+			entries: "2024-01-01,41.0\n",
+			options: LineOptions{Header: "date,price"},
+			want:    "date,price\n2024-01-01,41.0\n",
+		},
+		{
+			name:    "header once",
+			data:    "date,price\n2024-01-01,41.0\n",
+			entries: "2024-01-02,42.5\n",
+			options: LineOptions{Header: "date,price\n"},
+			want:    "date,price\n2024-01-01,41.0\n2024-01-02,42.5\n",
+		},
+		{
+			name:    "prepend below header",
+			data:    "# Changelog\n- 1.0.0\n",
+			entries: "- 1.1.0\n",
+			options: LineOptions{Prepend: true, Header: "# Changelog"},
+			want:    "# Changelog\n- 1.1.0\n- 1.0.0\n",
+		},
+		{
+			name:    "dedupe by key",
+			data:    "date,price\n2024-01-01,41.0\n2024-01-02,40.0\n2024-01-03,43.0\n",
+			entries: "2024-01-02,42.5\n",
+			options: LineOptions{Header: "date,price", DedupeKey: "^([^,]+),"},
+			want:    "date,price\n2024-01-01,41.0\n2024-01-03,43.0\n2024-01-02,42.5\n",
+		},
+		{
+			name:    "max lines",
+			data:    "date,price\n2024-01-01,41.0\n2024-01-02,42.5\n",
+			entries: "2024-01-03,43.0\n",
+			options: LineOptions{Header: "date,price", MaxLines: 2},
+			want:    "date,price\n2024-01-02,42.5\n2024-01-03,43.0\n",
+		},
+		{
+			name:    "max lines of prepended file",
+			data:    "c\nb\na\n",
+			entries: "d",
+			options: LineOptions{Prepend: true, MaxLines: 3},
+			want:    "d\nc\nb\n",
+		},
+		{
+			name:    "max bytes",
+			data:    "aaaa\nbbbb\n",
+			entries: "cccc\n",
+			options: LineOptions{MaxBytes: 12},
+			want:    "bbbb\ncccc\n",
+		},
+		{
+			name:    "crlf",
+			data:    "a\r\n",
+			entries: "b\r\n",
+			want:    "a\r\nb\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddLines([]byte(tt.data), []byte(tt.entries), tt.options)
+			if err != nil {
+				t.Fatalf("AddLines() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("AddLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineOptionsExceeded(t *testing.T) {
+	options := LineOptions{Header: "date,price", MaxLines: 2}
+	if options.Exceeded([]byte("date,price\na\nb\n")) {
+		t.Errorf("Exceeded() = true, the header is not an entry")
+	}
+	if !options.Exceeded([]byte("date,price\na\nb\nc\n")) {
+		t.Errorf("Exceeded() = false for three entries")
+	}
+	if !(LineOptions{MaxBytes: 3}).Exceeded([]byte("abc\n")) {
+		t.Errorf("Exceeded() = false for four bytes")
+	}
+}
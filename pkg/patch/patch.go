@@ -0,0 +1,276 @@
+// Package patch edits files in place. YAML and JSON documents are edited with JSON Patch, JSON
+// merge patch and YAML path expressions as YAML nodes, so comments and key order are kept. Other
+// text files are edited with regular expressions and line edits, and files with an entry per line
+// grow with headers, deduplication and size limits.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is a parsed YAML or JSON document and the formatting needed to write it back
+type document struct {
+	root      *yaml.Node
+	original  []byte
+	json      bool
+	indent    string
+	separator bool
+}
+
+// parse reads a single YAML or JSON document. An empty document is an empty mapping.
+func parse(data []byte) (*document, error) {
+	doc := &document{
+		original:  data,
+		json:      isJSON(data),
+		indent:    detectIndent(data),
+		separator: bytes.HasPrefix(data, []byte("---")),
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document: %w", err)
+		}
+		if doc.root != nil {
+			return nil, fmt.Errorf("files with multiple YAML documents are not supported")
+		}
+		doc.root = &node
+	}
+
+	if doc.root == nil || len(doc.root.Content) == 0 {
+		doc.root = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	return doc, nil
+}
+
+// bytes writes the document in its original format
+func (d *document) bytes() ([]byte, error) {
+	if d.json {
+		var compact bytes.Buffer
+		if err := writeJSON(&compact, d.root.Content[0]); err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, compact.Bytes(), "", d.indent); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}
+
+	var out bytes.Buffer
+	if d.separator {
+		out.WriteString("---\n")
+	}
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(len(strings.ReplaceAll(d.indent, "\t", "  ")))
+	if err := encoder.Encode(d.root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return restoreBlankLines(d.original, out.Bytes()), nil
+}
+
+// restoreBlankLines inserts the blank lines of the original document, which the YAML encoder
+// drops, before the same lines of the output. Lines are matched in order, a changed line is
+// matched by its key when it directly follows the previous match.
+func restoreBlankLines(original, out []byte) []byte {
+	lines := strings.SplitAfter(string(out), "\n")
+	blanks := make([]int, len(lines))
+
+	next, pending := 0, 0
+	for _, line := range strings.SplitAfter(string(original), "\n") {
+		if strings.TrimSpace(line) == "" {
+			pending++
+			continue
+		}
+		match := -1
+		for i := next; i < len(lines); i++ {
+			if lines[i] == line {
+				match = i
+				break
+			}
+		}
+		if match < 0 && next < len(lines) && lineKey(lines[next]) != "" && lineKey(lines[next]) == lineKey(line) {
+			match = next
+		}
+		if match >= 0 {
+			blanks[match] = pending
+			next = match + 1
+		}
+		pending = 0
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(strings.Repeat("\n", blanks[i]))
+		b.WriteString(line)
+	}
+	return []byte(b.String())
+}
+
+// lineKey returns the indentation and key of a mapping line, empty for other lines
+func lineKey(line string) string {
+	if i := strings.Index(line, ":"); i > 0 && !strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return line[:i]
+	}
+	return ""
+}
+
+// value parses a YAML or JSON value, an empty value is null
+func value(data []byte) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse value: %w", err)
+	}
+	if len(node.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	return node.Content[0], nil
+}
+
+// isJSON reports whether the document is JSON rather than YAML
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// detectIndent returns the indentation of the first indented line, two spaces if there is none
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		return line[:len(line)-len(trimmed)]
+	}
+	return "  "
+}
+
+// writeJSON writes the node as compact JSON, keeping the order of mapping keys
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, node.Content[i].Value); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		// Numbers are written as they are so that 1.50 stays 1.50
+		if (node.Tag == "!!int" || node.Tag == "!!float") && json.Valid([]byte(node.Value)) {
+			buf.WriteString(node.Value)
+			return nil
+		}
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+		return writeJSONValue(buf, v)
+	}
+	return nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// replace puts value in place of node, keeping the comments of node unless value has its own.
+// A string replacing a quoted string is quoted the same way.
+func replace(node, value *yaml.Node) {
+	replacement := *value
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && replacement.Kind == yaml.ScalarNode &&
+		replacement.Tag == "!!str" && replacement.Style == 0 {
+		replacement.Style = node.Style
+	}
+	if replacement.HeadComment == "" {
+		replacement.HeadComment = node.HeadComment
+	}
+	if replacement.LineComment == "" {
+		replacement.LineComment = node.LineComment
+	}
+	if replacement.FootComment == "" {
+		replacement.FootComment = node.FootComment
+	}
+	*node = replacement
+}
+
+// restyle drops the flow style and quotes of a value written as JSON, so that it is written
+// to a YAML document in block style. Strings are still quoted where YAML requires it.
+func restyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		restyle(child)
+	}
+}
+
+// mappingIndex returns the index of the key in the content of the mapping, -1 if it is missing
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func newKey(key string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+}
+
+// deepCopy copies the node and its children
+func deepCopy(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = deepCopy(child)
+	}
+	return &copied
+}
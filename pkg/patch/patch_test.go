@@ -0,0 +1,235 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+)
+
+const values = `# Default values for frontend
+replicaCount: 1
+
+image:
+  repository: registry.example.com/frontend # the image
+  # Overridden by the release pipeline
+  tag: "1.19.0"
+  pullPolicy: IfNotPresent
+
+containers:
+  - name: sidecar
+    image: proxy:2.0
+  - name: app
+    image: frontend:1.19.0 # app image
+`
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		path    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "quoted scalar",
+			data:  values,
+			path:  "image.tag",
+			value: "1.20",
+			want:  strings.Replace(values, `tag: "1.19.0"`, `tag: "1.20"`, 1),
+		},
+		{
+			name:  "selector",
+			data:  values,
+			path:  "containers[name=app].image",
+			value: "frontend:1.20.0",
+			want:  strings.Replace(values, "image: frontend:1.19.0 # app image", "image: frontend:1.20.0 # app image", 1),
+		},
+		{
+			name:  "index",
+			data:  values,
+			path:  "containers[0].image",
+			value: "proxy:2.1",
+			want:  strings.Replace(values, "proxy:2.0", "proxy:2.1", 1),
+		},
+		{
+			name:  "new keys",
+			data:  "replicaCount: 1\n",
+			path:  "resources.limits.memory",
+			value: "256Mi",
+			want:  "replicaCount: 1\nresources:\n  limits:\n    memory: 256Mi\n",
+		},
+		{
+			name:  "mapping value",
+			data:  "image: {}\n",
+			path:  "image",
+			value: "repository: frontend\ntag: latest\n",
+			want:  "image:\n  repository: frontend\n  tag: latest\n",
+		},
+		{
+			name:  "new file",
+			data:  "",
+			path:  "image.tag",
+			value: "v1",
+			want:  "image:\n  tag: v1\n",
+		},
+		{
+			name:  "json",
+			data:  "{\n    \"name\": \"frontend\",\n    \"version\": \"1.0.0\",\n    \"private\": true\n}\n",
+			path:  "version",
+			value: "1.1.0",
+			want:  "{\n    \"name\": \"frontend\",\n    \"version\": \"1.1.0\",\n    \"private\": true\n}\n",
+		},
+		{name: "missing element", data: values, path: "containers[name=worker].image", value: "x", wantErr: "containers has no element with name=worker"},
+		{name: "index out of range", data: values, path: "containers[2].image", value: "x", wantErr: "containers has no element 2"},
+		{name: "not a mapping", data: values, path: "replicaCount.value", value: "x", wantErr: "replicaCount is not a mapping"},
+		{name: "invalid path", data: values, path: "image..tag", value: "x", wantErr: "invalid YAML path"},
+		{name: "invalid selector", data: values, path: "containers[app]", value: "x", wantErr: "invalid selector [app]"},
+		{name: "multiple documents", data: "a: 1\n---\nb: 2\n", path: "a", value: "2", wantErr: "multiple YAML documents"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetPath([]byte(tt.data), tt.path, []byte(tt.value))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetPath() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetPath() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SetPath() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		patch string
+		want  string
+	}{
+		{
+			name:  "yaml patch",
+			data:  values,
+			patch: "image:\n  tag: \"1.20.0\"\n  pullPolicy: null\nreplicaCount: 3\n",
+			want: strings.NewReplacer(`tag: "1.19.0"`, `tag: "1.20.0"`, "  pullPolicy: IfNotPresent\n", "",
+				"replicaCount: 1", "replicaCount: 3").Replace(values),
+		},
+		{
+			name:  "json patch into yaml",
+			data:  "# settings\nimage:\n  tag: v1 # pinned\n",
+			patch: `{"image": {"tag": "v2", "digest": "sha256:abc"}, "labels": {"team": "web"}}`,
+			want:  "# settings\nimage:\n  tag: v2 # pinned\n  digest: sha256:abc\nlabels:\n  team: web\n",
+		},
+		{
+			name:  "replace list",
+			data:  "args: [a, b]\n",
+			patch: "args: [c]\n",
+			want:  "args: [c]\n",
+		},
+		{
+			name:  "json",
+			data:  "{\n  \"b\": 1,\n  \"a\": {\"x\": 1.50}\n}\n",
+			patch: `{"a": {"y": "<none>"}}`,
+			want:  "{\n  \"b\": 1,\n  \"a\": {\n    \"x\": 1.50,\n    \"y\": \"<none>\"\n  }\n}\n",
+		},
+		{
+			name:  "new json file",
+			data:  "",
+			patch: `{"version": "1.0.0", "removed": null}`,
+			want:  "{\n  \"version\": \"1.0.0\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.data), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MergePatch() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "operations",
+			data: values,
+			patch: `[
+				{"op": "test", "path": "/replicaCount", "value": 1.0},
+				{"op": "replace", "path": "/image/tag", "value": "1.20.0"},
+				{"op": "remove", "path": "/containers/0"},
+				{"op": "add", "path": "/containers/-", "value": {"name": "worker", "image": "worker:1.0"}},
+				{"op": "copy", "from": "/image/repository", "path": "/repository"},
+				{"op": "move", "from": "/image/pullPolicy", "path": "/pullPolicy"}
+			]`,
+			want: `# Default values for frontend
+replicaCount: 1
+
+image:
+  repository: registry.example.com/frontend # the image
+  # Overridden by the release pipeline
+  tag: "1.20.0"
+
+containers:
+  - name: app
+    image: frontend:1.19.0 # app image
+  - name: worker
+    image: worker:1.0
+repository: registry.example.com/frontend # the image
+pullPolicy: IfNotPresent
+`,
+		},
+		{
+			name:  "yaml operations",
+			data:  "items: [a, c]\n",
+			patch: "- op: add\n  path: /items/1\n  value: b\n- op: add\n  path: /a~1b\n  value: slash\n",
+			want:  "items: [a, b, c]\na/b: slash\n",
+		},
+		{
+			name:  "json",
+			data:  "{\"items\": [1, 2]}",
+			patch: `[{"op": "add", "path": "/items/0", "value": 0}, {"op": "add", "path": "/name", "value": "x"}]`,
+			want:  "{\n  \"items\": [\n    0,\n    1,\n    2\n  ],\n  \"name\": \"x\"\n}\n",
+		},
+		{name: "failed test", data: values, patch: `[{"op": "test", "path": "/image/tag", "value": "1.18"}]`, wantErr: "operation 0 (test /image/tag): test failed"},
+		{name: "missing key", data: values, patch: `[{"op": "replace", "path": "/image/digest", "value": "x"}]`, wantErr: "key not found"},
+		{name: "missing parent", data: values, patch: `[{"op": "add", "path": "/resources/limits", "value": "x"}]`, wantErr: "/resources: key not found"},
+		{name: "index out of range", data: values, patch: `[{"op": "remove", "path": "/containers/2"}]`, wantErr: "list index 2 out of range"},
+		{name: "missing value", data: values, patch: `[{"op": "add", "path": "/x"}]`, wantErr: "missing value"},
+		{name: "unknown operation", data: values, patch: `[{"op": "merge", "path": "/x", "value": 1}]`, wantErr: "unknown operation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(tt.data), []byte(tt.patch))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("JSONPatch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONPatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("JSONPatch() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// segment is one step of a YAML path: a mapping key, a list index or a list element selected
+// by the value of one of its fields
+type segment struct {
+	text  string
+	key   string
+	index int
+	field string
+	value string
+}
+
+func (s segment) isKey() bool      { return s.text == s.key }
+func (s segment) isSelector() bool { return s.field != "" }
+
+// parsePath splits a path like spec.containers[name=app].image or items[0] into segments
+func parsePath(path string) ([]segment, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("empty YAML path")
+	}
+
+	var segments []segment
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			return nil, fmt.Errorf("invalid YAML path %q", path)
+		}
+		key := part
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+		}
+		if key != "" {
+			segments = append(segments, segment{text: key, key: key})
+		}
+
+		rest := part[len(key):]
+		for rest != "" {
+			end := strings.Index(rest, "]")
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid YAML path %q", path)
+			}
+			selector := rest[1:end]
+			s := segment{text: rest[:end+1]}
+			if field, value, ok := strings.Cut(selector, "="); ok && field != "" {
+				s.field, s.value = field, strings.Trim(value, `"'`)
+			} else if index, err := strconv.Atoi(selector); err == nil && index >= 0 {
+				s.index = index
+			} else {
+				return nil, fmt.Errorf("invalid selector %s in YAML path %q", s.text, path)
+			}
+			segments = append(segments, s)
+			rest = rest[end+1:]
+		}
+	}
+	return segments, nil
+}
+
+// SetPath sets the value at the YAML path in the document. Missing mapping keys are created,
+// list elements are selected by index, e.g. items[0], or by a field, e.g. containers[name=app].
+// The value is parsed as YAML.
+func SetPath(data []byte, path string, v []byte) ([]byte, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := value(v)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	node := doc.root.Content[0]
+	for i, s := range segments {
+		location := joinSegments(segments[:i])
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		var next *yaml.Node
+		switch {
+		case s.isKey():
+			if isNull(node) {
+				*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", LineComment: node.LineComment}
+			}
+			if node.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s is not a mapping", location)
+			}
+			j := mappingIndex(node, s.key)
+			if j < 0 {
+				next = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+				node.Content = append(node.Content, newKey(s.key), next)
+			} else {
+				next = node.Content[j+1]
+			}
+		case s.isSelector():
+			if node.Kind != yaml.SequenceNode {
+				return nil, fmt.Errorf("%s is not a list", location)
+			}
+			for _, item := range node.Content {
+				if j := mappingIndex(item, s.field); item.Kind == yaml.MappingNode && j >= 0 && item.Content[j+1].Value == s.value {
+					next = item
+					break
+				}
+			}
+			if next == nil {
+				return nil, fmt.Errorf("%s has no element with %s=%s", location, s.field, s.value)
+			}
+		default:
+			if node.Kind != yaml.SequenceNode {
+				return nil, fmt.Errorf("%s is not a list", location)
+			}
+			if s.index >= len(node.Content) {
+				return nil, fmt.Errorf("%s has no element %d", location, s.index)
+			}
+			next = node.Content[s.index]
+		}
+		node = next
+	}
+
+	// A quoted scalar stays a quoted string so that tag: "1.20" does not turn into a number
+	if node.Kind == yaml.ScalarNode && newValue.Kind == yaml.ScalarNode && newValue.Style == 0 &&
+		node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 && !isNull(newValue) {
+		newValue.Style = node.Style
+		newValue.Tag = "!!str"
+	}
+	replace(node, newValue)
+	return doc.bytes()
+}
+
+func joinSegments(segments []segment) string {
+	if len(segments) == 0 {
+		return "the document"
+	}
+	var b strings.Builder
+	for i, s := range segments {
+		if i > 0 && s.isKey() {
+			b.WriteByte('.')
+		}
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNoMatch is returned when the pattern or marker of a text edit matches nothing
+var ErrNoMatch = errors.New("pattern matches nothing")
+
+// Replace replaces every match of the regular expression in the text. The replacement may
+// refer to capture groups as $1 or ${name}.
+func Replace(data []byte, pattern, replacement string) ([]byte, error) {
+	re, err := compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if !re.Match(data) {
+		return nil, ErrNoMatch
+	}
+	return re.ReplaceAll(data, []byte(replacement)), nil
+}
+
+// InsertBefore inserts the text as lines before the first line matching the marker pattern.
+// Nothing is inserted when the lines are already there.
+func InsertBefore(data []byte, marker, text string) ([]byte, error) {
+	return insert(data, marker, text, false)
+}
+
+// InsertAfter inserts the text as lines after the first line matching the marker pattern.
+// Nothing is inserted when the lines are already there.
+func InsertAfter(data []byte, marker, text string) ([]byte, error) {
+	return insert(data, marker, text, true)
+}
+
+func insert(data []byte, marker, text string, after bool) ([]byte, error) {
+	re, err := compile(marker)
+	if err != nil {
+		return nil, err
+	}
+	f := splitLines(data)
+	inserted := splitLines([]byte(text)).lines
+
+	for i, line := range f.lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		at := i
+		if after {
+			at = i + 1
+		}
+		if !f.contains(at, inserted, after) {
+			f.lines = append(f.lines[:at], append(inserted, f.lines[at:]...)...)
+		}
+		return f.bytes(), nil
+	}
+	return nil, ErrNoMatch
+}
+
+// EnsureLine makes sure the line is in the text. When the line is missing it replaces the
+// first line matching the pattern or, without a match, is appended.
+func EnsureLine(data []byte, pattern, line string) ([]byte, error) {
+	var re *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if re, err = compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+	line = strings.TrimRight(line, "\r\n")
+	f := splitLines(data)
+
+	match := -1
+	for i, l := range f.lines {
+		if l == line {
+			return data, nil
+		}
+		if match < 0 && re != nil && re.MatchString(l) {
+			match = i
+		}
+	}
+	if match >= 0 {
+		f.lines[match] = line
+	} else {
+		f.lines = append(f.lines, line)
+	}
+	return f.bytes(), nil
+}
+
+// RemoveLine removes the lines matching the pattern, or equal to line when there is no pattern
+func RemoveLine(data []byte, pattern, line string) ([]byte, error) {
+	line = strings.TrimRight(line, "\r\n")
+	matches := func(l string) bool { return l == line }
+	if pattern != "" || line == "" {
+		re, err := compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matches = re.MatchString
+	}
+
+	f := splitLines(data)
+	kept := f.lines[:0]
+	for _, l := range f.lines {
+		if !matches(l) {
+			kept = append(kept, l)
+		}
+	}
+	f.lines = kept
+	return f.bytes(), nil
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// lines is a text split into lines without their line endings
+type lines struct {
+	lines []string
+	eol   string
+}
+
+// splitLines splits the text into lines, keeping its CRLF line endings
+func splitLines(data []byte) *lines {
+	text := string(data)
+	f := &lines{eol: "\n"}
+	if strings.Contains(text, "\r\n") {
+		f.eol = "\r\n"
+	}
+	text = strings.TrimSuffix(text, f.eol)
+	if text != "" {
+		f.lines = strings.Split(text, f.eol)
+	}
+	return f
+}
+
+// bytes joins the lines, every line including the last one ends with a line break
+func (f *lines) bytes() []byte {
+	if len(f.lines) == 0 {
+		return []byte{}
+	}
+	return []byte(strings.Join(f.lines, f.eol) + f.eol)
+}
+
+// contains reports whether the inserted lines are already in place, ending before index at
+// when after is false and starting at index at when after is true
+func (f *lines) contains(at int, inserted []string, after bool) bool {
+	start := at
+	if !after {
+		start = at - len(inserted)
+	}
+	if start < 0 || start+len(inserted) > len(f.lines) {
+		return false
+	}
+	for i, line := range inserted {
+		if f.lines[start+i] != line {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package patch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const dockerfile = `FROM node:18-alpine AS build
+WORKDIR /app
+# BEGIN dependencies
+RUN npm ci
+# END dependencies
+CMD ["node", "server.js"]
+`
+
+func TestTextEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(data []byte) ([]byte, error)
+		data    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "replace with capture groups",
+			edit: func(data []byte) ([]byte, error) {
+				return Replace(data, `(?m)^FROM node:\d+(-\w+)?`, "FROM node:20$1")
+			},
+			data: dockerfile,
+			want: strings.Replace(dockerfile, "node:18-alpine", "node:20-alpine", 1),
+		},
+		{
+			name: "replace with named groups",
+			edit: func(data []byte) ([]byte, error) {
+				return Replace(data, `(?P<key>VERSION)=\S+`, "${key}=1.4.0")
+			},
+			data: "NAME=frontend\nVERSION=1.3.0\n",
+			want: "NAME=frontend\nVERSION=1.4.0\n",
+		},
+		{
+			name: "replace without match",
+			edit: func(data []byte) ([]byte, error) {
+				return Replace(data, `^FROM python`, "FROM python:3.12")
+			},
+			data:    dockerfile,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name: "insert before",
+			edit: func(data []byte) ([]byte, error) {
+				return InsertBefore(data, `^# END dependencies`, "RUN npm prune --production\n")
+			},
+			data: dockerfile,
+			want: strings.Replace(dockerfile, "# END", "RUN npm prune --production\n# END", 1),
+		},
+		{
+			name: "insert before again",
+			edit: func(data []byte) ([]byte, error) {
+				return InsertBefore(data, `^# END dependencies`, "RUN npm ci")
+			},
+			data: dockerfile,
+			want: dockerfile,
+		},
+		{
+			name: "insert after",
+			edit: func(data []byte) ([]byte, error) {
+				return InsertAfter(data, `^WORKDIR`, "COPY package*.json ./\nCOPY . .")
+			},
+			data: dockerfile,
+			want: strings.Replace(dockerfile, "WORKDIR /app\n", "WORKDIR /app\nCOPY package*.json ./\nCOPY . .\n", 1),
+		},
+		{
+			name: "insert after without match",
+			edit: func(data []byte) ([]byte, error) {
+				return InsertAfter(data, `^EXPOSE`, "HEALTHCHECK NONE")
+			},
+			data:    dockerfile,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name: "ensure line replaces the match",
+			edit: func(data []byte) ([]byte, error) {
+				return EnsureLine(data, `^LOG_LEVEL=`, "LOG_LEVEL=debug")
+			},
+			data: "PORT=8080\nLOG_LEVEL=info\n",
+			want: "PORT=8080\nLOG_LEVEL=debug\n",
+		},
+		{
+			name: "ensure line appends",
+			edit: func(data []byte) ([]byte, error) {
+				return EnsureLine(data, `^LOG_LEVEL=`, "LOG_LEVEL=debug")
+			},
+			data: "PORT=8080",
+			want: "PORT=8080\nLOG_LEVEL=debug\n",
+		},
+		{
+			name: "ensure present line",
+			edit: func(data []byte) ([]byte, error) {
+				return EnsureLine(data, "", "PORT=8080")
+			},
+			data: "PORT=8080\r\nLOG_LEVEL=info",
+			want: "PORT=8080\r\nLOG_LEVEL=info",
+		},
+		{
+			name: "ensure line keeps CRLF",
+			edit: func(data []byte) ([]byte, error) {
+				return EnsureLine(data, "", "DEBUG=1")
+			},
+			data: "PORT=8080\r\n",
+			want: "PORT=8080\r\nDEBUG=1\r\n",
+		},
+		{
+			name: "remove lines by pattern",
+			edit: func(data []byte) ([]byte, error) {
+				return RemoveLine(data, `^\s*#`, "")
+			},
+			data: dockerfile,
+			want: "FROM node:18-alpine AS build\nWORKDIR /app\nRUN npm ci\nCMD [\"node\", \"server.js\"]\n",
+		},
+		{
+			name: "remove absent line",
+			edit: func(data []byte) ([]byte, error) {
+				return RemoveLine(data, "", "DEBUG=1")
+			},
+			data: "PORT=8080\n",
+			want: "PORT=8080\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.edit([]byte(tt.data))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextEditPatterns(t *testing.T) {
+	if _, err := Replace([]byte("a"), "(", "b"); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("Replace() error = %v, want an invalid pattern", err)
+	}
+	if _, err := RemoveLine([]byte("a\n\nb\n"), "", ""); err == nil || !strings.Contains(err.Error(), "empty pattern") {
+		t.Errorf("RemoveLine() error = %v, want an empty pattern", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Package render renders the templated fields of GitCommit and PullRequest resources with Go templates
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	sprig "github.com/go-task/slim-sprig"
+	"sigs.k8s.io/yaml"
+)
+
+// helpers complement the slim-sprig functions
+var helpers = template.FuncMap{
+	"short": func(sha string) string {
+		if len(sha) > 7 {
+			return sha[:7]
+		}
+		return sha
+	},
+	"toYaml": func(v interface{}) (string, error) {
+		out, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(out), "\n"), err
+	},
+	"fromYaml": func(s string) (interface{}, error) {
+		var v interface{}
+		err := yaml.Unmarshal([]byte(s), &v)
+		return v, err
+	},
+}
+
+// funcs are the helpers available in addition to the text/template builtins: the slim-sprig
+// functions, except for those reading the environment or the network, and the helpers above
+var funcs = func() template.FuncMap {
+	f := sprig.TxtFuncMap()
+	for _, name := range []string{"env", "expandenv", "getHostByName"} {
+		delete(f, name)
+	}
+	for name, fn := range helpers {
+		f[name] = fn
+	}
+	return f
+}()
+
+// String renders text as a Go template. Referencing a missing key is an error so that typos
+// do not end up in the repository. Text without template actions is returned unchanged.
+func String(name, text string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
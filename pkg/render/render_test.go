@@ -0,0 +1,65 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestString(t *testing.T) {
+	data := map[string]interface{}{
+		"time":           time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		"metadata":       map[string]interface{}{"name": "daily-report", "labels": map[string]string{}},
+		"files":          []string{"a.txt", "b.txt"},
+		"previousCommit": "0123456789abcdef",
+		"restAPIs": map[string]interface{}{
+			"prices": map[string]interface{}{
+				"formattedOutput": "42.5",
+				"extractedData":   map[string]interface{}{"value": 42.5},
+			},
+			"tags": map[string]interface{}{
+				"extractedData": []interface{}{"stable", "latest"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{name: "static text", text: "Update config", want: "Update config"},
+		{name: "time", text: "Report for {{ date \"2006-01-02\" .time }}", want: "Report for 2024-01-15"},
+		{name: "metadata", text: "{{ .metadata.name | upper }}", want: "DAILY-REPORT"},
+		{name: "files", text: "Update {{ join \", \" .files }}", want: "Update a.txt, b.txt"},
+		{name: "previous commit", text: "Follows {{ short .previousCommit }}", want: "Follows 0123456"},
+		{name: "rest api", text: "Price {{ .restAPIs.prices.formattedOutput }} ({{ .restAPIs.prices.extractedData.value }})", want: "Price 42.5 (42.5)"},
+		{name: "join decoded json", text: "Tags {{ .restAPIs.tags.extractedData | join \",\" }}", want: "Tags stable,latest"},
+		{name: "default of zero value", text: "{{ 0 | default 3 }} {{ false | default true }}", want: "3 true"},
+		{name: "default", text: "{{ index .metadata.labels \"team\" | default \"none\" }}", want: "none"},
+		{name: "sprig", text: "{{ .metadata.name | trimPrefix \"daily-\" | quote }}", want: "\"report\""},
+		{name: "yaml", text: "data:\n{{ .restAPIs.prices.extractedData | toYaml | indent 2 }}", want: "data:\n  value: 42.5"},
+		{name: "environment", text: "{{ env \"HOME\" }}", wantErr: "function \"env\" not defined"},
+		{name: "missing key", text: "{{ .metadata.nmae }}", wantErr: "map has no entry for key"},
+		{name: "parse error", text: "{{ .metadata.name ", wantErr: "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := String("commitMessage", tt.text, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("String() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
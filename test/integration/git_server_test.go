@@ -0,0 +1,453 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newBareRepository creates a bare repository under root seeded with a single commit on main
+func newBareRepository(root, name string) (string, error) {
+	barePath := filepath.Join(root, name)
+	if _, err := git.PlainInit(barePath, true); err != nil {
+		return "", err
+	}
+
+	workPath, err := os.MkdirTemp("", "seed-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workPath)
+
+	repo, err := git.PlainInit(workPath, false)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(workPath, "README.md"), []byte("# seed\n"), 0644); err != nil {
+		return "", err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Add("README.md"); err != nil {
+		return "", err
+	}
+	if _, err := w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Seed", Email: "seed@example.com", When: time.Now()},
+	}); err != nil {
+		return "", err
+	}
+
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{barePath}}); err != nil {
+		return "", err
+	}
+	if err := repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"refs/heads/master:refs/heads/main"},
+	}); err != nil {
+		return "", err
+	}
+
+	bare, err := git.PlainOpen(barePath)
+	if err != nil {
+		return "", err
+	}
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))
+	if err := bare.Storer.SetReference(head); err != nil {
+		return "", err
+	}
+
+	return barePath, nil
+}
+
+// readCommittedFile returns the content of path at the tip of branch in the bare repository
+func readCommittedFile(barePath, branch, path string) (string, string, error) {
+	repo, err := git.PlainOpen(barePath)
+	if err != nil {
+		return "", "", err
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return "", "", err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return "", "", err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return "", "", err
+	}
+	return content, ref.Hash().String(), nil
+}
+
+// committedFileMode returns the git file mode of the file at path on branch of the bare repository
+func committedFileMode(barePath, branch, path string) (filemode.FileMode, error) {
+	repo, err := git.PlainOpen(barePath)
+	if err != nil {
+		return filemode.Empty, err
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return filemode.Empty, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return filemode.Empty, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return filemode.Empty, err
+	}
+	return file.Mode, nil
+}
+
+// newSSHClientKey generates an ed25519 key pair and returns the PEM encoded private key
+func newSSHClientKey() ([]byte, ssh.PublicKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(block), sshPub, nil
+}
+
+// sshGitServer serves the repositories below root over SSH by running
+// git-upload-pack and git-receive-pack for authorized clients
+type sshGitServer struct {
+	listener      net.Listener
+	root          string
+	hostKey       ssh.Signer
+	authorizedKey ssh.PublicKey
+}
+
+func startSSHGitServer(root string, authorizedKey ssh.PublicKey) (*sshGitServer, error) {
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sshGitServer{
+		listener:      listener,
+		root:          root,
+		hostKey:       hostKey,
+		authorizedKey: authorizedKey,
+	}
+	go s.serve()
+	return s, nil
+}
+
+// URL returns the ssh:// URL of a repository served from the server root
+func (s *sshGitServer) URL(name string) string {
+	return fmt.Sprintf("ssh://git@%s/%s", s.listener.Addr().String(), name)
+}
+
+// KnownHosts returns a known_hosts line matching the server host key
+func (s *sshGitServer) KnownHosts() string {
+	return knownhosts.Line([]string{knownhosts.Normalize(s.listener.Addr().String())}, s.hostKey.PublicKey()) + "\n"
+}
+
+func (s *sshGitServer) Close() error {
+	return s.listener.Close()
+}
+
+func (s *sshGitServer) serve() {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), s.authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unauthorized key for %s", conn.User())
+		},
+	}
+	config.AddHostKey(s.hostKey)
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn, config)
+	}
+}
+
+func (s *sshGitServer) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(channel, requests)
+	}
+}
+
+func (s *sshGitServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for req := range requests {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			return
+		}
+		req.Reply(true, nil)
+
+		status := s.runGitCommand(payload.Command, channel)
+		exitStatus := make([]byte, 4)
+		binary.BigEndian.PutUint32(exitStatus, status)
+		channel.SendRequest("exit-status", false, exitStatus)
+		return
+	}
+}
+
+func (s *sshGitServer) runGitCommand(command string, channel ssh.Channel) uint32 {
+	fields := strings.SplitN(command, " ", 2)
+	if len(fields) != 2 || (fields[0] != "git-upload-pack" && fields[0] != "git-receive-pack") {
+		fmt.Fprintf(channel.Stderr(), "unsupported command: %s\n", command)
+		return 1
+	}
+	repoPath := filepath.Join(s.root, filepath.Clean("/"+strings.Trim(fields[1], "'")))
+
+	cmd := exec.Command(fields[0], repoPath)
+	cmd.Stdout = channel
+	cmd.Stderr = channel.Stderr()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return 1
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(channel.Stderr(), "failed to start %s: %v\n", fields[0], err)
+		return 1
+	}
+	go func() {
+		io.Copy(stdin, channel)
+		stdin.Close()
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return uint32(exitErr.ExitCode())
+		}
+		return 1
+	}
+	return 0
+}
+
+// httpGitServer serves the repositories below root over smart HTTP using git http-backend.
+// Requests below /api/ are routed to the api handler so a provider API stand-in can share the host.
+type httpGitServer struct {
+	*httptest.Server
+
+	// Root is the directory the repositories are served from
+	Root string
+
+	mu                sync.Mutex
+	beforeReceivePack func()
+}
+
+func startHTTPGitServer(root string, api http.Handler) (*httpGitServer, error) {
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	backend := &cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+
+	server := &httpGitServer{Root: root}
+	mux := http.NewServeMux()
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/git-receive-pack") {
+			server.mu.Lock()
+			hook := server.beforeReceivePack
+			server.beforeReceivePack = nil
+			server.mu.Unlock()
+			if hook != nil {
+				hook()
+			}
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	if api != nil {
+		mux.Handle("/api/", api)
+	}
+
+	server.Server = httptest.NewServer(mux)
+	return server, nil
+}
+
+// BeforeNextPush runs hook once, after the next pushing client has read the refs but before
+// its pack is received. This simulates a concurrent push racing the client.
+func (s *httpGitServer) BeforeNextPush(hook func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.beforeReceivePack = hook
+}
+
+// startGitServerFixture serves the bare repository org/repo.git over HTTP, seeded with a commit on
+// main and open for pushes, and creates a Secret in the default namespace holding token. api
+// handles the provider API requests when it is not nil. The server, its repositories and the
+// Secret are removed when the spec ends. It returns the server, the path of the bare repository
+// and the name of the Secret.
+func startGitServerFixture(token string, api http.Handler) (*httpGitServer, string, string) {
+	root, err := os.MkdirTemp("", "http-git-server-")
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(os.RemoveAll, root)
+
+	barePath, err := newBareRepository(root, "org/repo.git")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(enableReceivePack(barePath)).To(Succeed())
+
+	server, err := startHTTPGitServer(root, api)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(server.Close)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "git-server-token-", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte(token)},
+	}
+	Expect(k8sClient.Create(context.Background(), secret)).To(Succeed())
+	DeferCleanup(func() {
+		k8sClient.Delete(context.Background(), secret)
+	})
+	return server, barePath, secret.Name
+}
+
+// RepositoryURL returns the http:// URL of a repository served from the server root
+func (s *httpGitServer) RepositoryURL(name string) string {
+	return s.Server.URL + "/" + name
+}
+
+// enableReceivePack allows anonymous pushes to a bare repository served by git http-backend
+func enableReceivePack(barePath string) error {
+	return exec.Command("git", "-C", barePath, "config", "http.receivepack", "true").Run()
+}
+
+// commitToBareRepository commits a file on top of branch in the bare repository, as another
+// client pushing to the same branch would
+func commitToBareRepository(barePath, branch, path, content string) error {
+	workPath, err := os.MkdirTemp("", "concurrent-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workPath)
+
+	repo, err := git.PlainClone(workPath, false, &git.CloneOptions{
+		URL:           barePath,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(workPath, path)), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(workPath, path), []byte(content), 0644); err != nil {
+		return err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Add(path); err != nil {
+		return err
+	}
+	if _, err := w.Commit("Concurrent change", &git.CommitOptions{
+		Author: &object.Signature{Name: "Someone Else", Email: "someone@example.com", When: time.Now()},
+	}); err != nil {
+		return err
+	}
+	return repo.Push(&git.PushOptions{})
+}
+
+// readBranchHead returns the commit hash a branch points to in the bare repository
+func readBranchHead(barePath, branch string) (string, error) {
+	repo, err := git.PlainOpen(barePath)
+	if err != nil {
+		return "", err
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return "", err
+	}
+	return ref.Hash().String(), nil
+}
+
+// createBranch creates branch in the bare repository pointing at the tip of from
+func createBranch(barePath, branch, from string) error {
+	repo, err := git.PlainOpen(barePath)
+	if err != nil {
+		return err
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(from), true)
+	if err != nil {
+		return err
+	}
+	return repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), ref.Hash()))
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit branch checkout", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx         context.Context
+		secretName  string
+		barePath    string
+		server      *httpGitServer
+		mainHead    string
+		releaseHead string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+		Expect(createBranch(barePath, "release/1.2", "main")).To(Succeed())
+		Expect(commitToBareRepository(barePath, "release/1.2", "version.txt", "1.2.0\n")).To(Succeed())
+		Expect(commitToBareRepository(barePath, "main", "version.txt", "2.0.0-dev\n")).To(Succeed())
+		mainHead, err = readBranchHead(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		releaseHead, err = readBranchHead(barePath, "release/1.2")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	createGitCommit := func(name, branch, baseBranch string) func() gitv1.GitCommitStatus {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        branch,
+				BaseBranch:    baseBranch,
+				CommitMessage: "Add changelog",
+				AuthSecretRef: secretName,
+				Files:         []gitv1.File{{Path: "CHANGELOG.md", Content: "- fix\n"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		return func() gitv1.GitCommitStatus {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, current); err != nil {
+				return gitv1.GitCommitStatus{}
+			}
+			return current.Status
+		}
+	}
+
+	It("should commit on top of an existing remote branch", func() {
+		status := createGitCommit("branch-existing", "release/1.2", "")
+		Eventually(status, timeout, interval).Should(HaveField("Phase", gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "release/1.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commit.ParentHashes).To(ConsistOf(plumbing.NewHash(releaseHead)))
+
+		content, _, err := readCommittedFile(barePath, "release/1.2", "version.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("1.2.0\n"))
+
+		head, err := readBranchHead(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(head).To(Equal(mainHead))
+	})
+
+	It("should create a new branch from the base branch", func() {
+		status := createGitCommit("branch-from-base", "hotfix/1.2.1", "release/1.2")
+		Eventually(status, timeout, interval).Should(HaveField("Phase", gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "hotfix/1.2.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commit.ParentHashes).To(ConsistOf(plumbing.NewHash(releaseHead)))
+	})
+
+	It("should create a new branch from the default branch without a base branch", func() {
+		status := createGitCommit("branch-from-default", "feature/changelog", "")
+		Eventually(status, timeout, interval).Should(HaveField("Phase", gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "feature/changelog")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commit.ParentHashes).To(ConsistOf(plumbing.NewHash(mainHead)))
+	})
+
+	It("should fail when the base branch does not exist", func() {
+		status := createGitCommit("branch-missing-base", "hotfix/1.3.1", "release/1.3")
+		Eventually(status, timeout, interval).Should(And(
+			HaveField("Phase", gitv1.GitCommitPhaseFailed),
+			HaveField("Message", ContainSubstring("base branch release/1.3 not found")),
+		))
+
+		_, err := readBranchHead(barePath, "hotfix/1.3.1")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit with a shallow sparse clone", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+		Expect(commitToBareRepository(barePath, "main", "apps/config.txt", "replicas: 1\n")).To(Succeed())
+		Expect(commitToBareRepository(barePath, "main", "logs/deployments.log", "v1\n")).To(Succeed())
+		Expect(commitToBareRepository(barePath, "main", "assets/large.bin", "binary\n")).To(Succeed())
+	})
+
+	It("should commit changed and appended files and keep the files it did not check out", func() {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "sparse-clone", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Deploy v2",
+				AuthSecretRef: secretName,
+				Clone: &gitv1.CloneOptions{
+					Depth:        1,
+					SingleBranch: true,
+					SparsePaths:  []string{"apps"},
+				},
+				Files: []gitv1.File{
+					{Path: "apps/config.txt", Content: "replicas: 2\n"},
+					{Path: "logs/deployments.log", Content: "v2\n", WriteMode: gitv1.WriteModeAppend},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: gitCommit.Name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		for path, want := range map[string]string{
+			"apps/config.txt":      "replicas: 2\n",
+			"logs/deployments.log": "v1\nv2\n",
+			"assets/large.bin":     "binary\n",
+		} {
+			content, _, err := readCommittedFile(barePath, "main", path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(Equal(want), path)
+		}
+	})
+})
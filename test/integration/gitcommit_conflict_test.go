@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit push conflicts", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+	})
+
+	createGitCommit := func(name string, strategy *gitv1.ConflictStrategy) func() gitv1.GitCommitStatus {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:       server.RepositoryURL("org/repo.git"),
+				Branch:           "main",
+				CommitMessage:    "Append operator entry",
+				AuthSecretRef:    secretName,
+				ConflictStrategy: strategy,
+				Files: []gitv1.File{
+					{Path: "log.txt", Content: "operator\n", WriteMode: gitv1.WriteModeAppend},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		return func() gitv1.GitCommitStatus {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, current); err != nil {
+				return gitv1.GitCommitStatus{}
+			}
+			return current.Status
+		}
+	}
+
+	It("should re-apply appended content on top of a concurrent push", func() {
+		server.BeforeNextPush(func() {
+			defer GinkgoRecover()
+			Expect(commitToBareRepository(barePath, "main", "log.txt", "concurrent\n")).To(Succeed())
+		})
+
+		status := createGitCommit("conflict-rebase", &gitv1.ConflictStrategy{
+			Backoff: &metav1.Duration{Duration: 10 * time.Millisecond},
+		})
+		Eventually(status, timeout, interval).Should(HaveField("Phase", gitv1.GitCommitPhaseCommitted))
+
+		content, head, err := readCommittedFile(barePath, "main", "log.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("concurrent\noperator\n"))
+		Expect(status().CommitSHA).To(Equal(head))
+	})
+
+	It("should fail on the first rejection with the Fail strategy", func() {
+		server.BeforeNextPush(func() {
+			defer GinkgoRecover()
+			Expect(commitToBareRepository(barePath, "main", "log.txt", "concurrent\n")).To(Succeed())
+		})
+
+		status := createGitCommit("conflict-fail", &gitv1.ConflictStrategy{Type: gitv1.ConflictStrategyFail})
+		Eventually(status, timeout, interval).Should(And(
+			HaveField("Phase", gitv1.GitCommitPhaseFailed),
+			HaveField("Message", ContainSubstring("failed to update ref")),
+		))
+
+		content, _, err := readCommittedFile(barePath, "main", "log.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("concurrent\n"))
+	})
+})
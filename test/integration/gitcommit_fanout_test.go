@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit fan-out", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		bares      map[string]string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		server, _, secretName = startGitServerFixture("unused", nil)
+
+		bares = map[string]string{}
+		for _, name := range []string{"frontend", "backend", "archived", "worker"} {
+			bares[name], err = newBareRepository(server.Root, "org/"+name+".git")
+			Expect(err).NotTo(HaveOccurred())
+			// Pushes to the archived repository are rejected
+			if name != "archived" {
+				Expect(enableReceivePack(bares[name])).To(Succeed())
+			}
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "fan-out-worker", Namespace: namespace, Labels: map[string]string{"ca-bundle": "enabled"}},
+			Data: map[string][]byte{
+				"token":      []byte("unused"),
+				"repository": []byte(server.RepositoryURL("org/worker.git")),
+				"branch":     []byte("certificates"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), secret)
+		})
+	})
+
+	It("should commit to every repository and report the failed ones", func() {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Branch:        "main",
+				CommitMessage: "Rotate CA bundle",
+				AuthSecretRef: secretName,
+				Files:         []gitv1.File{{Path: "certs/ca.pem", Content: "-----BEGIN CERTIFICATE-----\n"}},
+				FanOut: &gitv1.FanOut{
+					Repositories: []gitv1.RepositoryTarget{
+						{Repository: server.RepositoryURL("org/frontend.git")},
+						{Repository: server.RepositoryURL("org/backend.git")},
+						{Repository: server.RepositoryURL("org/archived.git")},
+					},
+					Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"ca-bundle": "enabled"}},
+					MaxConcurrency: 2,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		status := func() gitv1.GitCommitStatus {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: gitCommit.Name, Namespace: namespace}, current); err != nil {
+				return gitv1.GitCommitStatus{}
+			}
+			return current.Status
+		}
+		Eventually(status, timeout, interval).Should(HaveField("Phase", gitv1.GitCommitPhasePartiallyCommitted))
+
+		repositories := status().Repositories
+		Expect(repositories).To(HaveLen(4))
+		Expect(repositories[2]).To(And(
+			HaveField("Repository", server.RepositoryURL("org/archived.git")),
+			HaveField("Phase", gitv1.GitCommitPhaseFailed),
+			HaveField("Message", Not(BeEmpty())),
+		))
+
+		for i, target := range []struct{ name, branch string }{{"frontend", "main"}, {"backend", "main"}, {"worker", "certificates"}} {
+			index := i
+			if target.name == "worker" {
+				index = 3
+			}
+			content, sha, err := readCommittedFile(bares[target.name], target.branch, "certs/ca.pem")
+			Expect(err).NotTo(HaveOccurred(), target.name)
+			Expect(content).To(Equal("-----BEGIN CERTIFICATE-----\n"))
+			Expect(repositories[index]).To(And(
+				HaveField("Phase", gitv1.GitCommitPhaseCommitted),
+				HaveField("Branch", target.branch),
+				HaveField("CommitSHA", sha),
+			))
+		}
+	})
+})
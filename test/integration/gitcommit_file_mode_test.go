@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	"filippo.io/age"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/encryption"
+)
+
+var _ = Describe("GitCommit binary files, file modes and symbolic links", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+
+		script = "#!/bin/sh\nkubectl rollout restart deployment/frontend\n"
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+		logo       = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0xff}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+		Expect(commitToBareRepository(barePath, "main", "releases/current", "v1.3.0")).To(Succeed())
+	})
+
+	commit := func(name string, files []gitv1.File, config *gitv1.Encryption) {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Release frontend 1.4.0",
+				AuthSecretRef: secretName,
+				Files:         files,
+				Encryption:    config,
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+	}
+
+	expectCommitted := func(path, content string, mode filemode.FileMode) {
+		committed, _, err := readCommittedFile(barePath, "main", path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(committed).To(Equal(content))
+		Expect(committedFileMode(barePath, "main", path)).To(Equal(mode))
+	}
+
+	It("should commit binary content, executables and symbolic links with their git file modes", func() {
+		commit("frontend-assets", []gitv1.File{
+			{Path: "assets/logo.png", BinaryContent: logo},
+			{Path: "scripts/restart.sh", Content: script, Executable: true},
+			{Path: "releases/current", SymlinkTarget: "v1.4.0"},
+		}, nil)
+
+		expectCommitted("assets/logo.png", string(logo), filemode.Regular)
+		expectCommitted("scripts/restart.sh", script, filemode.Executable)
+		expectCommitted("releases/current", "v1.4.0", filemode.Symlink)
+	})
+
+	It("should keep binary content and the executable bit of encrypted files", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+
+		commit("frontend-secrets", []gitv1.File{
+			{Path: "certs/keystore.p12", BinaryContent: logo},
+			{Path: "scripts/restart.sh", Content: script, Executable: true},
+			{Path: "releases/current", SymlinkTarget: "v1.4.0"},
+		}, &gitv1.Encryption{
+			Enabled:    true,
+			Recipients: []gitv1.Recipient{{Type: gitv1.RecipientTypeAge, Value: identity.Recipient().String()}},
+		})
+
+		decryptor, err := encryption.NewDecryptorFromIdentities([]age.Identity{identity})
+		Expect(err).NotTo(HaveOccurred())
+		for path, want := range map[string]struct {
+			content string
+			mode    filemode.FileMode
+		}{
+			"certs/keystore.p12.age": {string(logo), filemode.Regular},
+			"scripts/restart.sh.age": {script, filemode.Executable},
+		} {
+			encrypted, _, err := readCommittedFile(barePath, "main", path)
+			Expect(err).NotTo(HaveOccurred())
+			decrypted, err := decryptor.Decrypt([]byte(encrypted))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(decrypted)).To(Equal(want.content))
+			Expect(committedFileMode(barePath, "main", path)).To(Equal(want.mode))
+		}
+
+		// Symbolic links have no content to encrypt
+		expectCommitted("releases/current", "v1.4.0", filemode.Symlink)
+	})
+})
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit file operations", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+		for _, path := range []string{
+			"reports/2023/01.csv",
+			"reports/2023/02.csv.age",
+			"reports/2024/01.csv",
+			"config/legacy.yaml",
+		} {
+			Expect(commitToBareRepository(barePath, "main", path, path+"\n")).To(Succeed())
+		}
+	})
+
+	It("should delete, move and write files in a single commit", func() {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "file-operations", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Retire 2023 reports",
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: "reports/2023/**", Operation: gitv1.FileOperationDelete},
+					{Path: "config/legacy.yaml", Operation: gitv1.FileOperationMove, Destination: "config/app.yaml"},
+					{Path: "reports/README.md", Content: "Reports from 2024 on\n"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "file-operations", Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		files, err := commit.Files()
+		Expect(err).NotTo(HaveOccurred())
+		var paths []string
+		Expect(files.ForEach(func(file *object.File) error {
+			paths = append(paths, file.Name)
+			return nil
+		})).To(Succeed())
+		Expect(paths).To(ConsistOf("README.md", "config/app.yaml", "reports/2024/01.csv", "reports/README.md"))
+
+		content, _, err := readCommittedFile(barePath, "main", "config/app.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("config/legacy.yaml\n"))
+	})
+})
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit file templates", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+		Expect(commitToBareRepository(barePath, "main", "deploy/frontend.yaml", "# Managed by the platform team\nreplicas: 1\n")).To(Succeed())
+
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend-settings", Namespace: namespace},
+			Data:       map[string]string{"replicas": "3", "image": "frontend:1.4.0"},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), configMap)
+		})
+	})
+
+	It("should render the path and content from the referenced resources and the current file", func() {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Render frontend manifest",
+				AuthSecretRef: secretName,
+				ResourceRefs: []gitv1.ResourceRef{{
+					ApiVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       "frontend-settings",
+					Strategy:   gitv1.OutputStrategy{Type: gitv1.OutputTypeSingleField, Path: "settings/image", FieldRef: &gitv1.FieldRef{Key: "image"}},
+				}},
+				Files: []gitv1.File{{
+					Path:     "deploy/{{ .metadata.name }}.yaml",
+					Template: true,
+					Content: `{{ .file.content | splitList "\n" | first }}
+{{- $settings := index .resources "frontend-settings" }}
+replicas: {{ $settings.data.replicas }}
+image: {{ $settings.data.image | quote }}
+`,
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: gitCommit.Name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		content, _, err := readCommittedFile(barePath, "main", "deploy/frontend.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("# Managed by the platform team\nreplicas: 3\nimage: \"frontend:1.4.0\"\n"))
+	})
+})
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit author, trailers and message templates", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+	})
+
+	It("should record the configured identities and trailers", func() {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "commit-metadata", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Update config",
+				AuthSecretRef: secretName,
+				Files:         []gitv1.File{{Path: "config.txt", Content: "replicas: 3\n"}},
+				CommitMetadata: gitv1.CommitMetadata{
+					Author:        &gitv1.CommitIdentity{Name: "Alice", Email: "alice@example.com"},
+					Committer:     &gitv1.CommitIdentity{Name: "Release Bot", Email: "bot@example.com"},
+					Trailers:      []gitv1.CommitTrailer{{Key: "Co-authored-by", Value: "Bob <bob@example.com>"}},
+					SignOff:       true,
+					OriginTrailer: true,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "commit-metadata", Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commit.Author.Name).To(Equal("Alice"))
+		Expect(commit.Author.Email).To(Equal("alice@example.com"))
+		Expect(commit.Committer.Name).To(Equal("Release Bot"))
+		Expect(commit.Committer.Email).To(Equal("bot@example.com"))
+		Expect(commit.Message).To(Equal(fmt.Sprintf("Update config\n\n"+
+			"Co-authored-by: Bob <bob@example.com>\n"+
+			"Signed-off-by: Release Bot <bot@example.com>\n"+
+			"Git-Change-Operator-Origin: GitCommit default/commit-metadata (uid %s)\n", gitCommit.UID)))
+	})
+
+	It("should render the commit message template", func() {
+		Expect(commitToBareRepository(barePath, "main", "seed.txt", "seed\n")).To(Succeed())
+		previous, err := readBranchHead(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "commit-template", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: `Update {{ join ", " .files }} for {{ .metadata.name }} after {{ short .previousCommit }}`,
+				Template:      true,
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: "b.txt", Content: "b\n"},
+					{Path: "a.txt", Content: "a\n"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "commit-template", Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commit.Message).To(Equal("Update a.txt, b.txt for commit-template after " + previous[:7]))
+	})
+})
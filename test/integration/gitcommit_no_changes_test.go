@@ -0,0 +1,88 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit without changes", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+		seedHead   string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+		Expect(commitToBareRepository(barePath, "main", "config.txt", "replicas: 3\n")).To(Succeed())
+		seedHead, err = readBranchHead(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	createGitCommit := func(name string, policy gitv1.EmptyCommitPolicy) func() gitv1.GitCommitStatus {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:        server.RepositoryURL("org/repo.git"),
+				Branch:            "main",
+				CommitMessage:     "Set replicas",
+				AuthSecretRef:     secretName,
+				EmptyCommitPolicy: policy,
+				Files:             []gitv1.File{{Path: "config.txt", Content: "replicas: 3\n"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		return func() gitv1.GitCommitStatus {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, current); err != nil {
+				return gitv1.GitCommitStatus{}
+			}
+			return current.Status
+		}
+	}
+
+	It("should report NoChanges instead of committing identical content", func() {
+		status := createGitCommit("no-changes-skip", gitv1.EmptyCommitPolicySkip)
+		Eventually(status, timeout, interval).Should(And(
+			HaveField("Phase", gitv1.GitCommitPhaseNoChanges),
+			HaveField("CommitSHA", BeEmpty()),
+		))
+
+		head, err := readBranchHead(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(head).To(Equal(seedHead))
+	})
+
+	It("should create an empty commit with the Allow policy", func() {
+		status := createGitCommit("no-changes-allow", gitv1.EmptyCommitPolicyAllow)
+		Eventually(status, timeout, interval).Should(HaveField("Phase", gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commit.Hash.String()).To(Equal(status().CommitSHA))
+		Expect(commit.ParentHashes).To(ConsistOf(plumbing.NewHash(seedHead)))
+	})
+})
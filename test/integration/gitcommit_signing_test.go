@@ -0,0 +1,156 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+// newOpenPGPSigningKey returns an armored private key protected by passphrase and its public key
+func newOpenPGPSigningKey(passphrase []byte) (string, string, error) {
+	entity, err := openpgp.NewEntity("Git Change Operator", "", "git-change-operator@galos.one", nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", "", err
+	}
+	entity.Serialize(w)
+	w.Close()
+
+	if err := entity.EncryptPrivateKeys(passphrase, nil); err != nil {
+		return "", "", err
+	}
+	var privateKey bytes.Buffer
+	w, err = armor.Encode(&privateKey, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return "", "", err
+	}
+	entity.SerializePrivateWithoutSigning(w, nil)
+	w.Close()
+
+	return privateKey.String(), publicKey.String(), nil
+}
+
+// readCommit returns the commit at the tip of branch in a bare repository
+func readCommit(barePath, branch string) (*object.Commit, error) {
+	repo, err := git.PlainOpen(barePath)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return nil, err
+	}
+	return repo.CommitObject(ref.Hash())
+}
+
+var _ = Describe("GitCommit signing", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+	})
+
+	commitSigned := func(name string, signing *gitv1.Signing, data map[string][]byte) *object.Commit {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: signing.SecretRef.Name, Namespace: namespace},
+			Data:       data,
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Signed change",
+				AuthSecretRef: secretName,
+				Signing:       signing,
+				Files:         []gitv1.File{{Path: "signed.txt", Content: name + "\n"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+			k8sClient.Delete(context.Background(), secret)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		commit, err := readCommit(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		return commit
+	}
+
+	It("should push a commit signed with an OpenPGP key", func() {
+		privateKey, publicKey, err := newOpenPGPSigningKey([]byte("s3cret"))
+		Expect(err).NotTo(HaveOccurred())
+
+		commit := commitSigned("signing-openpgp", &gitv1.Signing{
+			SecretRef: gitv1.SecretRef{Name: "openpgp-signing-key"},
+		}, map[string][]byte{
+			"signing-key": []byte(privateKey),
+			"passphrase":  []byte("s3cret"),
+		})
+
+		entity, err := commit.Verify(publicKey)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entity.Identities).To(HaveKey("Git Change Operator <git-change-operator@galos.one>"))
+	})
+
+	It("should push a commit signed with an SSH key", func() {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		block, err := ssh.MarshalPrivateKey(key, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		commit := commitSigned("signing-ssh", &gitv1.Signing{
+			Format:    gitv1.SigningFormatSSH,
+			SecretRef: gitv1.SecretRef{Name: "ssh-signing-key", Key: "id_ed25519"},
+		}, map[string][]byte{
+			"id_ed25519": pem.EncodeToMemory(block),
+		})
+
+		Expect(commit.PGPSignature).To(HavePrefix("-----BEGIN SSH SIGNATURE-----"))
+	})
+})
@@ -0,0 +1,260 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+// fakeReleaseAPI serves the version queried by the REST API and the GitHub releases endpoints
+type fakeReleaseAPI struct {
+	mu       sync.Mutex
+	releases []map[string]interface{}
+
+	// failures is the number of release creations answered with an internal server error
+	failures int
+}
+
+func (a *fakeReleaseAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/version":
+		w.Write([]byte(`{"version":"1.4.0"}`))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v3/repos/org/repo/releases/tags/"):
+		tag := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/org/repo/releases/tags/")
+		for i, release := range a.releases {
+			if release["tag_name"] == tag {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"id":       i + 1,
+					"html_url": "https://github.example.com/org/repo/releases/tag/" + tag,
+				})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/org/repo/releases" && a.failures > 0:
+		a.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"Server Error"}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/org/repo/releases":
+		var release map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&release)
+		a.releases = append(a.releases, release)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":       len(a.releases),
+			"html_url": "https://github.example.com/org/repo/releases/tag/" + release["tag_name"].(string),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (a *fakeReleaseAPI) Releases() []map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]map[string]interface{}(nil), a.releases...)
+}
+
+var _ = Describe("GitCommit tags", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		api        *fakeReleaseAPI
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		api = &fakeReleaseAPI{}
+		server, barePath, secretName = startGitServerFixture("unused", api)
+	})
+
+	waitForPhase := func(name string, phase gitv1.GitCommitPhase) *gitv1.GitCommit {
+		current := &gitv1.GitCommit{}
+		Eventually(func() gitv1.GitCommitPhase {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(phase), func() string { return current.Status.Message })
+		return current
+	}
+
+	// startGitCommit creates a GitCommit that writes the REST API version to VERSION and files
+	startGitCommit := func(name string, tag *gitv1.TagSpec, signing *gitv1.Signing, files ...gitv1.File) {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Bump version to {{ .restAPIs.version.formattedOutput }}",
+				Template:      true,
+				AuthSecretRef: secretName,
+				Signing:       signing,
+				Tag:           tag,
+				RestAPIs: []gitv1.RestAPI{{
+					Name: "version",
+					URL:  server.URL + "/api/version",
+					ResponseParsing: &gitv1.ResponseParsing{
+						Condition:      "has(response.version)",
+						DataExpression: "response.version",
+					},
+				}},
+				Files: append([]gitv1.File{{Path: "VERSION", UseRestAPIData: true}}, files...),
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+	}
+
+	createGitCommit := func(name string, tag *gitv1.TagSpec, signing *gitv1.Signing, files ...gitv1.File) *gitv1.GitCommit {
+		startGitCommit(name, tag, signing, files...)
+		return waitForPhase(name, gitv1.GitCommitPhaseCommitted)
+	}
+
+	// pushedCommits returns the messages of the commits on main that were pushed by the operator
+	pushedCommits := func() []string {
+		repo, err := git.PlainOpen(barePath)
+		Expect(err).NotTo(HaveOccurred())
+		head, err := repo.Reference(plumbing.NewBranchReferenceName("main"), true)
+		Expect(err).NotTo(HaveOccurred())
+		commits, err := repo.Log(&git.LogOptions{From: head.Hash()})
+		Expect(err).NotTo(HaveOccurred())
+		messages := []string{}
+		Expect(commits.ForEach(func(c *object.Commit) error {
+			if strings.HasPrefix(c.Message, "Bump version") {
+				messages = append(messages, c.Message)
+			}
+			return nil
+		})).To(Succeed())
+		return messages
+	}
+
+	It("should create a lightweight tag named after the REST API output", func() {
+		gitCommit := createGitCommit("tag-lightweight", &gitv1.TagSpec{
+			Name: "v{{ .restAPIs.version.formattedOutput }}",
+		}, nil)
+		Expect(gitCommit.Status.Tag).To(Equal("v1.4.0"))
+
+		repo, err := git.PlainOpen(barePath)
+		Expect(err).NotTo(HaveOccurred())
+		ref, err := repo.Reference(plumbing.NewTagReferenceName("v1.4.0"), true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.Hash().String()).To(Equal(gitCommit.Status.CommitSHA))
+		Expect(api.Releases()).To(BeEmpty())
+	})
+
+	It("should create a signed annotated tag and publish a release", func() {
+		privateKey, publicKey, err := newOpenPGPSigningKey([]byte("s3cret"))
+		Expect(err).NotTo(HaveOccurred())
+		signingSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tag-signing-key", Namespace: namespace},
+			Data: map[string][]byte{
+				"signing-key": []byte(privateKey),
+				"passphrase":  []byte("s3cret"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, signingSecret)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), signingSecret)
+		})
+
+		gitCommit := createGitCommit("tag-release", &gitv1.TagSpec{
+			Name:    "v{{ .restAPIs.version.formattedOutput }}",
+			Message: "Release {{ .tag }}",
+			Sign:    true,
+			Release: &gitv1.ReleaseSpec{
+				Name:          "Version {{ .restAPIs.version.formattedOutput }}",
+				GenerateNotes: true,
+			},
+		}, &gitv1.Signing{SecretRef: gitv1.SecretRef{Name: "tag-signing-key"}})
+		Expect(gitCommit.Status.Tag).To(Equal("v1.4.0"))
+		Expect(gitCommit.Status.ReleaseURL).To(Equal("https://github.example.com/org/repo/releases/tag/v1.4.0"))
+
+		repo, err := git.PlainOpen(barePath)
+		Expect(err).NotTo(HaveOccurred())
+		ref, err := repo.Reference(plumbing.NewTagReferenceName("v1.4.0"), true)
+		Expect(err).NotTo(HaveOccurred())
+		tag, err := repo.TagObject(ref.Hash())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Message).To(Equal("Release v1.4.0\n"))
+		Expect(tag.Target.String()).To(Equal(gitCommit.Status.CommitSHA))
+		_, err = tag.Verify(publicKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(api.Releases()).To(ConsistOf(And(
+			HaveKeyWithValue("tag_name", "v1.4.0"),
+			HaveKeyWithValue("name", "Version 1.4.0"),
+			HaveKeyWithValue("generate_release_notes", true),
+		)))
+	})
+
+	It("should retry the release of a pushed commit without committing again", func() {
+		api.failures = 1
+
+		gitCommit := createGitCommit("tag-release-retry", &gitv1.TagSpec{
+			Name:    "v{{ .restAPIs.version.formattedOutput }}",
+			Release: &gitv1.ReleaseSpec{},
+		}, nil, gitv1.File{Path: "CHANGELOG", Content: "1.4.0\n", WriteMode: gitv1.WriteModeAppend})
+		Expect(gitCommit.Status.Tag).To(Equal("v1.4.0"))
+		Expect(gitCommit.Status.ReleaseURL).To(Equal("https://github.example.com/org/repo/releases/tag/v1.4.0"))
+		Expect(gitCommit.Status.TagAttempts).To(BeZero())
+		Expect(api.Releases()).To(ConsistOf(HaveKeyWithValue("tag_name", "v1.4.0")))
+
+		// The append-mode file is written by the first run only
+		Expect(pushedCommits()).To(Equal([]string{"Bump version to 1.4.0"}))
+		repo, err := git.PlainOpen(barePath)
+		Expect(err).NotTo(HaveOccurred())
+		head, err := repo.Reference(plumbing.NewBranchReferenceName("main"), true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(head.Hash().String()).To(Equal(gitCommit.Status.CommitSHA))
+		commit, err := repo.CommitObject(head.Hash())
+		Expect(err).NotTo(HaveOccurred())
+		changelog, err := commit.File("CHANGELOG")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changelog.Contents()).To(Equal("1.4.0\n"))
+	})
+
+	It("should fail after the tag was retried too often", func() {
+		api.failures = 100
+
+		startGitCommit("tag-release-fail", &gitv1.TagSpec{
+			Name:    "v{{ .restAPIs.version.formattedOutput }}",
+			Release: &gitv1.ReleaseSpec{},
+		}, nil)
+		gitCommit := waitForPhase("tag-release-fail", gitv1.GitCommitPhaseFailed)
+		Expect(gitCommit.Status.Message).To(HavePrefix("Tagging failed after 5 attempts"))
+		Expect(gitCommit.Status.TagAttempts).To(BeZero())
+		Expect(gitCommit.Status.CommitSHA).NotTo(BeEmpty())
+		Expect(api.Releases()).To(BeEmpty())
+		Expect(pushedCommits()).To(Equal([]string{"Bump version to 1.4.0"}))
+	})
+})
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("GitCommit structured write modes", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+
+		values = `# Values for the frontend chart
+replicaCount: 1 # scaled by the operator
+
+image:
+  repository: registry.example.com/frontend
+  # Bumped on every release
+  tag: "1.3.0"
+`
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		server, barePath, secretName = startGitServerFixture("unused", nil)
+		Expect(commitToBareRepository(barePath, "main", "charts/frontend/values.yaml", values)).To(Succeed())
+		Expect(commitToBareRepository(barePath, "main", "package.json", "{\n  \"name\": \"frontend\",\n  \"version\": \"1.3.0\"\n}\n")).To(Succeed())
+
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend-scaling", Namespace: namespace},
+			Data:       map[string]string{"replicas": "3"},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), configMap)
+		})
+	})
+
+	It("should edit the existing files and keep their comments", func() {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend-release", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Release frontend 1.4.0",
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: "charts/frontend/values.yaml", WriteMode: gitv1.WriteModeYAMLSet, YAMLPath: "image.tag", Content: "1.4.0"},
+					{Path: "package.json", WriteMode: gitv1.WriteModeMergePatch, Content: `{"version": "1.4.0"}`},
+				},
+				ResourceRefs: []gitv1.ResourceRef{{
+					ApiVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       "frontend-scaling",
+					Strategy: gitv1.OutputStrategy{
+						Type:      gitv1.OutputTypeSingleField,
+						Path:      "charts/frontend/values.yaml",
+						WriteMode: gitv1.WriteModeYAMLSet,
+						YAMLPath:  "replicaCount",
+						FieldRef:  &gitv1.FieldRef{Key: "replicas"},
+					},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: gitCommit.Name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		content, _, err := readCommittedFile(barePath, "main", "charts/frontend/values.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal(`# Values for the frontend chart
+replicaCount: 3 # scaled by the operator
+
+image:
+  repository: registry.example.com/frontend
+  # Bumped on every release
+  tag: "1.4.0"
+`))
+
+		content, _, err = readCommittedFile(barePath, "main", "package.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("{\n  \"name\": \"frontend\",\n  \"version\": \"1.4.0\"\n}\n"))
+	})
+
+	It("should edit text files with patterns and skip unmatched edits by policy", func() {
+		Expect(commitToBareRepository(barePath, "main", "Dockerfile", "FROM node:18-alpine\nWORKDIR /app\nCMD [\"node\", \"server.js\"]\n")).To(Succeed())
+
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend-runtime", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Update the frontend runtime",
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: "Dockerfile", WriteMode: gitv1.WriteModeRegexReplace, Pattern: `(?m)^FROM node:\d+(-\w+)?$`, Content: "FROM node:20$1"},
+					{Path: "Dockerfile", WriteMode: gitv1.WriteModeInsertAfter, Pattern: "^WORKDIR", Content: "ENV NODE_ENV=production"},
+					{Path: "Dockerfile", WriteMode: gitv1.WriteModeInsertBefore, Pattern: "^EXPOSE", Content: "HEALTHCHECK NONE", OnNoMatch: gitv1.NoMatchPolicySkip},
+					{Path: ".env", WriteMode: gitv1.WriteModeEnsureLine, Pattern: "^LOG_LEVEL=", Content: "LOG_LEVEL=info"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: gitCommit.Name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		content, _, err := readCommittedFile(barePath, "main", "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("FROM node:20-alpine\nWORKDIR /app\nENV NODE_ENV=production\nCMD [\"node\", \"server.js\"]\n"))
+
+		content, _, err = readCommittedFile(barePath, "main", ".env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("LOG_LEVEL=info\n"))
+	})
+
+	It("should add lines with a header, replace duplicate keys and trim the oldest entries", func() {
+		Expect(commitToBareRepository(barePath, "main", "data/prices.csv", "date,price\n2024-01-13,41.20\n2024-01-14,41.80\n2024-01-15,40.00")).To(Succeed())
+
+		lines := &gitv1.LineOptions{Header: "date,price", DedupeKey: "^([^,]+),", MaxLines: 3}
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend-prices", Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Branch:        "main",
+				CommitMessage: "Record prices",
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: "data/prices.csv", WriteMode: gitv1.WriteModeAppendLine, Lines: lines, Content: "2024-01-15,42.10\n2024-01-16,42.55\n"},
+					{Path: "CHANGELOG.md", WriteMode: gitv1.WriteModePrepend, Lines: &gitv1.LineOptions{Header: "# Changelog"}, Content: "- Prices of 2024-01-16"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+
+		Eventually(func() gitv1.GitCommitPhase {
+			current := &gitv1.GitCommit{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: gitCommit.Name, Namespace: namespace}, current); err != nil {
+				return ""
+			}
+			return current.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		content, _, err := readCommittedFile(barePath, "main", "data/prices.csv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("date,price\n2024-01-14,41.80\n2024-01-15,42.10\n2024-01-16,42.55\n"))
+
+		content, _, err = readCommittedFile(barePath, "main", "CHANGELOG.md")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("# Changelog\n- Prices of 2024-01-16\n"))
+	})
+})
@@ -0,0 +1,305 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fakeGiteaPullRequest is the subset of a Gitea pull request the operator reads and writes
+type fakeGiteaPullRequest struct {
+	Number         int    `json:"number"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	State          string `json:"state"`
+	HTMLURL        string `json:"html_url"`
+	Merged         bool   `json:"merged"`
+	MergeCommitSHA string `json:"merge_commit_sha,omitempty"`
+	Mergeable      bool   `json:"mergeable"`
+	Head           struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Assignees []fakeGiteaUser `json:"assignees"`
+
+	// Metadata applied through the issue and review request endpoints
+	Labels             []string `json:"-"`
+	Milestone          int64    `json:"-"`
+	RequestedReviewers []string `json:"-"`
+
+	// MergeMethod is the method of the merge request, AutoMerge is set when it waits for checks
+	MergeMethod string `json:"-"`
+	AutoMerge   bool   `json:"-"`
+}
+
+type fakeGiteaUser struct {
+	Login string `json:"login"`
+}
+
+// fakeGiteaMilestones are the milestones every fake repository has
+var fakeGiteaMilestones = []map[string]interface{}{{"id": 1, "title": "v1.0"}}
+
+// fakeGiteaReview is a review left on a pull request
+type fakeGiteaReview struct {
+	State string        `json:"state"`
+	User  fakeGiteaUser `json:"user"`
+}
+
+// fakeGiteaAPI stands in for the pull request and commit status endpoints of the Gitea API v1
+type fakeGiteaAPI struct {
+	token string
+
+	// headSHA optionally resolves the commit a head branch points to, e.g. from the bare repository
+	headSHA func(ref string) string
+
+	mu       sync.Mutex
+	pulls    []*fakeGiteaPullRequest
+	reviews  map[int][]fakeGiteaReview
+	statuses map[string]string
+}
+
+func newFakeGiteaAPI(token string) *fakeGiteaAPI {
+	return &fakeGiteaAPI{token: token, reviews: map[int][]fakeGiteaReview{}, statuses: map[string]string{}}
+}
+
+// Review records a review of the given state, e.g. APPROVED or REQUEST_CHANGES
+func (a *fakeGiteaAPI) Review(number int, login, state string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reviews[number] = append(a.reviews[number], fakeGiteaReview{State: state, User: fakeGiteaUser{Login: login}})
+}
+
+// SetCommitStatus sets the combined commit status of a commit, e.g. success or pending
+func (a *fakeGiteaAPI) SetCommitStatus(sha, state string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.statuses[sha] = state
+}
+
+// Merge marks a pull request as merged by the given merge commit
+func (a *fakeGiteaAPI) Merge(number int, mergeCommitSHA string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pr := a.pulls[number-1]
+	pr.State = "closed"
+	pr.Merged = true
+	pr.MergeCommitSHA = mergeCommitSHA
+}
+
+// PullRequests returns a snapshot of all pull requests
+func (a *fakeGiteaAPI) PullRequests() []fakeGiteaPullRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pulls := make([]fakeGiteaPullRequest, 0, len(a.pulls))
+	for _, pr := range a.pulls {
+		pulls = append(pulls, *pr)
+	}
+	return pulls
+}
+
+// Close closes a pull request without merging it
+func (a *fakeGiteaAPI) Close(number int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pulls[number-1].State = "closed"
+}
+
+func (a *fakeGiteaAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token "+a.token {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "token is required"})
+		return
+	}
+
+	// /api/v1/repos/{owner}/{repo}/{pulls,issues}[/{number}[/...]], /api/v1/repos/{owner}/{repo}/milestones
+	// and /api/v1/repos/{owner}/{repo}/commits/{sha}/status
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/repos/"), "/")
+	if len(parts) < 3 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "not found"})
+		return
+	}
+	repoPath := parts[0] + "/" + parts[1]
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch parts[2] {
+	case "pulls", "issues":
+	case "milestones":
+		var milestones []map[string]interface{}
+		for _, milestone := range fakeGiteaMilestones {
+			if name := r.URL.Query().Get("name"); name == "" || name == milestone["title"] {
+				milestones = append(milestones, milestone)
+			}
+		}
+		writeJSON(w, http.StatusOK, milestones)
+		return
+	case "commits":
+		if len(parts) != 5 || parts[4] != "status" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "not found"})
+			return
+		}
+		state, exists := a.statuses[parts[3]]
+		if !exists {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"state": "", "total_count": 0})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"state": state, "total_count": 1})
+		return
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "not found"})
+		return
+	}
+
+	var pr *fakeGiteaPullRequest
+	if len(parts) >= 4 {
+		number, _ := strconv.Atoi(parts[3])
+		if number < 1 || number > len(a.pulls) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "pull request not found"})
+			return
+		}
+		pr = a.pulls[number-1]
+	}
+
+	switch {
+	case parts[2] == "issues" && len(parts) >= 4:
+		a.serveIssue(w, r, pr, parts[4:])
+
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		open := []*fakeGiteaPullRequest{}
+		if page, _ := strconv.Atoi(r.URL.Query().Get("page")); page <= 1 {
+			for _, pr := range a.pulls {
+				if pr.State == "open" {
+					open = append(open, pr)
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, open)
+
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, pr := range a.pulls {
+			if pr.State == "open" && pr.Head.Ref == body["head"] && pr.Base.Ref == body["base"] {
+				writeJSON(w, http.StatusConflict, map[string]string{"message": "pull request already exists for these targets"})
+				return
+			}
+		}
+		pr := &fakeGiteaPullRequest{Number: len(a.pulls) + 1, Title: body["title"], Body: body["body"], State: "open", Mergeable: true}
+		pr.Head.Ref = body["head"]
+		pr.Base.Ref = body["base"]
+		pr.HTMLURL = fmt.Sprintf("https://gitea.example.com/%s/pulls/%d", repoPath, pr.Number)
+		a.pulls = append(a.pulls, pr)
+		writeJSON(w, http.StatusCreated, pr)
+
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		if a.headSHA != nil && pr.State == "open" {
+			pr.Head.SHA = a.headSHA(pr.Head.Ref)
+		}
+		writeJSON(w, http.StatusOK, pr)
+
+	case len(parts) == 5 && parts[4] == "reviews" && r.Method == http.MethodGet:
+		reviews := append([]fakeGiteaReview{}, a.reviews[pr.Number]...)
+		writeJSON(w, http.StatusOK, reviews)
+
+	case len(parts) == 5 && parts[4] == "requested_reviewers" && r.Method == http.MethodPost:
+		var body struct {
+			Reviewers     []string `json:"reviewers"`
+			TeamReviewers []string `json:"team_reviewers"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.TeamReviewers) > 0 {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "team reviewers are only available for organization repositories"})
+			return
+		}
+		pr.RequestedReviewers = append(pr.RequestedReviewers, body.Reviewers...)
+		writeJSON(w, http.StatusCreated, []interface{}{})
+
+	case len(parts) == 5 && parts[4] == "merge" && r.Method == http.MethodPost:
+		var body struct {
+			Do                     string `json:"Do"`
+			HeadCommitID           string `json:"head_commit_id"`
+			MergeWhenChecksSucceed bool   `json:"merge_when_checks_succeed"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if pr.State != "open" {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "pull request is not open"})
+			return
+		}
+		if body.HeadCommitID != "" && a.headSHA != nil && body.HeadCommitID != a.headSHA(pr.Head.Ref) {
+			writeJSON(w, http.StatusConflict, map[string]string{"message": "head out of date"})
+			return
+		}
+		pr.MergeMethod = body.Do
+		if body.MergeWhenChecksSucceed {
+			pr.AutoMerge = true
+			writeJSON(w, http.StatusCreated, map[string]string{})
+			return
+		}
+		pr.State = "closed"
+		pr.Merged = true
+		pr.MergeCommitSHA = "0000000000000000000000000000000000000001"
+		w.WriteHeader(http.StatusOK)
+
+	case len(parts) == 4 && r.Method == http.MethodPatch:
+		var body map[string]*string
+		json.NewDecoder(r.Body).Decode(&body)
+		if title := body["title"]; title != nil {
+			pr.Title = *title
+		}
+		if description := body["body"]; description != nil {
+			pr.Body = *description
+		}
+		if state := body["state"]; state != nil {
+			pr.State = *state
+		}
+		writeJSON(w, http.StatusCreated, pr)
+
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+	}
+}
+
+// serveIssue handles the issue endpoints used to label, assign and schedule pull requests
+func (a *fakeGiteaAPI) serveIssue(w http.ResponseWriter, r *http.Request, pr *fakeGiteaPullRequest, subpath []string) {
+	switch {
+	case len(subpath) == 1 && subpath[0] == "labels" && r.Method == http.MethodPost:
+		var body struct {
+			Labels []string `json:"labels"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		pr.Labels = append(pr.Labels, body.Labels...)
+		writeJSON(w, http.StatusOK, []interface{}{})
+
+	case len(subpath) == 0 && r.Method == http.MethodPatch:
+		var body struct {
+			Assignees []string `json:"assignees"`
+			Milestone *int64   `json:"milestone"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Assignees != nil {
+			pr.Assignees = nil
+			for _, login := range body.Assignees {
+				pr.Assignees = append(pr.Assignees, fakeGiteaUser{Login: login})
+			}
+		}
+		if body.Milestone != nil {
+			pr.Milestone = *body.Milestone
+		}
+		writeJSON(w, http.StatusCreated, pr)
+
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
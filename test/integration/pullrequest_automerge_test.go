@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("PullRequest auto-merge", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		api        *fakeGiteaAPI
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		api = newFakeGiteaAPI("gitea-token")
+		api.headSHA = func(ref string) string {
+			sha, _ := readBranchHead(barePath, ref)
+			return sha
+		}
+		server, barePath, secretName = startGitServerFixture("gitea-token", api)
+	})
+
+	createPullRequest := func(name string, autoMerge *gitv1.AutoMerge) {
+		pr := &gitv1.PullRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.PullRequestSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Provider:      gitv1.GitProviderGitea,
+				BaseBranch:    "main",
+				HeadBranch:    name,
+				Title:         "Update config",
+				AuthSecretRef: secretName,
+				AutoMerge:     autoMerge,
+				Files: []gitv1.File{
+					{Path: "config.txt", Content: "updated"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pr)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), pr)
+		})
+	}
+
+	getStatus := func(name string) func() gitv1.PullRequestStatus {
+		return func() gitv1.PullRequestStatus {
+			pr := &gitv1.PullRequest{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, pr); err != nil {
+				return gitv1.PullRequestStatus{}
+			}
+			return pr.Status
+		}
+	}
+
+	autoMergeState := func(name string) func() gitv1.AutoMergeState {
+		return func() gitv1.AutoMergeState {
+			if status := getStatus(name)(); status.AutoMerge != nil {
+				return status.AutoMerge.State
+			}
+			return ""
+		}
+	}
+
+	It("should wait for the checks, merge and delete the head branch", func() {
+		createPullRequest("automerge-poll", &gitv1.AutoMerge{
+			Method:       gitv1.MergeMethodSquash,
+			DeleteBranch: true,
+		})
+		Eventually(getStatus("automerge-poll"), timeout, interval).Should(HaveField("Phase", gitv1.PullRequestPhaseCreated))
+
+		head, err := readBranchHead(barePath, "automerge-poll")
+		Expect(err).NotTo(HaveOccurred())
+		api.SetCommitStatus(head, "pending")
+
+		Eventually(autoMergeState("automerge-poll"), timeout, interval).Should(Equal(gitv1.AutoMergeStateWaiting))
+		Consistently(autoMergeState("automerge-poll"), 2*time.Second, interval).Should(Equal(gitv1.AutoMergeStateWaiting))
+
+		api.SetCommitStatus(head, "success")
+
+		Eventually(getStatus("automerge-poll"), timeout, interval).Should(And(
+			HaveField("Phase", gitv1.PullRequestPhaseMerged),
+			HaveField("AutoMerge.State", gitv1.AutoMergeStateMerged),
+			HaveField("Message", "Pull request merged, head branch automerge-poll deleted"),
+		))
+		Expect(api.PullRequests()[0].MergeMethod).To(Equal("squash"))
+
+		_, err = readBranchHead(barePath, "automerge-poll")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not merge when the checks failed", func() {
+		createPullRequest("automerge-failed-checks", &gitv1.AutoMerge{})
+		Eventually(getStatus("automerge-failed-checks"), timeout, interval).Should(HaveField("Phase", gitv1.PullRequestPhaseCreated))
+
+		head, err := readBranchHead(barePath, "automerge-failed-checks")
+		Expect(err).NotTo(HaveOccurred())
+		api.SetCommitStatus(head, "failure")
+
+		Eventually(getStatus("automerge-failed-checks"), timeout, interval).Should(And(
+			HaveField("AutoMerge.State", gitv1.AutoMergeStateWaiting),
+			HaveField("AutoMerge.Message", "Checks failed on the head commit"),
+		))
+		Expect(api.PullRequests()[0].State).To(Equal("open"))
+	})
+
+	It("should arm native auto-merge once", func() {
+		createPullRequest("automerge-native", &gitv1.AutoMerge{Native: true})
+
+		Eventually(autoMergeState("automerge-native"), timeout, interval).Should(Equal(gitv1.AutoMergeStateEnabled))
+
+		pulls := api.PullRequests()
+		Expect(pulls).To(HaveLen(1))
+		Expect(pulls[0].AutoMerge).To(BeTrue())
+		Expect(pulls[0].MergeMethod).To(Equal("merge"))
+		Expect(pulls[0].State).To(Equal("open"))
+	})
+})
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("PullRequest cleanup", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		api        *fakeGiteaAPI
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		api = newFakeGiteaAPI("gitea-token")
+		server, barePath, secretName = startGitServerFixture("gitea-token", api)
+	})
+
+	// createAndDelete opens a pull request through the operator and deletes the resource again
+	createAndDelete := func(name string, policy gitv1.CleanupPolicy) {
+		pr := &gitv1.PullRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.PullRequestSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Provider:      gitv1.GitProviderGitea,
+				BaseBranch:    "main",
+				HeadBranch:    name,
+				Title:         "Update config",
+				AuthSecretRef: secretName,
+				CleanupPolicy: policy,
+				Files: []gitv1.File{
+					{Path: "config.txt", Content: "updated"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pr)).To(Succeed())
+
+		key := types.NamespacedName{Name: name, Namespace: namespace}
+		Eventually(func() gitv1.PullRequestPhase {
+			if err := k8sClient.Get(ctx, key, pr); err != nil {
+				return ""
+			}
+			return pr.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.PullRequestPhaseCreated))
+
+		Expect(k8sClient.Delete(ctx, pr)).To(Succeed())
+		Eventually(func() bool {
+			return errors.IsNotFound(k8sClient.Get(ctx, key, &gitv1.PullRequest{}))
+		}, timeout, interval).Should(BeTrue())
+	}
+
+	eventMessages := func(name string) func() []string {
+		return func() []string {
+			events := &corev1.EventList{}
+			if err := k8sClient.List(ctx, events, client.InNamespace(namespace)); err != nil {
+				return nil
+			}
+			var messages []string
+			for _, event := range events.Items {
+				if event.InvolvedObject.Kind == "PullRequest" && event.InvolvedObject.Name == name {
+					messages = append(messages, event.Reason+": "+event.Message)
+				}
+			}
+			return messages
+		}
+	}
+
+	It("should close the pull request and delete the head branch", func() {
+		createAndDelete("cleanup-delete-branch", gitv1.CleanupPolicyCloseAndDeleteBranch)
+
+		Expect(api.PullRequests()[0].State).To(Equal("closed"))
+		_, err := readBranchHead(barePath, "cleanup-delete-branch")
+		Expect(err).To(HaveOccurred())
+
+		Eventually(eventMessages("cleanup-delete-branch"), timeout, interval).Should(ContainElement(
+			"CleanedUp: Closed pull request #1, deleted head branch cleanup-delete-branch"))
+	})
+
+	It("should close the pull request but keep the head branch", func() {
+		createAndDelete("cleanup-close", gitv1.CleanupPolicyClose)
+
+		Expect(api.PullRequests()[0].State).To(Equal("closed"))
+		_, err := readBranchHead(barePath, "cleanup-close")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should leave the pull request open by default", func() {
+		createAndDelete("cleanup-retain", "")
+
+		Expect(api.PullRequests()[0].State).To(Equal("open"))
+		_, err := readBranchHead(barePath, "cleanup-retain")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
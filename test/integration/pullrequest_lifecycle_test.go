@@ -0,0 +1,112 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("PullRequest lifecycle", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		api        *fakeGiteaAPI
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		api = newFakeGiteaAPI("gitea-token")
+		api.headSHA = func(ref string) string {
+			sha, _ := readBranchHead(barePath, ref)
+			return sha
+		}
+		server, barePath, secretName = startGitServerFixture("gitea-token", api)
+	})
+
+	createPullRequest := func(name, headBranch string) {
+		pr := &gitv1.PullRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.PullRequestSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Provider:      gitv1.GitProviderGitea,
+				BaseBranch:    "main",
+				HeadBranch:    headBranch,
+				Title:         "Update config",
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: "config.txt", Content: "updated"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pr)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), pr)
+		})
+	}
+
+	getStatus := func(name string) func() gitv1.PullRequestStatus {
+		return func() gitv1.PullRequestStatus {
+			pr := &gitv1.PullRequest{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, pr); err != nil {
+				return gitv1.PullRequestStatus{}
+			}
+			return pr.Status
+		}
+	}
+
+	It("should report reviews and checks and move to Merged once merged", func() {
+		createPullRequest("lifecycle-merged", "lifecycle-merged")
+		Eventually(getStatus("lifecycle-merged"), timeout, interval).Should(HaveField("Phase", gitv1.PullRequestPhaseCreated))
+
+		head, err := readBranchHead(barePath, "lifecycle-merged")
+		Expect(err).NotTo(HaveOccurred())
+		api.SetCommitStatus(head, "pending")
+		api.Review(1, "alice", "APPROVED")
+
+		Eventually(getStatus("lifecycle-merged"), timeout, interval).Should(And(
+			HaveField("Phase", gitv1.PullRequestPhaseCreated),
+			HaveField("MergeableState", gitv1.MergeableStateMergeable),
+			HaveField("ReviewDecision", gitv1.ReviewDecisionApproved),
+			HaveField("CheckStatus", gitv1.CheckStatusPending),
+		))
+
+		api.SetCommitStatus(head, "success")
+		api.Merge(1, "0123456789abcdef0123456789abcdef01234567")
+
+		Eventually(getStatus("lifecycle-merged"), timeout, interval).Should(And(
+			HaveField("Phase", gitv1.PullRequestPhaseMerged),
+			HaveField("MergeCommitSHA", "0123456789abcdef0123456789abcdef01234567"),
+			HaveField("CheckStatus", gitv1.CheckStatusSuccess),
+			HaveField("PullRequestNumber", 1),
+		))
+	})
+
+	It("should move to Closed when the pull request is closed without merging", func() {
+		createPullRequest("lifecycle-closed", "lifecycle-closed")
+		Eventually(getStatus("lifecycle-closed"), timeout, interval).Should(HaveField("Phase", gitv1.PullRequestPhaseCreated))
+
+		api.Review(1, "bob", "REQUEST_CHANGES")
+		api.Close(1)
+
+		Eventually(getStatus("lifecycle-closed"), timeout, interval).Should(And(
+			HaveField("Phase", gitv1.PullRequestPhaseClosed),
+			HaveField("ReviewDecision", gitv1.ReviewDecisionChangesRequested),
+			HaveField("MergeCommitSHA", ""),
+		))
+	})
+})
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("PullRequest metadata", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		api        *fakeGiteaAPI
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		api = newFakeGiteaAPI("gitea-token")
+		server, _, secretName = startGitServerFixture("gitea-token", api)
+	})
+
+	createPullRequest := func(name string, mutate func(spec *gitv1.PullRequestSpec)) *gitv1.PullRequest {
+		pr := &gitv1.PullRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.PullRequestSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Provider:      gitv1.GitProviderGitea,
+				BaseBranch:    "main",
+				HeadBranch:    name,
+				Title:         "Update config",
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: "config.txt", Content: "updated"},
+				},
+			},
+		}
+		mutate(&pr.Spec)
+		Expect(k8sClient.Create(ctx, pr)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), pr)
+		})
+
+		Eventually(func() gitv1.PullRequestPhase {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, pr); err != nil {
+				return ""
+			}
+			return pr.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.PullRequestPhaseCreated))
+		return pr
+	}
+
+	It("should open a draft and apply labels, reviewers, assignees and the milestone", func() {
+		pr := createPullRequest("metadata-applied", func(spec *gitv1.PullRequestSpec) {
+			spec.Draft = true
+			spec.Labels = []string{"automated", "config"}
+			spec.Reviewers = []string{"alice"}
+			spec.Assignees = []string{"bob"}
+			spec.Milestone = "v1.0"
+		})
+		Expect(pr.Status.Message).To(Equal("Pull request created successfully"))
+
+		pulls := api.PullRequests()
+		Expect(pulls).To(HaveLen(1))
+		Expect(pulls[0].Title).To(Equal("WIP: Update config"))
+		Expect(pulls[0].Labels).To(Equal([]string{"automated", "config"}))
+		Expect(pulls[0].RequestedReviewers).To(Equal([]string{"alice"}))
+		Expect(pulls[0].Assignees).To(Equal([]fakeGiteaUser{{Login: "bob"}}))
+		Expect(pulls[0].Milestone).To(Equal(int64(1)))
+	})
+
+	It("should report partial failures without losing the pull request number", func() {
+		pr := createPullRequest("metadata-partial", func(spec *gitv1.PullRequestSpec) {
+			spec.Labels = []string{"automated"}
+			spec.TeamReviewers = []string{"platform"}
+			spec.Milestone = "v9.9"
+		})
+		Expect(pr.Status.PullRequestNumber).To(Equal(1))
+		Expect(pr.Status.Message).To(HavePrefix("Pull request created successfully, but applying metadata failed"))
+		Expect(pr.Status.Message).To(ContainSubstring(`milestone "v9.9" not found`))
+		Expect(pr.Status.Message).To(ContainSubstring("failed to request reviewers"))
+
+		// The parts that could be applied are kept
+		Expect(api.PullRequests()[0].Labels).To(Equal([]string{"automated"}))
+	})
+})
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+)
+
+var _ = Describe("PullRequest upsert", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		secretName string
+		barePath   string
+		api        *fakeGiteaAPI
+		server     *httpGitServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		api = newFakeGiteaAPI("gitea-token")
+		server, barePath, secretName = startGitServerFixture("gitea-token", api)
+	})
+
+	createPullRequest := func(name, title, content string, upsert bool) {
+		pr := &gitv1.PullRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.PullRequestSpec{
+				Repository:    server.RepositoryURL("org/repo.git"),
+				Provider:      gitv1.GitProviderGitea,
+				BaseBranch:    "main",
+				HeadBranch:    "update-config",
+				Title:         title,
+				Body:          "Body of " + title,
+				AuthSecretRef: secretName,
+				Upsert:        upsert,
+				Files: []gitv1.File{
+					{Path: "config.txt", Content: content},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pr)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), pr)
+		})
+	}
+
+	waitForPhase := func(name string, phase gitv1.PullRequestPhase) *gitv1.PullRequest {
+		pr := &gitv1.PullRequest{}
+		Eventually(func() gitv1.PullRequestPhase {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, pr); err != nil {
+				return ""
+			}
+			return pr.Status.Phase
+		}, timeout, interval).Should(Equal(phase))
+		return pr
+	}
+
+	It("should update the open pull request and force-update the head branch", func() {
+		createPullRequest("upsert-first", "First title", "first", true)
+		first := waitForPhase("upsert-first", gitv1.PullRequestPhaseCreated)
+		Expect(first.Status.PullRequestNumber).To(Equal(1))
+		Expect(first.Status.Message).To(Equal("Pull request created successfully"))
+
+		firstHead, err := readBranchHead(barePath, "update-config")
+		Expect(err).NotTo(HaveOccurred())
+
+		createPullRequest("upsert-second", "Second title", "second", true)
+		second := waitForPhase("upsert-second", gitv1.PullRequestPhaseCreated)
+		Expect(second.Status.PullRequestNumber).To(Equal(1))
+		Expect(second.Status.Message).To(Equal("Existing pull request updated successfully"))
+
+		pulls := api.PullRequests()
+		Expect(pulls).To(HaveLen(1))
+		Expect(pulls[0].Title).To(Equal("Second title"))
+		Expect(pulls[0].Body).To(Equal("Body of Second title"))
+
+		// The head branch is rebuilt on main rather than stacked on the previous commit
+		content, secondHead, err := readCommittedFile(barePath, "update-config", "config.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("second"))
+		Expect(secondHead).NotTo(Equal(firstHead))
+
+		repo, err := git.PlainOpen(barePath)
+		Expect(err).NotTo(HaveOccurred())
+		commit, err := repo.CommitObject(plumbing.NewHash(secondHead))
+		Expect(err).NotTo(HaveOccurred())
+		mainHead, err := readBranchHead(barePath, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commit.ParentHashes).To(ConsistOf(plumbing.NewHash(mainHead)))
+	})
+
+	It("should fail instead of ignoring a diverged head branch without upsert", func() {
+		createPullRequest("plain-first", "First title", "first", false)
+		waitForPhase("plain-first", gitv1.PullRequestPhaseCreated)
+
+		createPullRequest("plain-second", "Second title", "second", false)
+		second := waitForPhase("plain-second", gitv1.PullRequestPhaseFailed)
+		Expect(second.Status.Message).To(ContainSubstring("spec.upsert"))
+
+		content, _, err := readCommittedFile(barePath, "update-config", "config.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("first"))
+	})
+})
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitv1 "github.com/mihaigalos/git-change-operator/api/v1"
+	"github.com/mihaigalos/git-change-operator/pkg/gitauth"
+)
+
+var _ = Describe("SSH transport", func() {
+	const (
+		namespace = "default"
+		timeout   = time.Second * 30
+		interval  = time.Millisecond * 250
+	)
+
+	var (
+		ctx        context.Context
+		root       string
+		barePath   string
+		server     *sshGitServer
+		privateKey []byte
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		root, err = os.MkdirTemp("", "ssh-git-server-")
+		Expect(err).NotTo(HaveOccurred())
+
+		barePath, err = newBareRepository(root, "repo.git")
+		Expect(err).NotTo(HaveOccurred())
+
+		var publicKey ssh.PublicKey
+		privateKey, publicKey, err = newSSHClientKey()
+		Expect(err).NotTo(HaveOccurred())
+
+		server, err = startSSHGitServer(root, publicKey)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(root)
+	})
+
+	createSecret := func(name string, data map[string][]byte) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       data,
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), secret)
+		})
+	}
+
+	createGitCommit := func(name, secretName, path, content string) *gitv1.GitCommit {
+		gitCommit := &gitv1.GitCommit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: gitv1.GitCommitSpec{
+				Repository:    server.URL("repo.git"),
+				Branch:        "main",
+				CommitMessage: "Commit over SSH",
+				AuthSecretRef: secretName,
+				Files: []gitv1.File{
+					{Path: path, Content: content},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gitCommit)).To(Succeed())
+		DeferCleanup(func() {
+			k8sClient.Delete(context.Background(), gitCommit)
+		})
+		return gitCommit
+	}
+
+	It("should push a commit using a private key and known_hosts", func() {
+		createSecret("ssh-credentials", map[string][]byte{
+			gitauth.SSHPrivateKeyKey: privateKey,
+			gitauth.SSHKnownHostsKey: []byte(server.KnownHosts()),
+		})
+		createGitCommit("ssh-gitcommit", "ssh-credentials", "ssh/hello.txt", "hello over ssh")
+
+		gitCommit := &gitv1.GitCommit{}
+		Eventually(func() gitv1.GitCommitPhase {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "ssh-gitcommit", Namespace: namespace}, gitCommit); err != nil {
+				return ""
+			}
+			return gitCommit.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseCommitted))
+
+		content, sha, err := readCommittedFile(barePath, "main", "ssh/hello.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("hello over ssh"))
+		Expect(sha).To(Equal(gitCommit.Status.CommitSHA))
+	})
+
+	It("should refuse to connect without known_hosts", func() {
+		createSecret("ssh-credentials-no-known-hosts", map[string][]byte{
+			gitauth.SSHPrivateKeyKey: privateKey,
+		})
+		createGitCommit("ssh-gitcommit-no-known-hosts", "ssh-credentials-no-known-hosts", "ssh/hello.txt", "hello")
+
+		gitCommit := &gitv1.GitCommit{}
+		Eventually(func() gitv1.GitCommitPhase {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "ssh-gitcommit-no-known-hosts", Namespace: namespace}, gitCommit); err != nil {
+				return ""
+			}
+			return gitCommit.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseFailed))
+		Expect(gitCommit.Status.Message).To(ContainSubstring(gitauth.SSHKnownHostsKey))
+	})
+
+	It("should reject a server whose host key does not match known_hosts", func() {
+		_, otherHostKey, err := newSSHClientKey()
+		Expect(err).NotTo(HaveOccurred())
+		wrongKnownHosts := knownhosts.Line([]string{knownhosts.Normalize(server.listener.Addr().String())}, otherHostKey)
+
+		createSecret("ssh-credentials-wrong-host", map[string][]byte{
+			gitauth.SSHPrivateKeyKey: privateKey,
+			gitauth.SSHKnownHostsKey: []byte(wrongKnownHosts),
+		})
+		createGitCommit("ssh-gitcommit-wrong-host", "ssh-credentials-wrong-host", "ssh/hello.txt", "hello")
+
+		gitCommit := &gitv1.GitCommit{}
+		Eventually(func() gitv1.GitCommitPhase {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "ssh-gitcommit-wrong-host", Namespace: namespace}, gitCommit); err != nil {
+				return ""
+			}
+			return gitCommit.Status.Phase
+		}, timeout, interval).Should(Equal(gitv1.GitCommitPhaseFailed))
+
+		_, _, err = readCommittedFile(barePath, "main", "ssh/hello.txt")
+		Expect(err).To(HaveOccurred())
+	})
+})